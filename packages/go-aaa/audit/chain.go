@@ -0,0 +1,77 @@
+package audit
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+)
+
+// Payload keys added to each event when hash chaining is enabled.
+const (
+	// PrevHashKey holds the hash of the previously emitted event (or the seed).
+	PrevHashKey = "prev_hash"
+	// HashKey holds the SHA-256 hash of this event chained to PrevHashKey.
+	HashKey = "hash"
+)
+
+// hashChain maintains the running head of a tamper-evident event chain.
+type hashChain struct {
+	head string
+}
+
+// link stamps payload with the current head as its previous hash, computes the
+// payload's chained hash, and advances the head.
+func (c *hashChain) link(payload map[string]interface{}) error {
+	sum, err := chainHash(c.head, payload)
+	if err != nil {
+		return err
+	}
+	payload[PrevHashKey] = c.head
+	payload[HashKey] = sum
+	c.head = sum
+	return nil
+}
+
+// chainHash returns the hex SHA-256 of prevHash followed by the canonical JSON
+// encoding of payload, excluding the chain fields themselves. encoding/json sorts
+// map keys, which makes the encoding deterministic.
+func chainHash(prevHash string, payload map[string]interface{}) (string, error) {
+	canonical := make(map[string]interface{}, len(payload))
+	for k, v := range payload {
+		if k == PrevHashKey || k == HashKey {
+			continue
+		}
+		canonical[k] = v
+	}
+	data, err := json.Marshal(canonical)
+	if err != nil {
+		return "", fmt.Errorf("audit: canonicalize event for hash chain: %w", err)
+	}
+	h := sha256.New()
+	h.Write([]byte(prevHash))
+	h.Write(data)
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// VerifyChain checks that events form a continuous hash chain starting from seed.
+// Each event must carry the previous event's hash in PrevHashKey and a HashKey that
+// matches its recomputed hash. It returns the head hash on success, or an error
+// identifying the first event that fails verification.
+func VerifyChain(seed string, events []map[string]interface{}) (string, error) {
+	prev := seed
+	for i, ev := range events {
+		if got, _ := ev[PrevHashKey].(string); got != prev {
+			return "", fmt.Errorf("audit: event %d: prev_hash %q does not match expected %q", i, got, prev)
+		}
+		want, err := chainHash(prev, ev)
+		if err != nil {
+			return "", fmt.Errorf("audit: event %d: %w", i, err)
+		}
+		if got, _ := ev[HashKey].(string); got != want {
+			return "", fmt.Errorf("audit: event %d: hash mismatch", i)
+		}
+		prev = want
+	}
+	return prev, nil
+}
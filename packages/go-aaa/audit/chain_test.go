@@ -0,0 +1,128 @@
+package audit
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/penguintechinc/penguin-libs/packages/go-common/logging"
+)
+
+func emitChain(t *testing.T, seed string, n int) ([]map[string]interface{}, *Emitter) {
+	t.Helper()
+	var received []map[string]interface{}
+	sink := logging.NewCallbackSink(func(event map[string]interface{}) {
+		received = append(received, event)
+	})
+	emitter := NewEmitterWithOptions([]logging.Sink{sink}, WithHashChain(seed))
+	for i := 0; i < n; i++ {
+		if err := emitter.Emit(NewAuditEvent(EventAuthSuccess, "user-1", "login", "/auth", OutcomeSuccess)); err != nil {
+			t.Fatalf("emit %d: %v", i, err)
+		}
+	}
+	return received, emitter
+}
+
+func TestHashChain_Continuous(t *testing.T) {
+	events, emitter := emitChain(t, "seed", 3)
+
+	if events[0][PrevHashKey] != "seed" {
+		t.Errorf("expected first prev_hash to be the seed, got %v", events[0][PrevHashKey])
+	}
+	for i := 1; i < len(events); i++ {
+		if events[i][PrevHashKey] != events[i-1][HashKey] {
+			t.Errorf("event %d prev_hash does not match event %d hash", i, i-1)
+		}
+	}
+	if emitter.HeadHash() != events[2][HashKey] {
+		t.Errorf("expected head hash %v, got %q", events[2][HashKey], emitter.HeadHash())
+	}
+
+	head, err := VerifyChain("seed", events)
+	if err != nil {
+		t.Fatalf("expected chain to verify, got %v", err)
+	}
+	if head != emitter.HeadHash() {
+		t.Errorf("expected VerifyChain head %q, got %q", emitter.HeadHash(), head)
+	}
+}
+
+func TestHashChain_Deterministic(t *testing.T) {
+	payload := NewAuditEvent(EventAuthSuccess, "u", "a", "r", OutcomeSuccess).ToMap()
+	h1, err := chainHash("seed", payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h2, _ := chainHash("seed", payload)
+	if h1 != h2 {
+		t.Error("expected identical input to produce identical hashes")
+	}
+	if h3, _ := chainHash("other", payload); h3 == h1 {
+		t.Error("expected different previous hash to change the result")
+	}
+}
+
+func TestHashChain_TamperBreaksVerification(t *testing.T) {
+	events, _ := emitChain(t, "seed", 4)
+	events[1]["subject"] = "attacker"
+
+	if _, err := VerifyChain("seed", events); err == nil {
+		t.Fatal("expected verification to fail after altering an event")
+	}
+	if _, err := VerifyChain("seed", events[:1]); err != nil {
+		t.Errorf("expected events before the tampered one to verify, got %v", err)
+	}
+
+	// Re-hashing the altered event still breaks the link to the next one.
+	rehashed, _ := chainHash(events[1][PrevHashKey].(string), events[1])
+	events[1][HashKey] = rehashed
+	if _, err := VerifyChain("seed", events); err == nil {
+		t.Error("expected verification to fail for events after a re-hashed event")
+	}
+}
+
+func TestHashChain_DisabledByDefault(t *testing.T) {
+	var received map[string]interface{}
+	emitter := NewEmitter(logging.NewCallbackSink(func(event map[string]interface{}) { received = event }))
+	_ = emitter.Emit(NewAuditEvent(EventAuthSuccess, "u", "a", "r", OutcomeSuccess))
+
+	if _, ok := received[HashKey]; ok {
+		t.Error("expected no hash field without WithHashChain")
+	}
+	if emitter.HeadHash() != "" {
+		t.Errorf("expected empty head hash, got %q", emitter.HeadHash())
+	}
+}
+
+func TestHashChain_ConcurrentEmitWritesInChainOrder(t *testing.T) {
+	var (
+		mu       sync.Mutex
+		received []map[string]interface{}
+	)
+	sink := logging.NewCallbackSink(func(event map[string]interface{}) {
+		// Yield so that unordered writes would interleave.
+		time.Sleep(time.Millisecond)
+		mu.Lock()
+		received = append(received, event)
+		mu.Unlock()
+	})
+	emitter := NewEmitterWithOptions([]logging.Sink{sink}, WithHashChain("seed"), WithRetry(fastRetry(0)))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_ = emitter.Emit(NewAuditEvent(EventAuthSuccess, "user-1", "login", "/auth", OutcomeSuccess))
+		}()
+	}
+	wg.Wait()
+
+	head, err := VerifyChain("seed", received)
+	if err != nil {
+		t.Fatalf("events as written do not verify: %v", err)
+	}
+	if head != emitter.HeadHash() {
+		t.Errorf("verified head %q, want %q", head, emitter.HeadHash())
+	}
+}
@@ -1,14 +1,37 @@
 package audit
 
 import (
+	"context"
 	"fmt"
+	"sync"
 
+	"github.com/penguintechinc/penguin-libs/packages/go-aaa/authz"
 	"github.com/penguintechinc/penguin-libs/packages/go-common/logging"
 )
 
 // Emitter fans out audit events to one or more logging Sinks.
 type Emitter struct {
 	sinks []logging.Sink
+	// writeMu serializes linking and writing when hash chaining is enabled, so
+	// sinks receive events in chain order. mu only guards the chain head, so
+	// HeadHash never waits on a sink.
+	writeMu sync.Mutex
+	mu      sync.Mutex
+	chain   *hashChain
+	retry   *RetryConfig
+}
+
+// EmitterOption is a functional option that configures an Emitter.
+type EmitterOption func(*Emitter)
+
+// WithHashChain enables tamper-evident hash chaining. Every emitted event carries
+// a PrevHashKey and HashKey entry, where the hash covers the canonical event plus
+// the previous hash. seed is used as the previous hash of the first event; pass a
+// previously checkpointed HeadHash to continue an existing chain.
+func WithHashChain(seed string) EmitterOption {
+	return func(e *Emitter) {
+		e.chain = &hashChain{head: seed}
+	}
 }
 
 // NewEmitter creates an Emitter that writes to the provided sinks.
@@ -17,10 +40,52 @@ func NewEmitter(sinks ...logging.Sink) *Emitter {
 	return &Emitter{sinks: sinks}
 }
 
+// NewEmitterWithOptions creates an Emitter that writes to the provided sinks and
+// applies the given options.
+func NewEmitterWithOptions(sinks []logging.Sink, opts ...EmitterOption) *Emitter {
+	e := NewEmitter(sinks...)
+	for _, o := range opts {
+		o(e)
+	}
+	return e
+}
+
+// HeadHash returns the hash of the most recently emitted event, or the seed when
+// nothing has been emitted yet. It returns an empty string when hash chaining is
+// not enabled. Persist this value to checkpoint the chain.
+func (e *Emitter) HeadHash() string {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.chain == nil {
+		return ""
+	}
+	return e.chain.head
+}
+
 // Emit converts the event to a map and writes it to every registered sink.
 // Errors from individual sinks are collected and returned as a combined error.
+// When retry is enabled, each sink is retried independently before its error is
+// reported. When hash chaining is enabled, events are linked and written in
+// emission order, so concurrent Emit calls are serialized.
 func (e *Emitter) Emit(event AuditEvent) error {
+	return e.emit(context.Background(), event)
+}
+
+func (e *Emitter) emit(ctx context.Context, event AuditEvent) error {
 	payload := event.ToMap()
+	if e.chain != nil {
+		e.writeMu.Lock()
+		defer e.writeMu.Unlock()
+		e.mu.Lock()
+		err := e.chain.link(payload)
+		e.mu.Unlock()
+		if err != nil {
+			return err
+		}
+	}
+	if e.retry != nil {
+		return e.writeConcurrently(ctx, payload)
+	}
 	var errs []error
 	for _, s := range e.sinks {
 		if err := s.Write(payload); err != nil {
@@ -30,6 +95,53 @@ func (e *Emitter) Emit(event AuditEvent) error {
 	return joinErrors(errs)
 }
 
+// writeConcurrently writes payload to every sink in parallel, retrying each sink
+// independently, and waits for all of them to finish. Each sink receives its
+// own shallow copy of payload, so sinks that modify it do not race.
+func (e *Emitter) writeConcurrently(ctx context.Context, payload map[string]interface{}) error {
+	results := make([]error, len(e.sinks))
+	var wg sync.WaitGroup
+	for i, s := range e.sinks {
+		wg.Add(1)
+		eventCopy := make(map[string]interface{}, len(payload))
+		for k, v := range payload {
+			eventCopy[k] = v
+		}
+		go func(i int, s logging.Sink) {
+			defer wg.Done()
+			results[i] = writeWithRetry(ctx, s, eventCopy, *e.retry)
+		}(i, s)
+	}
+	wg.Wait()
+
+	var errs []error
+	for _, err := range results {
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return joinErrors(errs)
+}
+
+// EmitContext enriches event from ctx and then emits it. The subject and tenant are
+// taken from the authz Claims in ctx and the request ID from the logging correlation
+// ID. Fields already set on the event are never overwritten. When retry is
+// enabled, retries stop once ctx is done.
+func (e *Emitter) EmitContext(ctx context.Context, event AuditEvent) error {
+	if claims := authz.ClaimsFromContext(ctx); claims != nil {
+		if event.Subject == "" {
+			event.Subject = claims.Sub
+		}
+		if event.Tenant == "" {
+			event.Tenant = claims.Tenant
+		}
+	}
+	if event.RequestID == "" {
+		event.RequestID = logging.CorrelationIDFromContext(ctx)
+	}
+	return e.emit(ctx, event)
+}
+
 // Close flushes and closes every registered sink.
 // Errors from individual sinks are collected and returned as a combined error.
 func (e *Emitter) Close() error {
@@ -1,9 +1,12 @@
 package audit
 
 import (
+	"context"
 	"errors"
 	"testing"
 
+	"github.com/penguintechinc/penguin-libs/packages/go-aaa/authn"
+	"github.com/penguintechinc/penguin-libs/packages/go-aaa/authz"
 	"github.com/penguintechinc/penguin-libs/packages/go-common/logging"
 )
 
@@ -105,3 +108,58 @@ func (s *closeTrackingSink) Close() error {
 	s.onClose()
 	return nil
 }
+
+func TestEmitter_EmitContext_EnrichesFromContext(t *testing.T) {
+	var received map[string]interface{}
+	emitter := NewEmitter(logging.NewCallbackSink(func(event map[string]interface{}) { received = event }))
+
+	ctx := authz.ContextWithClaims(context.Background(), &authn.Claims{Sub: "user-9", Tenant: "acme"})
+	ctx = logging.ContextWithCorrelationID(ctx, "corr-1")
+
+	if err := emitter.EmitContext(ctx, NewAuditEvent(EventTokenIssued, "", "issue", "/token", OutcomeSuccess)); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if received["subject"] != "user-9" {
+		t.Errorf("expected subject user-9, got %v", received["subject"])
+	}
+	if received["tenant"] != "acme" {
+		t.Errorf("expected tenant acme, got %v", received["tenant"])
+	}
+	if received["request_id"] != "corr-1" {
+		t.Errorf("expected request_id corr-1, got %v", received["request_id"])
+	}
+}
+
+func TestEmitter_EmitContext_RespectsPresetFields(t *testing.T) {
+	var received map[string]interface{}
+	emitter := NewEmitter(logging.NewCallbackSink(func(event map[string]interface{}) { received = event }))
+
+	ctx := authz.ContextWithClaims(context.Background(), &authn.Claims{Sub: "user-9", Tenant: "acme"})
+	ctx = logging.ContextWithCorrelationID(ctx, "corr-1")
+	event := NewAuditEvent(EventTokenIssued, "admin", "issue", "/token", OutcomeSuccess,
+		WithTenant("globex"), WithRequestID("req-5"))
+
+	_ = emitter.EmitContext(ctx, event)
+	if received["subject"] != "admin" {
+		t.Errorf("expected preset subject admin, got %v", received["subject"])
+	}
+	if received["tenant"] != "globex" {
+		t.Errorf("expected preset tenant globex, got %v", received["tenant"])
+	}
+	if received["request_id"] != "req-5" {
+		t.Errorf("expected preset request_id req-5, got %v", received["request_id"])
+	}
+}
+
+func TestEmitter_EmitContext_NoClaims(t *testing.T) {
+	var received map[string]interface{}
+	emitter := NewEmitter(logging.NewCallbackSink(func(event map[string]interface{}) { received = event }))
+
+	_ = emitter.EmitContext(context.Background(), NewAuditEvent(EventAuthFailure, "", "login", "/auth", OutcomeFailure))
+	if received["subject"] != "" {
+		t.Errorf("expected empty subject without claims, got %v", received["subject"])
+	}
+	if _, ok := received["tenant"]; ok {
+		t.Error("expected tenant to be omitted without claims")
+	}
+}
@@ -7,6 +7,8 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+
+	"github.com/penguintechinc/penguin-libs/packages/go-common/logging"
 )
 
 // EventType classifies an audit event by the action that was performed.
@@ -52,11 +54,83 @@ type AuditEvent struct {
 	Resource string `json:"resource"`
 	// Outcome indicates whether the action succeeded or failed.
 	Outcome Outcome `json:"outcome"`
+	// Tenant identifies the tenant the action was performed in, when known.
+	Tenant string `json:"tenant,omitempty"`
+	// SourceIP is the network address of the client, when known.
+	SourceIP string `json:"source_ip,omitempty"`
+	// UserAgent is the client's User-Agent header, when known.
+	UserAgent string `json:"user_agent,omitempty"`
+	// RequestID correlates the event with a specific request (e.g., X-Request-ID).
+	RequestID string `json:"request_id,omitempty"`
+	// DurationMs is how long the audited action took, in milliseconds, when measured.
+	DurationMs float64 `json:"duration_ms,omitempty"`
+	// Details carries event-specific data such as the scopes checked or old and new
+	// values. Sensitive entries are redacted by ToMap before emission.
+	Details map[string]interface{} `json:"details,omitempty"`
+}
+
+// EventOption is a functional option that sets optional fields on an AuditEvent.
+type EventOption func(*AuditEvent)
+
+// WithTenant sets the tenant identifier on the event.
+func WithTenant(tenant string) EventOption {
+	return func(e *AuditEvent) { e.Tenant = tenant }
+}
+
+// WithSourceIP sets the client network address on the event.
+func WithSourceIP(ip string) EventOption {
+	return func(e *AuditEvent) { e.SourceIP = ip }
+}
+
+// WithUserAgent sets the client User-Agent on the event.
+func WithUserAgent(ua string) EventOption {
+	return func(e *AuditEvent) { e.UserAgent = ua }
+}
+
+// WithRequestID sets the request or correlation identifier on the event.
+func WithRequestID(id string) EventOption {
+	return func(e *AuditEvent) { e.RequestID = id }
+}
+
+// WithDuration records how long the audited action took.
+func WithDuration(d time.Duration) EventOption {
+	return func(e *AuditEvent) { e.DurationMs = float64(d) / float64(time.Millisecond) }
+}
+
+// Timer measures the wall time of an action for manual audit emission.
+type Timer struct {
+	start time.Time
+}
+
+// StartTimer starts timing an action.
+func StartTimer() Timer {
+	return Timer{start: time.Now()}
+}
+
+// Stop returns an EventOption recording the time elapsed since StartTimer.
+func (t Timer) Stop() EventOption {
+	return WithDuration(time.Since(t.start))
+}
+
+// WithDetails merges the given entries into the event's Details map.
+func WithDetails(details map[string]interface{}) EventOption {
+	return func(e *AuditEvent) {
+		if len(details) == 0 {
+			return
+		}
+		if e.Details == nil {
+			e.Details = make(map[string]interface{}, len(details))
+		}
+		for k, v := range details {
+			e.Details[k] = v
+		}
+	}
 }
 
 // NewAuditEvent creates a new AuditEvent with a generated UUID and the current UTC time.
-func NewAuditEvent(eventType EventType, subject, action, resource string, outcome Outcome) AuditEvent {
-	return AuditEvent{
+// Optional request metadata can be attached with EventOption values.
+func NewAuditEvent(eventType EventType, subject, action, resource string, outcome Outcome, opts ...EventOption) AuditEvent {
+	e := AuditEvent{
 		ID:        uuid.New().String(),
 		Timestamp: time.Now().UTC(),
 		Type:      eventType,
@@ -65,11 +139,18 @@ func NewAuditEvent(eventType EventType, subject, action, resource string, outcom
 		Resource:  resource,
 		Outcome:   outcome,
 	}
+	for _, o := range opts {
+		o(&e)
+	}
+	return e
 }
 
 // ToMap converts the AuditEvent to a map suitable for passing to a logging Sink.
+// Optional request metadata fields and the duration are omitted when empty. Details are included
+// under the "details" key when non-empty, with sensitive values redacted via the
+// logging sanitizer.
 func (e AuditEvent) ToMap() map[string]interface{} {
-	return map[string]interface{}{
+	m := map[string]interface{}{
 		"id":        e.ID,
 		"timestamp": e.Timestamp.Format(time.RFC3339Nano),
 		"type":      string(e.Type),
@@ -78,4 +159,37 @@ func (e AuditEvent) ToMap() map[string]interface{} {
 		"resource":  e.Resource,
 		"outcome":   string(e.Outcome),
 	}
+	if e.Tenant != "" {
+		m["tenant"] = e.Tenant
+	}
+	if e.SourceIP != "" {
+		m["source_ip"] = e.SourceIP
+	}
+	if e.UserAgent != "" {
+		m["user_agent"] = e.UserAgent
+	}
+	if e.RequestID != "" {
+		m["request_id"] = e.RequestID
+	}
+	if e.DurationMs != 0 {
+		m["duration_ms"] = e.DurationMs
+	}
+	if len(e.Details) > 0 {
+		m["details"] = sanitizeDetails(e.Details)
+	}
+	return m
+}
+
+// sanitizeDetails returns a copy of details with every value passed through
+// logging.SanitizeValue. Nested maps are sanitized recursively.
+func sanitizeDetails(details map[string]interface{}) map[string]interface{} {
+	out := make(map[string]interface{}, len(details))
+	for k, v := range details {
+		sv := logging.SanitizeValue(k, v)
+		if nested, ok := sv.(map[string]interface{}); ok {
+			sv = sanitizeDetails(nested)
+		}
+		out[k] = sv
+	}
+	return out
 }
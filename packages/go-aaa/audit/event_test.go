@@ -110,3 +110,102 @@ func TestOutcomeConstants(t *testing.T) {
 		t.Error("OutcomeSuccess and OutcomeFailure must be distinct")
 	}
 }
+
+func TestNewAuditEvent_WithRequestMetadata(t *testing.T) {
+	event := NewAuditEvent(EventAuthSuccess, "u", "login", "/auth", OutcomeSuccess,
+		WithSourceIP("203.0.113.7"),
+		WithUserAgent("curl/8.0"),
+		WithRequestID("req-42"),
+	)
+	m := event.ToMap()
+
+	if m["source_ip"] != "203.0.113.7" {
+		t.Errorf("expected source_ip 203.0.113.7, got %v", m["source_ip"])
+	}
+	if m["user_agent"] != "curl/8.0" {
+		t.Errorf("expected user_agent curl/8.0, got %v", m["user_agent"])
+	}
+	if m["request_id"] != "req-42" {
+		t.Errorf("expected request_id req-42, got %v", m["request_id"])
+	}
+}
+
+func TestAuditEvent_ToMap_OmitsEmptyRequestMetadata(t *testing.T) {
+	m := NewAuditEvent(EventAuthSuccess, "u", "login", "/auth", OutcomeSuccess).ToMap()
+	for _, key := range []string{"source_ip", "user_agent", "request_id"} {
+		if _, ok := m[key]; ok {
+			t.Errorf("expected key %q to be omitted when empty", key)
+		}
+	}
+}
+
+func TestAuditEvent_ToMap_IncludesDetails(t *testing.T) {
+	event := NewAuditEvent(EventAuthzDenied, "u", "invoke", "/rpc/Foo", OutcomeFailure,
+		WithDetails(map[string]interface{}{"scopes_checked": []string{"report:read"}, "old_value": 3}),
+	)
+	details, ok := event.ToMap()["details"].(map[string]interface{})
+	if !ok {
+		t.Fatalf("expected details map in ToMap() output, got %T", event.ToMap()["details"])
+	}
+	if details["old_value"] != 3 {
+		t.Errorf("expected old_value 3, got %v", details["old_value"])
+	}
+	if _, ok := details["scopes_checked"]; !ok {
+		t.Error("expected scopes_checked in details")
+	}
+}
+
+func TestAuditEvent_ToMap_OmitsEmptyDetails(t *testing.T) {
+	m := NewAuditEvent(EventAuthSuccess, "u", "a", "r", OutcomeSuccess).ToMap()
+	if _, ok := m["details"]; ok {
+		t.Error("expected details to be omitted when empty")
+	}
+}
+
+func TestAuditEvent_ToMap_RedactsSensitiveDetails(t *testing.T) {
+	event := NewAuditEvent(EventTokenIssued, "u", "a", "r", OutcomeSuccess,
+		WithDetails(map[string]interface{}{
+			"access_token": "eyJhbGciOi",
+			"contact":      "alice@example.com",
+			"nested":       map[string]interface{}{"password": "hunter2", "field": "name"},
+		}),
+	)
+	details := event.ToMap()["details"].(map[string]interface{})
+
+	if details["access_token"] != "[REDACTED]" {
+		t.Errorf("expected access_token redacted, got %v", details["access_token"])
+	}
+	if details["contact"] != "[email]@example.com" {
+		t.Errorf("expected email masked, got %v", details["contact"])
+	}
+	nested := details["nested"].(map[string]interface{})
+	if nested["password"] != "[REDACTED]" {
+		t.Errorf("expected nested password redacted, got %v", nested["password"])
+	}
+	if nested["field"] != "name" {
+		t.Errorf("expected non-sensitive nested value preserved, got %v", nested["field"])
+	}
+	if event.Details["access_token"] != "eyJhbGciOi" {
+		t.Error("ToMap should not mutate the event's Details")
+	}
+}
+
+func TestTimer_PopulatesDuration(t *testing.T) {
+	timer := StartTimer()
+	time.Sleep(2 * time.Millisecond)
+	event := NewAuditEvent(EventTokenIssued, "u", "issue", "/token", OutcomeSuccess, timer.Stop())
+
+	if event.DurationMs < 2 {
+		t.Errorf("expected duration of at least 2ms, got %v", event.DurationMs)
+	}
+	if event.ToMap()["duration_ms"] != event.DurationMs {
+		t.Errorf("expected duration_ms in ToMap(), got %v", event.ToMap()["duration_ms"])
+	}
+}
+
+func TestAuditEvent_ToMap_OmitsZeroDuration(t *testing.T) {
+	m := NewAuditEvent(EventAuthSuccess, "u", "a", "r", OutcomeSuccess).ToMap()
+	if _, ok := m["duration_ms"]; ok {
+		t.Error("expected duration_ms to be omitted when zero")
+	}
+}
@@ -0,0 +1,66 @@
+package audit
+
+import (
+	"context"
+	"math"
+	"time"
+
+	"github.com/penguintechinc/penguin-libs/packages/go-common/logging"
+)
+
+// RetryConfig controls how Emitter retries a failed sink write. The backoff
+// starts at InitialBackoff and doubles after each attempt, up to MaxBackoff. A
+// MaxBackoff of zero or less means no cap.
+type RetryConfig struct {
+	MaxRetries     int
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+}
+
+// DefaultRetryConfig returns a RetryConfig with sensible defaults.
+func DefaultRetryConfig() RetryConfig {
+	return RetryConfig{
+		MaxRetries:     3,
+		InitialBackoff: 100 * time.Millisecond,
+		MaxBackoff:     5 * time.Second,
+	}
+}
+
+// WithRetry makes the Emitter retry each failed sink write with exponential
+// backoff. Sinks are written concurrently so that a sink being retried never
+// delays delivery to the others.
+func WithRetry(cfg RetryConfig) EmitterOption {
+	if cfg.MaxBackoff <= 0 {
+		cfg.MaxBackoff = math.MaxInt64
+	}
+	return func(e *Emitter) {
+		e.retry = &cfg
+	}
+}
+
+// writeWithRetry writes payload to s, retrying according to cfg. It returns the
+// last error when every attempt fails, and stops early once ctx is done.
+func writeWithRetry(ctx context.Context, s logging.Sink, payload map[string]interface{}, cfg RetryConfig) error {
+	var lastErr error
+	backoff := cfg.InitialBackoff
+	for attempt := 0; attempt <= cfg.MaxRetries; attempt++ {
+		if attempt > 0 {
+			timer := time.NewTimer(backoff)
+			select {
+			case <-timer.C:
+			case <-ctx.Done():
+				timer.Stop()
+				return lastErr
+			}
+			if backoff > cfg.MaxBackoff/2 {
+				backoff = cfg.MaxBackoff
+			} else {
+				backoff *= 2
+			}
+		}
+		if lastErr = s.Write(payload); lastErr == nil {
+			return nil
+		}
+	}
+	return lastErr
+}
@@ -0,0 +1,180 @@
+package audit
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/penguintechinc/penguin-libs/packages/go-common/logging"
+)
+
+func fastRetry(maxRetries int) RetryConfig {
+	return RetryConfig{
+		MaxRetries:     maxRetries,
+		InitialBackoff: time.Millisecond,
+		MaxBackoff:     5 * time.Millisecond,
+	}
+}
+
+func TestEmitter_Retry_EventualDelivery(t *testing.T) {
+	sink := &flakySink{failures: 2}
+	emitter := NewEmitterWithOptions([]logging.Sink{sink}, WithRetry(fastRetry(3)))
+
+	if err := emitter.Emit(NewAuditEvent(EventAuthSuccess, "u", "a", "r", OutcomeSuccess)); err != nil {
+		t.Fatalf("expected eventual delivery, got %v", err)
+	}
+	if sink.attempts != 3 {
+		t.Errorf("expected 3 attempts, got %d", sink.attempts)
+	}
+	if len(sink.delivered) != 1 {
+		t.Errorf("expected 1 delivered event, got %d", len(sink.delivered))
+	}
+}
+
+func TestEmitter_Retry_GivesUpAfterMaxRetries(t *testing.T) {
+	sink := &flakySink{failures: 10}
+	emitter := NewEmitterWithOptions([]logging.Sink{sink}, WithRetry(fastRetry(2)))
+
+	if err := emitter.Emit(NewAuditEvent(EventAuthSuccess, "u", "a", "r", OutcomeSuccess)); err == nil {
+		t.Fatal("expected error after exhausting retries")
+	}
+	if sink.attempts != 3 {
+		t.Errorf("expected 3 attempts (1 + 2 retries), got %d", sink.attempts)
+	}
+}
+
+func TestEmitter_Retry_FailingSinkDoesNotAffectOthers(t *testing.T) {
+	var mu sync.Mutex
+	delivered := 0
+	healthy := logging.NewCallbackSink(func(_ map[string]interface{}) {
+		mu.Lock()
+		delivered++
+		mu.Unlock()
+	})
+	failing := &errorSink{err: errors.New("down")}
+	emitter := NewEmitterWithOptions([]logging.Sink{failing, healthy}, WithRetry(fastRetry(2)))
+
+	if err := emitter.Emit(NewAuditEvent(EventAuthSuccess, "u", "a", "r", OutcomeSuccess)); err == nil {
+		t.Error("expected error from the failing sink")
+	}
+	if delivered != 1 {
+		t.Errorf("expected healthy sink to receive the event once, got %d", delivered)
+	}
+}
+
+func TestEmitter_Retry_ZeroMaxBackoffIsUncapped(t *testing.T) {
+	sink := &flakySink{failures: 3}
+	cfg := RetryConfig{MaxRetries: 3, InitialBackoff: 5 * time.Millisecond}
+	emitter := NewEmitterWithOptions([]logging.Sink{sink}, WithRetry(cfg))
+
+	start := time.Now()
+	if err := emitter.Emit(NewAuditEvent(EventAuthSuccess, "u", "a", "r", OutcomeSuccess)); err != nil {
+		t.Fatalf("expected eventual delivery, got %v", err)
+	}
+	// 5ms + 10ms + 20ms; a zero cap would skip every wait after the first.
+	if elapsed := time.Since(start); elapsed < 35*time.Millisecond {
+		t.Errorf("expected at least 35ms of backoff, took %v", elapsed)
+	}
+}
+
+func TestEmitter_Retry_SinksReceiveOwnPayload(t *testing.T) {
+	// Run with -race: sinks that modify a shared payload would race.
+	sinks := []logging.Sink{&mutatingSink{}, &mutatingSink{}, &mutatingSink{}}
+	emitter := NewEmitterWithOptions(sinks, WithRetry(fastRetry(0)))
+
+	if err := emitter.Emit(NewAuditEvent(EventAuthSuccess, "u", "a", "r", OutcomeSuccess)); err != nil {
+		t.Fatalf("Emit: %v", err)
+	}
+	for i, s := range sinks {
+		if got := s.(*mutatingSink).subject; got != "u" {
+			t.Errorf("sink %d: saw subject %v, want u", i, got)
+		}
+	}
+}
+
+func TestEmitter_Retry_StopsWhenContextDone(t *testing.T) {
+	sink := &flakySink{failures: 10}
+	cfg := RetryConfig{MaxRetries: 5, InitialBackoff: time.Hour, MaxBackoff: time.Hour}
+	emitter := NewEmitterWithOptions([]logging.Sink{sink}, WithRetry(cfg))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	done := make(chan error, 1)
+	go func() {
+		done <- emitter.EmitContext(ctx, NewAuditEvent(EventAuthSuccess, "u", "a", "r", OutcomeSuccess))
+	}()
+
+	select {
+	case err := <-done:
+		if err == nil {
+			t.Error("expected the last write error once ctx is done")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("retry did not stop when ctx was done")
+	}
+	if sink.attempts != 1 {
+		t.Errorf("expected 1 attempt before cancellation, got %d", sink.attempts)
+	}
+}
+
+func TestEmitter_HashChainLockReleasedDuringWrites(t *testing.T) {
+	release := make(chan struct{})
+	blocking := logging.NewCallbackSink(func(_ map[string]interface{}) { <-release })
+	emitter := NewEmitterWithOptions([]logging.Sink{blocking}, WithHashChain("seed"), WithRetry(fastRetry(0)))
+
+	done := make(chan error, 1)
+	go func() { done <- emitter.Emit(NewAuditEvent(EventAuthSuccess, "u", "a", "r", OutcomeSuccess)) }()
+
+	heads := make(chan string, 1)
+	go func() {
+		for emitter.HeadHash() == "seed" {
+			time.Sleep(time.Millisecond)
+		}
+		heads <- emitter.HeadHash()
+	}()
+	select {
+	case <-heads:
+	case <-time.After(5 * time.Second):
+		t.Fatal("HeadHash blocked while a sink write was in progress")
+	}
+	close(release)
+	if err := <-done; err != nil {
+		t.Fatalf("Emit: %v", err)
+	}
+}
+
+// mutatingSink records the subject it receives and then rewrites the event in place.
+type mutatingSink struct {
+	subject interface{}
+}
+
+func (s *mutatingSink) Write(event map[string]interface{}) error {
+	s.subject = event["subject"]
+	event["subject"] = "rewritten"
+	return nil
+}
+func (s *mutatingSink) Flush() error { return nil }
+func (s *mutatingSink) Close() error { return nil }
+
+// flakySink fails the first `failures` writes and then succeeds.
+type flakySink struct {
+	mu        sync.Mutex
+	failures  int
+	attempts  int
+	delivered []map[string]interface{}
+}
+
+func (s *flakySink) Write(event map[string]interface{}) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.attempts++
+	if s.attempts <= s.failures {
+		return errors.New("transient failure")
+	}
+	s.delivered = append(s.delivered, event)
+	return nil
+}
+func (s *flakySink) Flush() error { return nil }
+func (s *flakySink) Close() error { return nil }
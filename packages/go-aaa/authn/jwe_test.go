@@ -0,0 +1,125 @@
+package authn_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lestrrat-go/jwx/v2/jwa"
+	"github.com/lestrrat-go/jwx/v2/jwe"
+
+	"github.com/penguintechinc/penguin-libs/packages/go-aaa/authn"
+	"github.com/penguintechinc/penguin-libs/packages/go-aaa/crypto"
+)
+
+// encryptFor wraps payload in a compact JWE addressed to the current key of ks.
+func encryptFor(t *testing.T, ks crypto.KeyStore, alg jwa.KeyEncryptionAlgorithm, enc jwa.ContentEncryptionAlgorithm, payload string) string {
+	t.Helper()
+	set, err := ks.GetKeySet()
+	if err != nil {
+		t.Fatalf("GetKeySet: %v", err)
+	}
+	pub, ok := set.Key(set.Len() - 1)
+	if !ok {
+		t.Fatal("key set is empty")
+	}
+	out, err := jwe.Encrypt([]byte(payload), jwe.WithKey(alg, pub), jwe.WithContentEncryption(enc))
+	if err != nil {
+		t.Fatalf("jwe.Encrypt: %v", err)
+	}
+	return string(out)
+}
+
+// signedTestJWT issues an access token from a local provider.
+func signedTestJWT(t *testing.T) (string, crypto.KeyStore) {
+	t.Helper()
+	p, signer := newTestProvider(t)
+	now := time.Now()
+	ts, err := p.IssueTokenSet(context.Background(), &authn.Claims{
+		Sub: "user-alice", Iss: "https://issuer.example.com", Aud: []string{"my-app"},
+		Iat: now, Exp: now.Add(time.Hour),
+	})
+	if err != nil {
+		t.Fatalf("IssueTokenSet: %v", err)
+	}
+	return ts.AccessToken, signer
+}
+
+func newRPKeyStore(t *testing.T) crypto.KeyStore {
+	t.Helper()
+	ks, err := crypto.NewMemoryKeyStore(crypto.AlgorithmRS256)
+	if err != nil {
+		t.Fatalf("NewMemoryKeyStore: %v", err)
+	}
+	return ks
+}
+
+func TestJWEValidator_DecryptThenVerify(t *testing.T) {
+	signed, signer := signedTestJWT(t)
+	rpKeys := newRPKeyStore(t)
+	v, err := authn.NewJWEValidator(authn.JWEConfig{KeyStore: rpKeys})
+	if err != nil {
+		t.Fatalf("NewJWEValidator: %v", err)
+	}
+
+	encrypted := encryptFor(t, rpKeys, jwa.RSA_OAEP_256, jwa.A256GCM, signed)
+	if !authn.IsJWE(encrypted) || authn.IsJWE(signed) {
+		t.Fatal("IsJWE misclassified the test tokens")
+	}
+
+	inner, err := v.Decrypt(encrypted)
+	if err != nil {
+		t.Fatalf("Decrypt: %v", err)
+	}
+	if inner != signed {
+		t.Fatal("decrypted payload does not match the signed JWT")
+	}
+	if tok := parseWithKeyStore(t, signer, inner); tok.Subject() != "user-alice" {
+		t.Errorf("sub = %q, want user-alice", tok.Subject())
+	}
+}
+
+func TestJWEValidator_WrongKeyFails(t *testing.T) {
+	signed, _ := signedTestJWT(t)
+	v, err := authn.NewJWEValidator(authn.JWEConfig{KeyStore: newRPKeyStore(t)})
+	if err != nil {
+		t.Fatalf("NewJWEValidator: %v", err)
+	}
+
+	encrypted := encryptFor(t, newRPKeyStore(t), jwa.RSA_OAEP_256, jwa.A256GCM, signed)
+	if _, err := v.Decrypt(encrypted); err == nil {
+		t.Fatal("expected decryption with the wrong key to fail")
+	}
+}
+
+func TestJWEValidator_EnforcesAllowedAlgorithms(t *testing.T) {
+	signed, _ := signedTestJWT(t)
+	rpKeys := newRPKeyStore(t)
+	v, err := authn.NewJWEValidator(authn.JWEConfig{
+		KeyStore:           rpKeys,
+		ContentEncryptions: []string{"A256GCM"},
+	})
+	if err != nil {
+		t.Fatalf("NewJWEValidator: %v", err)
+	}
+
+	_, err = v.Decrypt(encryptFor(t, rpKeys, jwa.RSA_OAEP_256, jwa.A128CBC_HS256, signed))
+	if err == nil || !strings.Contains(err.Error(), "content encryption") {
+		t.Errorf("expected disallowed content encryption error, got %v", err)
+	}
+
+	_, err = v.Decrypt(encryptFor(t, rpKeys, jwa.RSA1_5, jwa.A256GCM, signed))
+	if err == nil || !strings.Contains(err.Error(), "key algorithm") {
+		t.Errorf("expected disallowed key algorithm error, got %v", err)
+	}
+}
+
+func TestNewJWEValidator_Validation(t *testing.T) {
+	if _, err := authn.NewJWEValidator(authn.JWEConfig{}); err == nil {
+		t.Error("expected error without a key store")
+	}
+	if _, err := authn.NewJWEValidator(authn.JWEConfig{KeyStore: newRPKeyStore(t), KeyAlgorithms: []string{"RSA1_5"}}); err == nil {
+		t.Error("expected RSA1_5 to be rejected")
+	}
+}
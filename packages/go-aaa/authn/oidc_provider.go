@@ -2,12 +2,15 @@ package authn
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"time"
 
 	"github.com/lestrrat-go/jwx/v2/jwa"
 	"github.com/lestrrat-go/jwx/v2/jwk"
+	"github.com/lestrrat-go/jwx/v2/jws"
 	"github.com/lestrrat-go/jwx/v2/jwt"
 	"github.com/penguintechinc/penguin-libs/packages/go-aaa/crypto"
 )
@@ -32,7 +35,14 @@ func NewOIDCProvider(cfg OIDCProviderConfig, ks crypto.KeyStore) (*OIDCProvider,
 // IssueTokenSet signs and returns an access token (and optionally an ID token)
 // for the provided Claims. The claims must pass validation before tokens are issued.
 // The context is accepted for interface compatibility and future use (e.g., key fetching).
-func (p *OIDCProvider) IssueTokenSet(_ context.Context, claims *Claims) (*TokenSet, error) {
+func (p *OIDCProvider) IssueTokenSet(ctx context.Context, claims *Claims) (*TokenSet, error) {
+	return p.issueTokenSet(ctx, claims, claims.Scope)
+}
+
+// issueTokenSet is IssueTokenSet with the refresh token's scope given
+// separately, so a refresh narrowed to fewer scopes still returns a refresh
+// token with the original grant (RFC 6749 §6).
+func (p *OIDCProvider) issueTokenSet(_ context.Context, claims *Claims, refreshScope []string) (*TokenSet, error) {
 	if err := claims.Validate(); err != nil {
 		return nil, fmt.Errorf("oidc_provider: invalid claims: %w", err)
 	}
@@ -56,13 +66,25 @@ func (p *OIDCProvider) IssueTokenSet(_ context.Context, claims *Claims) (*TokenS
 		return nil, fmt.Errorf("oidc_provider: failed to build id token: %w", err)
 	}
 
+	// The refresh token carries the grant's application claims so the token
+	// endpoint can reissue an equivalent access token from it alone.
 	refreshExpiry := now.Add(p.cfg.RefreshTTL)
+	refreshExt := make(map[string]interface{}, len(claims.Ext)+1)
+	for k, v := range claims.Ext {
+		refreshExt[k] = v
+	}
+	refreshExt[tokenUseClaim] = tokenUseRefresh
 	refreshClaims := &Claims{
-		Sub: claims.Sub,
-		Iss: claims.Iss,
-		Aud: claims.Aud,
-		Iat: now,
-		Exp: refreshExpiry,
+		Sub:    claims.Sub,
+		Iss:    claims.Iss,
+		Aud:    claims.Aud,
+		Iat:    now,
+		Exp:    refreshExpiry,
+		Scope:  refreshScope,
+		Roles:  claims.Roles,
+		Teams:  claims.Teams,
+		Tenant: claims.Tenant,
+		Ext:    refreshExt,
 	}
 	refreshToken, err := p.buildToken(signingKey, refreshClaims, now, refreshExpiry)
 	if err != nil {
@@ -79,12 +101,19 @@ func (p *OIDCProvider) IssueTokenSet(_ context.Context, claims *Claims) (*TokenS
 }
 
 // buildToken constructs and signs a JWT for the given claims and time window.
+// Every token gets a fresh random jti so relying parties can detect replays.
 func (p *OIDCProvider) buildToken(signingKey jwk.Key, claims *Claims, now, expiry time.Time) (string, error) {
+	jti, err := newJTI()
+	if err != nil {
+		return "", err
+	}
+
 	builder := jwt.NewBuilder().
 		Issuer(p.cfg.Issuer).
 		Subject(claims.Sub).
 		IssuedAt(now).
-		Expiration(expiry)
+		Expiration(expiry).
+		JwtID(jti)
 
 	for _, aud := range p.cfg.Audiences {
 		builder = builder.Audience([]string{aud})
@@ -116,7 +145,15 @@ func (p *OIDCProvider) buildToken(signingKey jwk.Key, claims *Claims, now, expir
 		alg = jwa.ES256
 	}
 
-	signed, err := jwt.Sign(token, jwt.WithKey(alg, signingKey))
+	// The kid identifies the verification key in the issuer's JWKS.
+	headers := jws.NewHeaders()
+	if kid := signingKey.KeyID(); kid != "" {
+		if err := headers.Set(jws.KeyIDKey, kid); err != nil {
+			return "", fmt.Errorf("failed to set kid header: %w", err)
+		}
+	}
+
+	signed, err := jwt.Sign(token, jwt.WithKey(alg, signingKey, jws.WithProtectedHeaders(headers)))
 	if err != nil {
 		return "", fmt.Errorf("failed to sign jwt: %w", err)
 	}
@@ -124,6 +161,15 @@ func (p *OIDCProvider) buildToken(signingKey jwk.Key, claims *Claims, now, expir
 	return string(signed), nil
 }
 
+// newJTI returns a random 128-bit token identifier.
+func newJTI() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate jti: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
+
 // DiscoveryDocument returns the OIDC discovery document as a JSON-serializable map.
 // This is suitable for serving at /.well-known/openid-configuration.
 func (p *OIDCProvider) DiscoveryDocument() ([]byte, error) {
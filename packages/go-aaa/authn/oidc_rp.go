@@ -4,6 +4,7 @@ import (
 	"context"
 	"crypto/subtle"
 	"fmt"
+	"sync"
 	"time"
 
 	gooidc "github.com/coreos/go-oidc/v3/oidc"
@@ -56,26 +57,42 @@ func NewOIDCRelyingParty(ctx context.Context, cfg OIDCRPConfig) (*OIDCRelyingPar
 
 // ValidateToken verifies rawToken against the configured provider and returns
 // the extracted Claims. It enforces the MaxTokenSize limit before parsing.
+// Encrypted tokens are decrypted with cfg.JWE, when set, before verification.
+// Refresh tokens issued by OIDCProvider are rejected, since they are signed like
+// access tokens but only valid at the token endpoint.
 func (rp *OIDCRelyingParty) ValidateToken(ctx context.Context, rawToken string) (*Claims, error) {
 	if len(rawToken) > MaxTokenSize {
 		return nil, fmt.Errorf("oidc_rp: token size %d exceeds maximum of %d bytes", len(rawToken), MaxTokenSize)
 	}
 
+	if rp.cfg.JWE != nil && IsJWE(rawToken) {
+		inner, err := rp.cfg.JWE.Decrypt(rawToken)
+		if err != nil {
+			return nil, fmt.Errorf("oidc_rp: %w", err)
+		}
+		rawToken = inner
+	}
+
 	idToken, err := rp.verifier.Verify(ctx, rawToken)
 	if err != nil {
 		return nil, fmt.Errorf("oidc_rp: token verification failed: %w", err)
 	}
 
 	var raw struct {
-		Scope  []string               `json:"scope"`
-		Roles  []string               `json:"roles"`
-		Teams  []string               `json:"teams"`
-		Tenant string                 `json:"tenant"`
-		Ext    map[string]interface{} `json:"ext"`
+		Jti      string                 `json:"jti"`
+		TokenUse string                 `json:"token_use"`
+		Scope    []string               `json:"scope"`
+		Roles    []string               `json:"roles"`
+		Teams    []string               `json:"teams"`
+		Tenant   string                 `json:"tenant"`
+		Ext      map[string]interface{} `json:"ext"`
 	}
 	if err := idToken.Claims(&raw); err != nil {
 		return nil, fmt.Errorf("oidc_rp: failed to extract custom claims: %w", err)
 	}
+	if raw.TokenUse == tokenUseRefresh {
+		return nil, fmt.Errorf("oidc_rp: refresh tokens cannot be used as access tokens")
+	}
 
 	claims := &Claims{
 		Sub:    idToken.Subject,
@@ -83,6 +100,7 @@ func (rp *OIDCRelyingParty) ValidateToken(ctx context.Context, rawToken string)
 		Aud:    idToken.Audience,
 		Iat:    idToken.IssuedAt,
 		Exp:    idToken.Expiry,
+		Jti:    raw.Jti,
 		Scope:  raw.Scope,
 		Roles:  raw.Roles,
 		Teams:  raw.Teams,
@@ -90,6 +108,21 @@ func (rp *OIDCRelyingParty) ValidateToken(ctx context.Context, rawToken string)
 		Ext:    raw.Ext,
 	}
 
+	if rp.cfg.ClaimsTransformer != nil {
+		var all map[string]interface{}
+		if err := idToken.Claims(&all); err != nil {
+			return nil, fmt.Errorf("oidc_rp: failed to extract custom claims: %w", err)
+		}
+		claims.Ext = extClaims(all, raw.Ext)
+		claims, err = rp.cfg.ClaimsTransformer(claims)
+		if err != nil {
+			return nil, fmt.Errorf("oidc_rp: claims transformer: %w", err)
+		}
+		if claims == nil {
+			return nil, fmt.Errorf("oidc_rp: claims transformer returned no claims")
+		}
+	}
+
 	if err := claims.Validate(); err != nil {
 		return nil, fmt.Errorf("oidc_rp: invalid claims: %w", err)
 	}
@@ -97,6 +130,74 @@ func (rp *OIDCRelyingParty) ValidateToken(ctx context.Context, rawToken string)
 	return claims, nil
 }
 
+// DefaultBatchConcurrency is the default number of tokens ValidateTokens
+// verifies concurrently.
+const DefaultBatchConcurrency = 8
+
+// ValidateTokens validates rawTokens concurrently, at most cfg.BatchConcurrency
+// at a time, sharing the relying party's cached key set. The returned slices are
+// positional: claims[i] and errs[i] belong to rawTokens[i], and exactly one of
+// them is non-nil. Tokens not yet started when ctx is cancelled fail with the
+// context's error.
+func (rp *OIDCRelyingParty) ValidateTokens(ctx context.Context, rawTokens []string) ([]*Claims, []error) {
+	claims := make([]*Claims, len(rawTokens))
+	errs := make([]error, len(rawTokens))
+
+	sem := make(chan struct{}, rp.cfg.BatchConcurrency)
+	var wg sync.WaitGroup
+	for i, raw := range rawTokens {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			errs[i] = ctx.Err()
+			continue
+		}
+		wg.Add(1)
+		go func(i int, raw string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			if err := ctx.Err(); err != nil {
+				errs[i] = err
+				return
+			}
+			claims[i], errs[i] = rp.ValidateToken(ctx, raw)
+		}(i, raw)
+	}
+	wg.Wait()
+	return claims, errs
+}
+
+// mappedClaims lists the claims ValidateToken maps onto Claims fields or that
+// carry no application meaning; every other claim is exposed to a
+// ClaimsTransformer through Claims.Ext.
+var mappedClaims = map[string]bool{
+	"sub": true, "iss": true, "aud": true, "iat": true, "exp": true, "nbf": true,
+	"jti": true, "azp": true, "nonce": true, "at_hash": true, "c_hash": true, "auth_time": true,
+	"scope": true, "roles": true, "teams": true, "tenant": true, "ext": true,
+}
+
+// extClaims merges the unmapped top-level claims in all, such as a provider's
+// "groups" claim, with the nested ext object, which takes precedence.
+func extClaims(all, nested map[string]interface{}) map[string]interface{} {
+	var ext map[string]interface{}
+	for k, v := range all {
+		if mappedClaims[k] {
+			continue
+		}
+		if ext == nil {
+			ext = make(map[string]interface{})
+		}
+		ext[k] = v
+	}
+	for k, v := range nested {
+		if ext == nil {
+			ext = make(map[string]interface{})
+		}
+		ext[k] = v
+	}
+	return ext
+}
+
 // AuthCodeURL returns the URL to redirect the user to for authorization.
 func (rp *OIDCRelyingParty) AuthCodeURL(state string, opts ...oauth2.AuthCodeOption) string {
 	return rp.oauth2.AuthCodeURL(state, opts...)
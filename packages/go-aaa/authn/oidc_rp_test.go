@@ -1,11 +1,21 @@
 package authn_test
 
 import (
+	"context"
 	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
+
+	gooidc "github.com/coreos/go-oidc/v3/oidc"
 
 	"github.com/penguintechinc/penguin-libs/packages/go-aaa/authn"
+	"github.com/penguintechinc/penguin-libs/packages/go-aaa/crypto"
 )
 
 func TestOIDCRelyingParty_ValidateState_Comparison(t *testing.T) {
@@ -59,3 +69,231 @@ func TestOIDCRPConfig_Validate_DefaultsApplied(t *testing.T) {
 		t.Error("expected default clock skew to be set")
 	}
 }
+
+// newTestIssuer starts an HTTPS server publishing the discovery document and
+// JWKS of a local OIDCProvider, and returns a relying party configured against
+// it. mutate may adjust the RP config before the relying party is created.
+func newTestIssuer(t *testing.T, mutate func(*authn.OIDCRPConfig)) (*authn.OIDCProvider, *authn.OIDCRelyingParty) {
+	t.Helper()
+	ks, err := crypto.NewMemoryKeyStore(crypto.AlgorithmES256)
+	if err != nil {
+		t.Fatalf("NewMemoryKeyStore: %v", err)
+	}
+
+	var provider *authn.OIDCProvider
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, _ *http.Request) {
+		doc, err := provider.DiscoveryDocument()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(doc)
+	})
+	mux.HandleFunc("/.well-known/jwks.json", func(w http.ResponseWriter, _ *http.Request) {
+		set, err := ks.GetKeySet()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(set)
+	})
+	srv := httptest.NewTLSServer(mux)
+	t.Cleanup(srv.Close)
+
+	provider, err = authn.NewOIDCProvider(authn.OIDCProviderConfig{
+		Issuer:    srv.URL,
+		Audiences: []string{"my-app"},
+		Algorithm: "ES256",
+	}, ks)
+	if err != nil {
+		t.Fatalf("NewOIDCProvider: %v", err)
+	}
+
+	cfg := authn.OIDCRPConfig{IssuerURL: srv.URL, ClientID: "my-app"}
+	if mutate != nil {
+		mutate(&cfg)
+	}
+	rp, err := authn.NewOIDCRelyingParty(gooidc.ClientContext(context.Background(), srv.Client()), cfg)
+	if err != nil {
+		t.Fatalf("NewOIDCRelyingParty: %v", err)
+	}
+	return provider, rp
+}
+
+// issueAccessToken mints an access token for claims from provider.
+func issueAccessToken(t *testing.T, provider *authn.OIDCProvider, claims *authn.Claims) string {
+	t.Helper()
+	now := time.Now()
+	claims.Iss = "test-issuer" // the provider signs with its configured issuer
+	claims.Aud = []string{"my-app"}
+	claims.Iat, claims.Exp = now, now.Add(time.Hour)
+	ts, err := provider.IssueTokenSet(context.Background(), claims)
+	if err != nil {
+		t.Fatalf("IssueTokenSet: %v", err)
+	}
+	return ts.AccessToken
+}
+
+func TestOIDCRelyingParty_ValidateToken_TopLevelClaimsNotInExt(t *testing.T) {
+	provider, rp := newTestIssuer(t, nil)
+	raw := issueAccessToken(t, provider, &authn.Claims{
+		Sub: "user-alice",
+		Ext: map[string]interface{}{"groups": []string{"eng"}},
+	})
+
+	claims, err := rp.ValidateToken(context.Background(), raw)
+	if err != nil {
+		t.Fatalf("ValidateToken: %v", err)
+	}
+	if claims.Sub != "user-alice" || claims.Jti == "" {
+		t.Errorf("unexpected claims: %+v", claims)
+	}
+	if _, ok := claims.Ext["groups"]; ok {
+		t.Errorf("top-level claims must not be copied into Ext without a transformer, got %v", claims.Ext)
+	}
+}
+
+func TestOIDCRelyingParty_ClaimsTransformerSeesProviderClaims(t *testing.T) {
+	var seen map[string]interface{}
+	provider, rp := newTestIssuer(t, func(cfg *authn.OIDCRPConfig) {
+		cfg.ClaimsTransformer = func(c *authn.Claims) (*authn.Claims, error) {
+			seen = c.Ext
+			return c, nil
+		}
+	})
+	raw := issueAccessToken(t, provider, &authn.Claims{
+		Sub: "user-alice",
+		Ext: map[string]interface{}{"groups": []string{"eng"}},
+	})
+
+	if _, err := rp.ValidateToken(context.Background(), raw); err != nil {
+		t.Fatalf("ValidateToken: %v", err)
+	}
+	if _, ok := seen["groups"]; !ok {
+		t.Errorf("expected groups claim in the transformer's Ext, got %v", seen)
+	}
+	if _, ok := seen["iss"]; ok {
+		t.Error("registered claims must not be copied into Ext")
+	}
+}
+
+func TestOIDCRelyingParty_ValidateToken_RejectsRefreshToken(t *testing.T) {
+	provider, rp := newTestIssuer(t, nil)
+	now := time.Now()
+	ts, err := provider.IssueTokenSet(context.Background(), &authn.Claims{
+		Sub: "user-alice", Iss: "test-issuer", Aud: []string{"my-app"},
+		Iat: now, Exp: now.Add(time.Hour), Scope: []string{"admin"},
+	})
+	if err != nil {
+		t.Fatalf("IssueTokenSet: %v", err)
+	}
+
+	if _, err := rp.ValidateToken(context.Background(), ts.AccessToken); err != nil {
+		t.Fatalf("access token: unexpected error: %v", err)
+	}
+	if _, err := rp.ValidateToken(context.Background(), ts.RefreshToken); err == nil {
+		t.Fatal("expected refresh token to be rejected as an access token")
+	}
+}
+
+func TestOIDCRelyingParty_ClaimsTransformerMapsGroupsToRoles(t *testing.T) {
+	provider, rp := newTestIssuer(t, func(cfg *authn.OIDCRPConfig) {
+		cfg.ClaimsTransformer = func(c *authn.Claims) (*authn.Claims, error) {
+			groups, _ := c.Ext["groups"].([]interface{})
+			for _, g := range groups {
+				if s, ok := g.(string); ok {
+					c.Roles = append(c.Roles, s)
+				}
+			}
+			c.Tenant = "acme"
+			return c, nil
+		}
+	})
+	raw := issueAccessToken(t, provider, &authn.Claims{
+		Sub: "user-alice",
+		Ext: map[string]interface{}{"groups": []string{"admin", "viewer"}},
+	})
+
+	claims, err := rp.ValidateToken(context.Background(), raw)
+	if err != nil {
+		t.Fatalf("ValidateToken: %v", err)
+	}
+	if len(claims.Roles) != 2 || claims.Roles[0] != "admin" || claims.Roles[1] != "viewer" {
+		t.Errorf("roles = %v, want [admin viewer]", claims.Roles)
+	}
+	if claims.Tenant != "acme" {
+		t.Errorf("tenant = %q, want acme", claims.Tenant)
+	}
+}
+
+func TestOIDCRelyingParty_ClaimsTransformerRejects(t *testing.T) {
+	provider, rp := newTestIssuer(t, func(cfg *authn.OIDCRPConfig) {
+		cfg.ClaimsTransformer = func(c *authn.Claims) (*authn.Claims, error) {
+			if c.Ext["email_verified"] != true {
+				return nil, errors.New("email not verified")
+			}
+			return c, nil
+		}
+	})
+	raw := issueAccessToken(t, provider, &authn.Claims{
+		Sub: "user-alice",
+		Ext: map[string]interface{}{"email_verified": false},
+	})
+
+	if _, err := rp.ValidateToken(context.Background(), raw); err == nil || !strings.Contains(err.Error(), "email not verified") {
+		t.Fatalf("expected transformer rejection, got %v", err)
+	}
+}
+
+func TestOIDCRelyingParty_ValidateTokens_PositionalResults(t *testing.T) {
+	provider, rp := newTestIssuer(t, func(cfg *authn.OIDCRPConfig) { cfg.BatchConcurrency = 2 })
+	foreign, _ := newTestIssuer(t, nil)
+
+	tokens := []string{
+		issueAccessToken(t, provider, &authn.Claims{Sub: "user-0"}),
+		"not-a-jwt",
+		issueAccessToken(t, provider, &authn.Claims{Sub: "user-2"}),
+		issueAccessToken(t, foreign, &authn.Claims{Sub: "user-3"}),
+		strings.Repeat("a", authn.MaxTokenSize+1),
+		issueAccessToken(t, provider, &authn.Claims{Sub: "user-5"}),
+	}
+	wantValid := []bool{true, false, true, false, false, true}
+
+	claims, errs := rp.ValidateTokens(context.Background(), tokens)
+	if len(claims) != len(tokens) || len(errs) != len(tokens) {
+		t.Fatalf("got %d claims and %d errors for %d tokens", len(claims), len(errs), len(tokens))
+	}
+	for i, valid := range wantValid {
+		if valid {
+			if errs[i] != nil || claims[i] == nil {
+				t.Errorf("token %d: expected valid, got err=%v", i, errs[i])
+				continue
+			}
+			if want := fmt.Sprintf("user-%d", i); claims[i].Sub != want {
+				t.Errorf("token %d: sub = %q, want %q", i, claims[i].Sub, want)
+			}
+			continue
+		}
+		if errs[i] == nil || claims[i] != nil {
+			t.Errorf("token %d: expected an error and no claims, got claims=%v err=%v", i, claims[i], errs[i])
+		}
+	}
+}
+
+func TestOIDCRelyingParty_ValidateTokens_CancelledContext(t *testing.T) {
+	provider, rp := newTestIssuer(t, nil)
+	raw := issueAccessToken(t, provider, &authn.Claims{Sub: "user-0"})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, errs := rp.ValidateTokens(ctx, []string{raw, raw, raw})
+	for i, err := range errs {
+		if err == nil {
+			t.Errorf("token %d: expected an error for a cancelled context", i)
+		}
+	}
+}
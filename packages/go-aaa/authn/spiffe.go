@@ -3,32 +3,109 @@ package authn
 import (
 	"context"
 	"crypto/x509"
+	"errors"
 	"fmt"
+	"strings"
 
+	"github.com/spiffe/go-spiffe/v2/bundle/jwtbundle"
+	"github.com/spiffe/go-spiffe/v2/bundle/x509bundle"
 	"github.com/spiffe/go-spiffe/v2/spiffeid"
+	"github.com/spiffe/go-spiffe/v2/svid/jwtsvid"
 	"github.com/spiffe/go-spiffe/v2/svid/x509svid"
 	"github.com/spiffe/go-spiffe/v2/workloadapi"
 )
 
-// SPIFFEAuthenticator validates peer certificates against a configured set of
-// allowed SPIFFE IDs obtained from the SPIFFE Workload API.
+// SPIFFEAuthenticator validates peer certificates and JWT-SVIDs against a
+// configured set of allowed SPIFFE IDs obtained from the SPIFFE Workload API.
 type SPIFFEAuthenticator struct {
-	cfg    SPIFFEConfig
-	source *workloadapi.X509Source
+	cfg         SPIFFEConfig
+	allowed     []spiffeIDMatcher
+	source      *workloadapi.X509Source
+	x509Bundles x509bundle.Source
+	jwtBundles  jwtbundle.Source
+}
+
+// SPIFFEOption configures an SPIFFEAuthenticator.
+type SPIFFEOption func(*SPIFFEAuthenticator)
+
+// WithX509BundleSource sets the X.509 bundles ValidatePeerCertificate verifies
+// certificate chains against, instead of connecting to the Workload API with
+// GetX509Source. Use it with a static x509bundle.Set, for example one loaded
+// with x509bundle.Load.
+func WithX509BundleSource(src x509bundle.Source) SPIFFEOption {
+	return func(a *SPIFFEAuthenticator) { a.x509Bundles = src }
+}
+
+// WithJWTBundleSource sets the JWT bundles ValidateJWTSVID verifies signatures
+// against, instead of connecting to the Workload API with GetJWTSource. Use it
+// with a static jwtbundle.Set, for example one loaded with jwtbundle.Load.
+func WithJWTBundleSource(src jwtbundle.Source) SPIFFEOption {
+	return func(a *SPIFFEAuthenticator) { a.jwtBundles = src }
+}
+
+// spiffeIDMatcher is a parsed SPIFFEConfig.AllowedIDs entry. It matches
+// either exactly one ID or, when anyInDomain is set, every ID in a trust
+// domain.
+type spiffeIDMatcher struct {
+	id          spiffeid.ID
+	anyInDomain bool
+}
+
+// errSPIFFEWildcard rejects AllowedIDs entries with a wildcard anywhere but
+// directly after the trust domain.
+var errSPIFFEWildcard = errors.New(`wildcards are only supported as a trailing "/*" after the trust domain`)
+
+// parseAllowedSPIFFEID parses an AllowedIDs entry. "spiffe://example.org/*"
+// and the bare trust domain "spiffe://example.org" match any ID in
+// example.org; anything else must be a valid SPIFFE ID and matches only
+// itself.
+func parseAllowedSPIFFEID(s string) (spiffeIDMatcher, error) {
+	domain, wildcard := strings.CutSuffix(s, "/*")
+	if wildcard {
+		s = domain
+	}
+	if strings.Contains(s, "*") {
+		return spiffeIDMatcher{}, errSPIFFEWildcard
+	}
+	id, err := spiffeid.FromString(s)
+	if err != nil {
+		return spiffeIDMatcher{}, err
+	}
+	if wildcard && id.Path() != "" {
+		return spiffeIDMatcher{}, errSPIFFEWildcard
+	}
+	return spiffeIDMatcher{id: id, anyInDomain: id.Path() == ""}, nil
+}
+
+// matches reports whether id is allowed by m.
+func (m spiffeIDMatcher) matches(id spiffeid.ID) bool {
+	if m.anyInDomain {
+		return id.MemberOf(m.id.TrustDomain())
+	}
+	return id == m.id
 }
 
 // NewSPIFFEAuthenticator creates an SPIFFEAuthenticator from the given configuration.
 // Call GetX509Source to connect to the Workload API before validating certificates.
-func NewSPIFFEAuthenticator(cfg SPIFFEConfig) (*SPIFFEAuthenticator, error) {
+func NewSPIFFEAuthenticator(cfg SPIFFEConfig, opts ...SPIFFEOption) (*SPIFFEAuthenticator, error) {
 	if err := cfg.Validate(); err != nil {
 		return nil, fmt.Errorf("spiffe: invalid config: %w", err)
 	}
-	return &SPIFFEAuthenticator{cfg: cfg}, nil
+	allowed := make([]spiffeIDMatcher, len(cfg.AllowedIDs))
+	for i, id := range cfg.AllowedIDs {
+		// Validate has already parsed every entry successfully.
+		allowed[i], _ = parseAllowedSPIFFEID(id)
+	}
+	a := &SPIFFEAuthenticator{cfg: cfg, allowed: allowed}
+	for _, o := range opts {
+		o(a)
+	}
+	return a, nil
 }
 
-// GetX509Source connects to the SPIFFE Workload API and stores the X.509 source
-// for use during peer certificate validation. The source should be closed when
-// the authenticator is no longer needed.
+// GetX509Source connects to the SPIFFE Workload API and stores the X.509 source,
+// whose trust bundles ValidatePeerCertificate verifies chains against. The
+// source should be closed when the authenticator is no longer needed.
 func (a *SPIFFEAuthenticator) GetX509Source(ctx context.Context) (*workloadapi.X509Source, error) {
 	source, err := workloadapi.NewX509Source(
 		ctx,
@@ -38,34 +115,77 @@ func (a *SPIFFEAuthenticator) GetX509Source(ctx context.Context) (*workloadapi.X
 		return nil, fmt.Errorf("spiffe: failed to connect to workload api at %q: %w", a.cfg.WorkloadSocket, err)
 	}
 	a.source = source
+	a.x509Bundles = source
 	return source, nil
 }
 
-// ValidatePeerCertificate validates a peer's certificate chain against the configured
-// allowed SPIFFE IDs. It returns the matched SPIFFE ID string on success.
-// The first certificate in certs is treated as the leaf/end-entity certificate.
-func (a *SPIFFEAuthenticator) ValidatePeerCertificate(certs []*x509.Certificate) (string, error) {
-	if len(certs) == 0 {
-		return "", fmt.Errorf("spiffe: no peer certificates provided")
+// GetJWTSource connects to the SPIFFE Workload API and stores the JWT source,
+// whose trust bundles ValidateJWTSVID verifies signatures against. The source
+// should be closed when the authenticator is no longer needed.
+func (a *SPIFFEAuthenticator) GetJWTSource(ctx context.Context) (*workloadapi.JWTSource, error) {
+	source, err := workloadapi.NewJWTSource(
+		ctx,
+		workloadapi.WithClientOptions(workloadapi.WithAddr(a.cfg.WorkloadSocket)),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("spiffe: failed to connect to workload api at %q: %w", a.cfg.WorkloadSocket, err)
+	}
+	a.jwtBundles = source
+	return source, nil
+}
+
+// ValidateJWTSVID verifies a JWT-SVID's signature against the trust bundle of
+// its subject's trust domain, checks that it is unexpired and that its aud
+// claim contains audience, and matches its SPIFFE ID against the configured
+// allowed IDs. It returns the SPIFFE ID string on success. The bundles come
+// from GetJWTSource or WithJWTBundleSource.
+func (a *SPIFFEAuthenticator) ValidateJWTSVID(_ context.Context, token, audience string) (string, error) {
+	if a.jwtBundles == nil {
+		return "", fmt.Errorf("spiffe: no JWT bundle source; call GetJWTSource or use WithJWTBundleSource")
+	}
+	if audience == "" {
+		return "", fmt.Errorf("spiffe: audience is required to validate a JWT-SVID")
+	}
+	if len(token) > MaxTokenSize {
+		return "", fmt.Errorf("spiffe: JWT-SVID exceeds %d bytes", MaxTokenSize)
 	}
 
-	// Extract the SPIFFE ID from the leaf certificate's URI SAN.
-	peerID, err := x509svid.IDFromCert(certs[0])
+	svid, err := jwtsvid.ParseAndValidate(token, a.jwtBundles, []string{audience})
 	if err != nil {
-		return "", fmt.Errorf("spiffe: failed to extract SPIFFE ID from peer certificate: %w", err)
+		return "", fmt.Errorf("spiffe: invalid JWT-SVID: %w", err)
 	}
+	return a.matchAllowed(svid.ID)
+}
 
-	peerIDStr := peerID.String()
-
-	for _, allowedID := range a.cfg.AllowedIDs {
-		allowed, err := spiffeid.FromString(allowedID)
-		if err != nil {
-			return "", fmt.Errorf("spiffe: invalid allowed id %q: %w", allowedID, err)
-		}
-		if peerID == allowed {
-			return peerIDStr, nil
+// matchAllowed returns id as a string if it matches an allowed ID entry.
+func (a *SPIFFEAuthenticator) matchAllowed(id spiffeid.ID) (string, error) {
+	for _, allowed := range a.allowed {
+		if allowed.matches(id) {
+			return id.String(), nil
 		}
 	}
+	return "", fmt.Errorf("spiffe: peer id %q is not in the allowed set", id.String())
+}
+
+// ValidatePeerCertificate verifies a peer's certificate chain against the trust
+// bundle of its SPIFFE ID's trust domain and matches the ID against the configured
+// allowed SPIFFE IDs, including trust-domain entries such as "spiffe://example.org/*".
+// It returns the peer's SPIFFE ID string on success. The first certificate in certs
+// is treated as the leaf/end-entity certificate and the rest as intermediates. The
+// bundles come from GetX509Source or WithX509BundleSource.
+func (a *SPIFFEAuthenticator) ValidatePeerCertificate(certs []*x509.Certificate) (string, error) {
+	if len(certs) == 0 {
+		return "", fmt.Errorf("spiffe: no peer certificates provided")
+	}
+	if a.x509Bundles == nil {
+		return "", fmt.Errorf("spiffe: no X.509 bundle source; call GetX509Source or use WithX509BundleSource")
+	}
+
+	// Verify the chain up to a trusted root before believing the URI SAN.
+	peerID, _, err := x509svid.Verify(certs, a.x509Bundles)
+	if err != nil {
+		return "", fmt.Errorf("spiffe: peer certificate verification failed: %w", err)
+	}
 
-	return "", fmt.Errorf("spiffe: peer id %q is not in the allowed set", peerIDStr)
+	return a.matchAllowed(peerID)
 }
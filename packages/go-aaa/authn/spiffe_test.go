@@ -0,0 +1,309 @@
+package authn_test
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"math/big"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/lestrrat-go/jwx/v2/jwa"
+	"github.com/lestrrat-go/jwx/v2/jws"
+	"github.com/lestrrat-go/jwx/v2/jwt"
+	"github.com/spiffe/go-spiffe/v2/bundle/jwtbundle"
+	"github.com/spiffe/go-spiffe/v2/bundle/x509bundle"
+	"github.com/spiffe/go-spiffe/v2/spiffeid"
+
+	"github.com/penguintechinc/penguin-libs/packages/go-aaa/authn"
+)
+
+// testSPIFFECA is a self-signed root that issues X509-SVIDs.
+type testSPIFFECA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+}
+
+func newTestSPIFFECA(t *testing.T) *testSPIFFECA {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	return &testSPIFFECA{cert: createCert(t, tmpl, tmpl, &key.PublicKey, key), key: key}
+}
+
+// issue returns a leaf certificate signed by ca whose only URI SAN is id.
+func (ca *testSPIFFECA) issue(t *testing.T, id string) *x509.Certificate {
+	t.Helper()
+	return ca.issueFrom(t, id, ca.cert, ca.key)
+}
+
+// selfSigned returns a leaf certificate for id that is not signed by ca.
+func (ca *testSPIFFECA) selfSigned(t *testing.T, id string) *x509.Certificate {
+	t.Helper()
+	return ca.issueFrom(t, id, nil, nil)
+}
+
+func (ca *testSPIFFECA) issueFrom(t *testing.T, id string, parent *x509.Certificate, parentKey *ecdsa.PrivateKey) *x509.Certificate {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	uri, err := url.Parse(id)
+	if err != nil {
+		t.Fatalf("parse id: %v", err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		URIs:         []*url.URL{uri},
+	}
+	if parent == nil {
+		parent, parentKey = tmpl, key
+	}
+	return createCert(t, tmpl, parent, &key.PublicKey, parentKey)
+}
+
+// bundles returns a bundle set trusting ca for each trust domain.
+func (ca *testSPIFFECA) bundles(domains ...string) *x509bundle.Set {
+	set := x509bundle.NewSet()
+	for _, td := range domains {
+		set.Add(x509bundle.FromX509Authorities(spiffeid.RequireTrustDomainFromString(td), []*x509.Certificate{ca.cert}))
+	}
+	return set
+}
+
+func createCert(t *testing.T, tmpl, parent *x509.Certificate, pub *ecdsa.PublicKey, signer *ecdsa.PrivateKey) *x509.Certificate {
+	t.Helper()
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, parent, pub, signer)
+	if err != nil {
+		t.Fatalf("create certificate: %v", err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("parse certificate: %v", err)
+	}
+	return cert
+}
+
+func newSPIFFEAuthenticator(t *testing.T, bundles x509bundle.Source, allowed ...string) *authn.SPIFFEAuthenticator {
+	t.Helper()
+	a, err := authn.NewSPIFFEAuthenticator(authn.SPIFFEConfig{
+		TrustDomain:    "example.org",
+		WorkloadSocket: "unix:///run/spire/sockets/agent.sock",
+		AllowedIDs:     allowed,
+	}, authn.WithX509BundleSource(bundles))
+	if err != nil {
+		t.Fatalf("NewSPIFFEAuthenticator: %v", err)
+	}
+	return a
+}
+
+func TestSPIFFEAuthenticator_ExactMatch(t *testing.T) {
+	ca := newTestSPIFFECA(t)
+	a := newSPIFFEAuthenticator(t, ca.bundles("example.org"), "spiffe://example.org/service/api")
+
+	got, err := a.ValidatePeerCertificate([]*x509.Certificate{ca.issue(t, "spiffe://example.org/service/api")})
+	if err != nil {
+		t.Fatalf("expected exact match to be allowed: %v", err)
+	}
+	if got != "spiffe://example.org/service/api" {
+		t.Errorf("matched id = %q", got)
+	}
+	if _, err := a.ValidatePeerCertificate([]*x509.Certificate{ca.issue(t, "spiffe://example.org/service/db")}); err == nil {
+		t.Error("expected a different ID in the same domain to be rejected by an exact entry")
+	}
+}
+
+func TestSPIFFEAuthenticator_TrustDomainMatch(t *testing.T) {
+	ca := newTestSPIFFECA(t)
+	for _, entry := range []string{"spiffe://example.org/*", "spiffe://example.org"} {
+		t.Run(entry, func(t *testing.T) {
+			a := newSPIFFEAuthenticator(t, ca.bundles("example.org"), entry)
+			for _, id := range []string{"spiffe://example.org/service/api", "spiffe://example.org/ns/prod/sa/worker"} {
+				got, err := a.ValidatePeerCertificate([]*x509.Certificate{ca.issue(t, id)})
+				if err != nil {
+					t.Errorf("expected %s to be allowed: %v", id, err)
+				}
+				if got != id {
+					t.Errorf("matched id = %q, want the peer id %q", got, id)
+				}
+			}
+		})
+	}
+}
+
+func TestSPIFFEAuthenticator_ForeignDomainRejected(t *testing.T) {
+	// The CA is trusted for every domain, so rejection comes from AllowedIDs.
+	ca := newTestSPIFFECA(t)
+	a := newSPIFFEAuthenticator(t, ca.bundles("example.org", "evil.org", "example.org.evil.org"),
+		"spiffe://example.org/*", "spiffe://example.org/service/api")
+
+	for _, id := range []string{"spiffe://evil.org/service/api", "spiffe://example.org.evil.org/service/api"} {
+		if _, err := a.ValidatePeerCertificate([]*x509.Certificate{ca.issue(t, id)}); err == nil {
+			t.Errorf("expected %s to be rejected", id)
+		}
+	}
+}
+
+func TestSPIFFEAuthenticator_ChainSignedByTrustedCA(t *testing.T) {
+	ca := newTestSPIFFECA(t)
+	a := newSPIFFEAuthenticator(t, ca.bundles("example.org"), "spiffe://example.org/service/api")
+
+	// The peer may send the root along with its leaf.
+	chain := []*x509.Certificate{ca.issue(t, "spiffe://example.org/service/api"), ca.cert}
+	if _, err := a.ValidatePeerCertificate(chain); err != nil {
+		t.Fatalf("expected chain signed by the trusted CA to be accepted: %v", err)
+	}
+}
+
+func TestSPIFFEAuthenticator_UntrustedIssuerRejected(t *testing.T) {
+	trusted, rogue := newTestSPIFFECA(t), newTestSPIFFECA(t)
+	a := newSPIFFEAuthenticator(t, trusted.bundles("example.org"), "spiffe://example.org/service/api")
+	const id = "spiffe://example.org/service/api"
+
+	tests := []struct {
+		name  string
+		chain []*x509.Certificate
+	}{
+		{"self-signed", []*x509.Certificate{trusted.selfSigned(t, id)}},
+		{"untrusted CA", []*x509.Certificate{rogue.issue(t, id)}},
+		{"untrusted CA sent as intermediate", []*x509.Certificate{rogue.issue(t, id), rogue.cert}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := a.ValidatePeerCertificate(tt.chain); err == nil {
+				t.Error("expected certificate with an untrusted issuer to be rejected")
+			}
+		})
+	}
+}
+
+func TestSPIFFEAuthenticator_NoX509BundleSource(t *testing.T) {
+	ca := newTestSPIFFECA(t)
+	a := newSPIFFEAuthenticator(t, nil, "spiffe://example.org/*")
+	if _, err := a.ValidatePeerCertificate([]*x509.Certificate{ca.issue(t, "spiffe://example.org/service/api")}); err == nil {
+		t.Fatal("expected error without an X.509 bundle source")
+	}
+}
+
+// jwtSVIDSigner signs JWT-SVIDs for a trust domain and exposes the matching
+// JWT bundle.
+type jwtSVIDSigner struct {
+	kid string
+	key *ecdsa.PrivateKey
+}
+
+func newJWTSVIDSigner(t *testing.T) *jwtSVIDSigner {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	return &jwtSVIDSigner{kid: "authority-1", key: key}
+}
+
+func (s *jwtSVIDSigner) bundle(t *testing.T, td string) *jwtbundle.Bundle {
+	t.Helper()
+	return jwtbundle.FromJWTAuthorities(spiffeid.RequireTrustDomainFromString(td), map[string]crypto.PublicKey{s.kid: &s.key.PublicKey})
+}
+
+func (s *jwtSVIDSigner) sign(t *testing.T, sub, aud string) string {
+	t.Helper()
+	tok, err := jwt.NewBuilder().Subject(sub).Audience([]string{aud}).Expiration(time.Now().Add(5 * time.Minute)).Build()
+	if err != nil {
+		t.Fatalf("build token: %v", err)
+	}
+	hdrs := jws.NewHeaders()
+	_ = hdrs.Set(jws.KeyIDKey, s.kid)
+	signed, err := jwt.Sign(tok, jwt.WithKey(jwa.ES256, s.key, jws.WithProtectedHeaders(hdrs)))
+	if err != nil {
+		t.Fatalf("sign token: %v", err)
+	}
+	return string(signed)
+}
+
+func newJWTSPIFFEAuthenticator(t *testing.T, bundles jwtbundle.Source, allowed ...string) *authn.SPIFFEAuthenticator {
+	t.Helper()
+	a, err := authn.NewSPIFFEAuthenticator(authn.SPIFFEConfig{
+		TrustDomain:    "example.org",
+		WorkloadSocket: "unix:///run/spire/sockets/agent.sock",
+		AllowedIDs:     allowed,
+	}, authn.WithJWTBundleSource(bundles))
+	if err != nil {
+		t.Fatalf("NewSPIFFEAuthenticator: %v", err)
+	}
+	return a
+}
+
+func TestSPIFFEAuthenticator_ValidateJWTSVID_AllowedID(t *testing.T) {
+	signer := newJWTSVIDSigner(t)
+	a := newJWTSPIFFEAuthenticator(t, signer.bundle(t, "example.org"), "spiffe://example.org/service/api")
+
+	token := signer.sign(t, "spiffe://example.org/service/api", "spiffe://example.org/service/billing")
+	got, err := a.ValidateJWTSVID(context.Background(), token, "spiffe://example.org/service/billing")
+	if err != nil {
+		t.Fatalf("expected allowed JWT-SVID to validate: %v", err)
+	}
+	if got != "spiffe://example.org/service/api" {
+		t.Errorf("id = %q", got)
+	}
+}
+
+func TestSPIFFEAuthenticator_ValidateJWTSVID_DisallowedID(t *testing.T) {
+	signer := newJWTSVIDSigner(t)
+	a := newJWTSPIFFEAuthenticator(t, signer.bundle(t, "example.org"), "spiffe://example.org/service/api")
+
+	token := signer.sign(t, "spiffe://example.org/service/intruder", "spiffe://example.org/service/billing")
+	if _, err := a.ValidateJWTSVID(context.Background(), token, "spiffe://example.org/service/billing"); err == nil {
+		t.Fatal("expected a validly signed JWT-SVID for a disallowed ID to be rejected")
+	}
+}
+
+func TestSPIFFEAuthenticator_ValidateJWTSVID_Rejections(t *testing.T) {
+	signer := newJWTSVIDSigner(t)
+	a := newJWTSPIFFEAuthenticator(t, signer.bundle(t, "example.org"), "spiffe://example.org/*")
+	const aud = "spiffe://example.org/service/billing"
+
+	tests := []struct {
+		name     string
+		token    string
+		audience string
+	}{
+		{"wrong audience", signer.sign(t, "spiffe://example.org/service/api", "spiffe://example.org/other"), aud},
+		{"untrusted signer", newJWTSVIDSigner(t).sign(t, "spiffe://example.org/service/api", aud), aud},
+		{"no bundle for domain", signer.sign(t, "spiffe://other.org/service/api", aud), aud},
+		{"empty audience", signer.sign(t, "spiffe://example.org/service/api", aud), ""},
+		{"not a jwt", "not-a-jwt", aud},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := a.ValidateJWTSVID(context.Background(), tt.token, tt.audience); err == nil {
+				t.Error("expected JWT-SVID to be rejected")
+			}
+		})
+	}
+}
+
+func TestSPIFFEAuthenticator_ValidateJWTSVID_NoBundleSource(t *testing.T) {
+	a := newSPIFFEAuthenticator(t, nil, "spiffe://example.org/*")
+	if _, err := a.ValidateJWTSVID(context.Background(), "token", "aud"); err == nil {
+		t.Fatal("expected error without a JWT bundle source")
+	}
+}
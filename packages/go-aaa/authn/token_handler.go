@@ -0,0 +1,333 @@
+package authn
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/lestrrat-go/jwx/v2/jws"
+	"github.com/lestrrat-go/jwx/v2/jwt"
+)
+
+// Grant types accepted by OIDCProvider.TokenHandler.
+const (
+	GrantTypePassword          = "password"
+	GrantTypeRefreshToken      = "refresh_token"
+	GrantTypeClientCredentials = "client_credentials"
+)
+
+// OAuth 2.0 token endpoint error codes (RFC 6749 §5.2).
+const (
+	TokenErrInvalidRequest       = "invalid_request"
+	TokenErrInvalidClient        = "invalid_client"
+	TokenErrInvalidGrant         = "invalid_grant"
+	TokenErrUnsupportedGrantType = "unsupported_grant_type"
+	TokenErrInvalidScope         = "invalid_scope"
+	TokenErrServerError          = "server_error"
+)
+
+// tokenUseClaim marks refresh tokens so access and ID tokens cannot be
+// presented to the refresh grant.
+const (
+	tokenUseClaim   = "token_use"
+	tokenUseRefresh = "refresh"
+)
+
+// TokenError is an OAuth 2.0 token endpoint error. Authenticators may return
+// one to control the error code sent to the client; any other error is
+// reported as the grant's default code.
+type TokenError struct {
+	// Code is the OAuth 2.0 error code, e.g. TokenErrInvalidGrant.
+	Code string `json:"error"`
+	// Description is a human-readable explanation safe to return to clients.
+	Description string `json:"error_description,omitempty"`
+}
+
+// Error implements the error interface.
+func (e *TokenError) Error() string {
+	if e.Description == "" {
+		return e.Code
+	}
+	return e.Code + ": " + e.Description
+}
+
+// PasswordAuthenticator verifies resource-owner credentials for the password
+// grant and returns the subject's claims. Only Sub, Scope, Roles, Teams, Tenant,
+// and Ext are used; the provider sets the issuer, audience, and lifetimes.
+type PasswordAuthenticator func(ctx context.Context, username, password string, scope []string) (*Claims, error)
+
+// ClientAuthenticator verifies client credentials for the client_credentials
+// grant and returns the client's claims, following the same rules as
+// PasswordAuthenticator.
+type ClientAuthenticator func(ctx context.Context, clientID, clientSecret string, scope []string) (*Claims, error)
+
+// tokenHandlerConfig holds the grants enabled on a token handler.
+type tokenHandlerConfig struct {
+	password          PasswordAuthenticator
+	clientCredentials ClientAuthenticator
+}
+
+// TokenHandlerOption enables a grant on OIDCProvider.TokenHandler.
+type TokenHandlerOption func(*tokenHandlerConfig)
+
+// WithPasswordGrant enables grant_type=password using fn to check credentials.
+func WithPasswordGrant(fn PasswordAuthenticator) TokenHandlerOption {
+	return func(cfg *tokenHandlerConfig) { cfg.password = fn }
+}
+
+// WithClientCredentialsGrant enables grant_type=client_credentials using fn to
+// check credentials. Clients may authenticate with HTTP Basic or form fields.
+func WithClientCredentialsGrant(fn ClientAuthenticator) TokenHandlerOption {
+	return func(cfg *tokenHandlerConfig) { cfg.clientCredentials = fn }
+}
+
+// TokenHandler returns an http.HandlerFunc implementing the /oauth2/token
+// endpoint. The refresh_token grant is always enabled and accepts refresh
+// tokens issued by this provider; a scope parameter narrows the reissued access
+// token to a subset of the refresh token's scopes, and anything broader is
+// rejected with invalid_scope. Password and client_credentials are enabled
+// through options. Responses follow RFC 6749 §5.
+func (p *OIDCProvider) TokenHandler(opts ...TokenHandlerOption) http.HandlerFunc {
+	cfg := tokenHandlerConfig{}
+	for _, o := range opts {
+		o(&cfg)
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			writeTokenError(w, http.StatusMethodNotAllowed, &TokenError{Code: TokenErrInvalidRequest, Description: "token requests must use POST"})
+			return
+		}
+		if err := r.ParseForm(); err != nil {
+			writeTokenError(w, http.StatusBadRequest, &TokenError{Code: TokenErrInvalidRequest, Description: "malformed form body"})
+			return
+		}
+
+		scope := strings.Fields(r.PostForm.Get("scope"))
+
+		var (
+			base         *Claims
+			tokErr       *TokenError
+			noRenew      bool
+			refreshScope []string
+		)
+		switch grant := r.PostForm.Get("grant_type"); grant {
+		case "":
+			tokErr = &TokenError{Code: TokenErrInvalidRequest, Description: "grant_type is required"}
+		case GrantTypeRefreshToken:
+			base, tokErr = p.refreshGrant(r.PostForm.Get("refresh_token"))
+			if tokErr != nil {
+				break
+			}
+			// RFC 6749 §6: the new refresh token keeps the original scope even
+			// when the access token is narrowed.
+			refreshScope = base.Scope
+			if len(scope) > 0 {
+				base.Scope, tokErr = narrowScope(base.Scope, scope)
+			}
+		case GrantTypePassword:
+			if cfg.password == nil {
+				tokErr = &TokenError{Code: TokenErrUnsupportedGrantType, Description: fmt.Sprintf("grant type %q is not enabled", grant)}
+				break
+			}
+			base, tokErr = passwordGrant(r.Context(), cfg.password, r.PostForm.Get("username"), r.PostForm.Get("password"), scope)
+		case GrantTypeClientCredentials:
+			if cfg.clientCredentials == nil {
+				tokErr = &TokenError{Code: TokenErrUnsupportedGrantType, Description: fmt.Sprintf("grant type %q is not enabled", grant)}
+				break
+			}
+			base, tokErr = clientCredentialsGrant(r, cfg.clientCredentials, scope)
+			// RFC 6749 §4.4.3: no refresh token for client credentials.
+			noRenew = true
+		default:
+			tokErr = &TokenError{Code: TokenErrUnsupportedGrantType, Description: fmt.Sprintf("grant type %q is not supported", grant)}
+		}
+		if tokErr != nil {
+			writeTokenError(w, tokenErrorStatus(tokErr.Code), tokErr)
+			return
+		}
+
+		now := time.Now()
+		claims := &Claims{
+			Sub:    base.Sub,
+			Iss:    p.cfg.Issuer,
+			Aud:    p.cfg.Audiences,
+			Iat:    now,
+			Exp:    now.Add(p.cfg.TokenTTL),
+			Scope:  base.Scope,
+			Roles:  base.Roles,
+			Teams:  base.Teams,
+			Tenant: base.Tenant,
+			Ext:    base.Ext,
+		}
+		if refreshScope == nil {
+			refreshScope = claims.Scope
+		}
+		tokens, err := p.issueTokenSet(r.Context(), claims, refreshScope)
+		if err != nil {
+			writeTokenError(w, http.StatusInternalServerError, &TokenError{Code: TokenErrServerError, Description: "failed to issue tokens"})
+			return
+		}
+		if noRenew {
+			tokens.RefreshToken = ""
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-store")
+		w.Header().Set("Pragma", "no-cache")
+		w.WriteHeader(http.StatusOK)
+		_ = json.NewEncoder(w).Encode(tokens)
+	}
+}
+
+// refreshGrant verifies a refresh token issued by this provider and returns
+// the claims it carries.
+func (p *OIDCProvider) refreshGrant(raw string) (*Claims, *TokenError) {
+	if raw == "" {
+		return nil, &TokenError{Code: TokenErrInvalidRequest, Description: "refresh_token is required"}
+	}
+	if len(raw) > MaxTokenSize {
+		return nil, &TokenError{Code: TokenErrInvalidGrant, Description: "refresh token is too large"}
+	}
+
+	keySet, err := p.ks.GetKeySet()
+	if err != nil {
+		return nil, &TokenError{Code: TokenErrServerError, Description: "failed to load signing keys"}
+	}
+
+	token, err := jwt.ParseString(raw,
+		jwt.WithKeySet(keySet, jws.WithRequireKid(false)),
+		jwt.WithValidate(true),
+		jwt.WithIssuer(p.cfg.Issuer),
+	)
+	if err != nil {
+		return nil, &TokenError{Code: TokenErrInvalidGrant, Description: "refresh token is invalid or expired"}
+	}
+	if use, _ := token.PrivateClaims()[tokenUseClaim].(string); use != tokenUseRefresh {
+		return nil, &TokenError{Code: TokenErrInvalidGrant, Description: "token is not a refresh token"}
+	}
+
+	return claimsFromJWT(token), nil
+}
+
+// narrowScope returns requested if every scope in it was granted, and an
+// invalid_scope error naming the first one that was not otherwise.
+func narrowScope(granted, requested []string) ([]string, *TokenError) {
+	allowed := make(map[string]bool, len(granted))
+	for _, s := range granted {
+		allowed[s] = true
+	}
+	for _, s := range requested {
+		if !allowed[s] {
+			return nil, &TokenError{Code: TokenErrInvalidScope, Description: fmt.Sprintf("scope %q exceeds the scope of the refresh token", s)}
+		}
+	}
+	return requested, nil
+}
+
+func passwordGrant(ctx context.Context, fn PasswordAuthenticator, username, password string, scope []string) (*Claims, *TokenError) {
+	if username == "" || password == "" {
+		return nil, &TokenError{Code: TokenErrInvalidRequest, Description: "username and password are required"}
+	}
+	claims, err := fn(ctx, username, password, scope)
+	return checkAuthenticated(claims, err, TokenErrInvalidGrant, "invalid resource owner credentials")
+}
+
+func clientCredentialsGrant(r *http.Request, fn ClientAuthenticator, scope []string) (*Claims, *TokenError) {
+	clientID, clientSecret, ok := r.BasicAuth()
+	if !ok {
+		clientID, clientSecret = r.PostForm.Get("client_id"), r.PostForm.Get("client_secret")
+	}
+	if clientID == "" || clientSecret == "" {
+		return nil, &TokenError{Code: TokenErrInvalidClient, Description: "client authentication is required"}
+	}
+	claims, err := fn(r.Context(), clientID, clientSecret, scope)
+	return checkAuthenticated(claims, err, TokenErrInvalidClient, "invalid client credentials")
+}
+
+// checkAuthenticated converts an authenticator result into claims or a token
+// error, using code and description when err is not already a *TokenError.
+func checkAuthenticated(claims *Claims, err error, code, description string) (*Claims, *TokenError) {
+	if err != nil {
+		var tokErr *TokenError
+		if errors.As(err, &tokErr) {
+			return nil, tokErr
+		}
+		return nil, &TokenError{Code: code, Description: description}
+	}
+	if claims == nil || claims.Sub == "" {
+		return nil, &TokenError{Code: TokenErrServerError, Description: "authenticator returned no subject"}
+	}
+	return claims, nil
+}
+
+// claimsFromJWT extracts the subject and application claims from a parsed
+// token. Registered time and audience claims are left for the caller to set.
+func claimsFromJWT(token jwt.Token) *Claims {
+	claims := &Claims{Sub: token.Subject()}
+	for k, v := range token.PrivateClaims() {
+		switch k {
+		case "scope":
+			claims.Scope = stringSlice(v)
+		case "roles":
+			claims.Roles = stringSlice(v)
+		case "teams":
+			claims.Teams = stringSlice(v)
+		case "tenant":
+			claims.Tenant, _ = v.(string)
+		case tokenUseClaim:
+		default:
+			if claims.Ext == nil {
+				claims.Ext = make(map[string]interface{})
+			}
+			claims.Ext[k] = v
+		}
+	}
+	return claims
+}
+
+// stringSlice converts a decoded JSON array claim into a []string, skipping
+// non-string entries.
+func stringSlice(v interface{}) []string {
+	switch vals := v.(type) {
+	case []string:
+		return vals
+	case []interface{}:
+		out := make([]string, 0, len(vals))
+		for _, item := range vals {
+			if s, ok := item.(string); ok {
+				out = append(out, s)
+			}
+		}
+		return out
+	default:
+		return nil
+	}
+}
+
+// tokenErrorStatus maps an OAuth 2.0 error code to its HTTP status.
+func tokenErrorStatus(code string) int {
+	switch code {
+	case TokenErrInvalidClient:
+		return http.StatusUnauthorized
+	case TokenErrServerError:
+		return http.StatusInternalServerError
+	default:
+		return http.StatusBadRequest
+	}
+}
+
+func writeTokenError(w http.ResponseWriter, status int, tokErr *TokenError) {
+	if status == http.StatusUnauthorized {
+		w.Header().Set("WWW-Authenticate", `Basic realm="token"`)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(tokErr)
+}
@@ -0,0 +1,416 @@
+package authn_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/lestrrat-go/jwx/v2/jws"
+	"github.com/lestrrat-go/jwx/v2/jwt"
+
+	"github.com/penguintechinc/penguin-libs/packages/go-aaa/authn"
+	"github.com/penguintechinc/penguin-libs/packages/go-aaa/crypto"
+)
+
+func newTestProvider(t *testing.T) (*authn.OIDCProvider, crypto.KeyStore) {
+	t.Helper()
+	ks, err := crypto.NewMemoryKeyStore(crypto.AlgorithmES256)
+	if err != nil {
+		t.Fatalf("NewMemoryKeyStore: %v", err)
+	}
+	p, err := authn.NewOIDCProvider(authn.OIDCProviderConfig{
+		Issuer:    "https://issuer.example.com",
+		Audiences: []string{"my-app"},
+		Algorithm: "ES256",
+	}, ks)
+	if err != nil {
+		t.Fatalf("NewOIDCProvider: %v", err)
+	}
+	return p, ks
+}
+
+func testPasswordAuthenticator(_ context.Context, username, password string, scope []string) (*authn.Claims, error) {
+	if username != "alice" || password != "correct-horse" {
+		return nil, errors.New("bad credentials")
+	}
+	return &authn.Claims{Sub: "user-alice", Roles: []string{"admin"}, Scope: scope, Tenant: "acme"}, nil
+}
+
+func testClientAuthenticator(_ context.Context, clientID, clientSecret string, scope []string) (*authn.Claims, error) {
+	if clientID != "svc-reporting" || clientSecret != "s3cret" {
+		return nil, errors.New("bad client")
+	}
+	return &authn.Claims{Sub: clientID, Scope: scope}, nil
+}
+
+func postToken(t *testing.T, h http.Handler, form url.Values, mutate func(*http.Request)) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodPost, "/oauth2/token", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	if mutate != nil {
+		mutate(req)
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+func decodeTokenSet(t *testing.T, rec *httptest.ResponseRecorder) *authn.TokenSet {
+	t.Helper()
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if got := rec.Header().Get("Cache-Control"); got != "no-store" {
+		t.Errorf("Cache-Control = %q, want no-store", got)
+	}
+	var ts authn.TokenSet
+	if err := json.NewDecoder(rec.Body).Decode(&ts); err != nil {
+		t.Fatalf("decode token response: %v", err)
+	}
+	return &ts
+}
+
+func assertTokenError(t *testing.T, rec *httptest.ResponseRecorder, status int, code string) {
+	t.Helper()
+	if rec.Code != status {
+		t.Errorf("status = %d, want %d (body %s)", rec.Code, status, rec.Body.String())
+	}
+	var body authn.TokenError
+	if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+		t.Fatalf("decode error response: %v", err)
+	}
+	if body.Code != code {
+		t.Errorf("error = %q, want %q", body.Code, code)
+	}
+}
+
+func parseWithKeyStore(t *testing.T, ks crypto.KeyStore, raw string) jwt.Token {
+	t.Helper()
+	keySet, err := ks.GetKeySet()
+	if err != nil {
+		t.Fatalf("GetKeySet: %v", err)
+	}
+	tok, err := jwt.ParseString(raw, jwt.WithKeySet(keySet, jws.WithRequireKid(false)), jwt.WithValidate(true))
+	if err != nil {
+		t.Fatalf("parse token: %v", err)
+	}
+	return tok
+}
+
+func TestTokenHandler_PasswordGrantIssuesTokens(t *testing.T) {
+	p, ks := newTestProvider(t)
+	h := p.TokenHandler(authn.WithPasswordGrant(testPasswordAuthenticator))
+
+	rec := postToken(t, h, url.Values{
+		"grant_type": {"password"},
+		"username":   {"alice"},
+		"password":   {"correct-horse"},
+		"scope":      {"read write"},
+	}, nil)
+	ts := decodeTokenSet(t, rec)
+
+	if ts.TokenType != "Bearer" || ts.AccessToken == "" || ts.RefreshToken == "" {
+		t.Fatalf("unexpected token set: %+v", ts)
+	}
+	access := parseWithKeyStore(t, ks, ts.AccessToken)
+	if access.Subject() != "user-alice" {
+		t.Errorf("sub = %q, want user-alice", access.Subject())
+	}
+	if access.Issuer() != "https://issuer.example.com" {
+		t.Errorf("iss = %q", access.Issuer())
+	}
+	if tenant, _ := access.Get("tenant"); tenant != "acme" {
+		t.Errorf("tenant = %v, want acme", tenant)
+	}
+}
+
+func TestTokenHandler_RefreshGrantReissuesClaims(t *testing.T) {
+	p, ks := newTestProvider(t)
+	h := p.TokenHandler(authn.WithPasswordGrant(testPasswordAuthenticator))
+
+	initial := decodeTokenSet(t, postToken(t, h, url.Values{
+		"grant_type": {"password"},
+		"username":   {"alice"},
+		"password":   {"correct-horse"},
+		"scope":      {"read"},
+	}, nil))
+
+	refreshed := decodeTokenSet(t, postToken(t, h, url.Values{
+		"grant_type":    {"refresh_token"},
+		"refresh_token": {initial.RefreshToken},
+	}, nil))
+
+	access := parseWithKeyStore(t, ks, refreshed.AccessToken)
+	if access.Subject() != "user-alice" {
+		t.Errorf("sub = %q, want user-alice", access.Subject())
+	}
+	roles, _ := access.Get("roles")
+	if rs, ok := roles.([]interface{}); !ok || len(rs) != 1 || rs[0] != "admin" {
+		t.Errorf("roles = %v, want [admin]", roles)
+	}
+	if _, ok := access.Get("token_use"); ok {
+		t.Error("access token must not carry the refresh marker")
+	}
+}
+
+// tokenScope returns the scope claim of tok as a space-separated string.
+func tokenScope(t *testing.T, tok jwt.Token) string {
+	t.Helper()
+	raw, _ := tok.Get("scope")
+	items, _ := raw.([]interface{})
+	scopes := make([]string, 0, len(items))
+	for _, item := range items {
+		s, _ := item.(string)
+		scopes = append(scopes, s)
+	}
+	return strings.Join(scopes, " ")
+}
+
+// issueReadWrite runs the password grant for alice with scope "read write".
+func issueReadWrite(t *testing.T, h http.Handler) *authn.TokenSet {
+	t.Helper()
+	return decodeTokenSet(t, postToken(t, h, url.Values{
+		"grant_type": {"password"},
+		"username":   {"alice"},
+		"password":   {"correct-horse"},
+		"scope":      {"read write"},
+	}, nil))
+}
+
+func TestTokenHandler_RefreshGrantNarrowsScope(t *testing.T) {
+	p, ks := newTestProvider(t)
+	h := p.TokenHandler(authn.WithPasswordGrant(testPasswordAuthenticator))
+	initial := issueReadWrite(t, h)
+
+	narrowed := decodeTokenSet(t, postToken(t, h, url.Values{
+		"grant_type":    {"refresh_token"},
+		"refresh_token": {initial.RefreshToken},
+		"scope":         {"read"},
+	}, nil))
+
+	if got := tokenScope(t, parseWithKeyStore(t, ks, narrowed.AccessToken)); got != "read" {
+		t.Errorf("access token scope = %q, want %q", got, "read")
+	}
+	// RFC 6749 §6: the new refresh token keeps the original scope, so the
+	// client can later widen back up to it.
+	if got := tokenScope(t, parseWithKeyStore(t, ks, narrowed.RefreshToken)); got != "read write" {
+		t.Errorf("refresh token scope = %q, want %q", got, "read write")
+	}
+}
+
+func TestTokenHandler_RefreshGrantEqualScope(t *testing.T) {
+	p, ks := newTestProvider(t)
+	h := p.TokenHandler(authn.WithPasswordGrant(testPasswordAuthenticator))
+	initial := issueReadWrite(t, h)
+
+	tests := []struct {
+		name string
+		form url.Values
+		want string
+	}{
+		{"same set reordered", url.Values{"scope": {"write read"}}, "write read"},
+		{"omitted", url.Values{}, "read write"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.form.Set("grant_type", "refresh_token")
+			tt.form.Set("refresh_token", initial.RefreshToken)
+			refreshed := decodeTokenSet(t, postToken(t, h, tt.form, nil))
+			if got := tokenScope(t, parseWithKeyStore(t, ks, refreshed.AccessToken)); got != tt.want {
+				t.Errorf("access token scope = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTokenHandler_RefreshGrantRejectsBroaderScope(t *testing.T) {
+	p, _ := newTestProvider(t)
+	h := p.TokenHandler(authn.WithPasswordGrant(testPasswordAuthenticator))
+	initial := issueReadWrite(t, h)
+
+	rec := postToken(t, h, url.Values{
+		"grant_type":    {"refresh_token"},
+		"refresh_token": {initial.RefreshToken},
+		"scope":         {"read write admin"},
+	}, nil)
+	assertTokenError(t, rec, http.StatusBadRequest, authn.TokenErrInvalidScope)
+}
+
+func TestTokenHandler_RefreshGrantRejectsAccessToken(t *testing.T) {
+	p, _ := newTestProvider(t)
+	h := p.TokenHandler(authn.WithPasswordGrant(testPasswordAuthenticator))
+
+	initial := decodeTokenSet(t, postToken(t, h, url.Values{
+		"grant_type": {"password"},
+		"username":   {"alice"},
+		"password":   {"correct-horse"},
+	}, nil))
+
+	rec := postToken(t, h, url.Values{
+		"grant_type":    {"refresh_token"},
+		"refresh_token": {initial.AccessToken},
+	}, nil)
+	assertTokenError(t, rec, http.StatusBadRequest, authn.TokenErrInvalidGrant)
+}
+
+func TestTokenHandler_RefreshGrantRejectsForeignSignature(t *testing.T) {
+	p, _ := newTestProvider(t)
+	other, _ := newTestProvider(t)
+
+	foreign := decodeTokenSet(t, postToken(t, other.TokenHandler(authn.WithPasswordGrant(testPasswordAuthenticator)), url.Values{
+		"grant_type": {"password"},
+		"username":   {"alice"},
+		"password":   {"correct-horse"},
+	}, nil))
+
+	rec := postToken(t, p.TokenHandler(), url.Values{
+		"grant_type":    {"refresh_token"},
+		"refresh_token": {foreign.RefreshToken},
+	}, nil)
+	assertTokenError(t, rec, http.StatusBadRequest, authn.TokenErrInvalidGrant)
+}
+
+func TestTokenHandler_ClientCredentialsGrant(t *testing.T) {
+	p, _ := newTestProvider(t)
+	h := p.TokenHandler(authn.WithClientCredentialsGrant(testClientAuthenticator))
+
+	ts := decodeTokenSet(t, postToken(t, h, url.Values{"grant_type": {"client_credentials"}}, func(r *http.Request) {
+		r.SetBasicAuth("svc-reporting", "s3cret")
+	}))
+	if ts.AccessToken == "" {
+		t.Error("expected access token")
+	}
+	if ts.RefreshToken != "" {
+		t.Error("client credentials grant must not issue a refresh token")
+	}
+
+	rec := postToken(t, h, url.Values{
+		"grant_type":    {"client_credentials"},
+		"client_id":     {"svc-reporting"},
+		"client_secret": {"wrong"},
+	}, nil)
+	assertTokenError(t, rec, http.StatusUnauthorized, authn.TokenErrInvalidClient)
+	if rec.Header().Get("WWW-Authenticate") == "" {
+		t.Error("expected WWW-Authenticate header on invalid_client")
+	}
+}
+
+func TestTokenHandler_AuthenticatorTokenErrorIsPreserved(t *testing.T) {
+	p, _ := newTestProvider(t)
+	h := p.TokenHandler(authn.WithPasswordGrant(func(context.Context, string, string, []string) (*authn.Claims, error) {
+		return nil, &authn.TokenError{Code: authn.TokenErrInvalidScope, Description: "scope admin not allowed"}
+	}))
+
+	rec := postToken(t, h, url.Values{
+		"grant_type": {"password"},
+		"username":   {"alice"},
+		"password":   {"correct-horse"},
+		"scope":      {"admin"},
+	}, nil)
+	assertTokenError(t, rec, http.StatusBadRequest, authn.TokenErrInvalidScope)
+}
+
+func TestTokenHandler_Errors(t *testing.T) {
+	p, _ := newTestProvider(t)
+	h := p.TokenHandler(authn.WithPasswordGrant(testPasswordAuthenticator))
+
+	tests := []struct {
+		name   string
+		form   url.Values
+		status int
+		code   string
+	}{
+		{"missing grant type", url.Values{}, http.StatusBadRequest, authn.TokenErrInvalidRequest},
+		{"unsupported grant type", url.Values{"grant_type": {"urn:ietf:params:oauth:grant-type:device_code"}}, http.StatusBadRequest, authn.TokenErrUnsupportedGrantType},
+		{"grant not enabled", url.Values{"grant_type": {"client_credentials"}}, http.StatusBadRequest, authn.TokenErrUnsupportedGrantType},
+		{"missing password", url.Values{"grant_type": {"password"}, "username": {"alice"}}, http.StatusBadRequest, authn.TokenErrInvalidRequest},
+		{"wrong password", url.Values{"grant_type": {"password"}, "username": {"alice"}, "password": {"nope"}}, http.StatusBadRequest, authn.TokenErrInvalidGrant},
+		{"missing refresh token", url.Values{"grant_type": {"refresh_token"}}, http.StatusBadRequest, authn.TokenErrInvalidRequest},
+		{"malformed refresh token", url.Values{"grant_type": {"refresh_token"}, "refresh_token": {"not-a-jwt"}}, http.StatusBadRequest, authn.TokenErrInvalidGrant},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assertTokenError(t, postToken(t, h, tt.form, nil), tt.status, tt.code)
+		})
+	}
+}
+
+func TestTokenHandler_RejectsNonPost(t *testing.T) {
+	p, _ := newTestProvider(t)
+	rec := httptest.NewRecorder()
+	p.TokenHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/oauth2/token", nil))
+
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("status = %d, want 405", rec.Code)
+	}
+	if rec.Header().Get("Allow") != http.MethodPost {
+		t.Errorf("Allow = %q, want POST", rec.Header().Get("Allow"))
+	}
+}
+
+func TestTokenHandler_TokensCarryUniqueJTI(t *testing.T) {
+	p, ks := newTestProvider(t)
+	h := p.TokenHandler(authn.WithPasswordGrant(testPasswordAuthenticator))
+
+	form := url.Values{
+		"grant_type": {"password"},
+		"username":   {"alice"},
+		"password":   {"correct-horse"},
+	}
+	first := decodeTokenSet(t, postToken(t, h, form, nil))
+	second := decodeTokenSet(t, postToken(t, h, form, nil))
+
+	seen := make(map[string]bool)
+	for _, raw := range []string{first.AccessToken, first.IDToken, first.RefreshToken, second.AccessToken} {
+		jti := parseWithKeyStore(t, ks, raw).JwtID()
+		if jti == "" {
+			t.Fatal("expected every token to carry a jti")
+		}
+		if seen[jti] {
+			t.Errorf("jti %q issued more than once", jti)
+		}
+		seen[jti] = true
+	}
+}
+
+func TestOIDCProvider_TokenHeaderCarriesKID(t *testing.T) {
+	p, ks := newTestProvider(t)
+	h := p.TokenHandler(authn.WithPasswordGrant(testPasswordAuthenticator))
+	set := decodeTokenSet(t, postToken(t, h, url.Values{
+		"grant_type": {"password"},
+		"username":   {"alice"},
+		"password":   {"correct-horse"},
+	}, nil))
+	key, err := ks.GetSigningKey()
+	if err != nil {
+		t.Fatalf("GetSigningKey: %v", err)
+	}
+	if key.KeyID() == "" {
+		t.Fatal("expected the signing key to carry a kid")
+	}
+
+	for _, raw := range []string{set.AccessToken, set.IDToken, set.RefreshToken} {
+		msg, err := jws.ParseString(raw)
+		if err != nil {
+			t.Fatalf("jws.ParseString: %v", err)
+		}
+		if got := msg.Signatures()[0].ProtectedHeaders().KeyID(); got != key.KeyID() {
+			t.Errorf("expected kid header %q, got %q", key.KeyID(), got)
+		}
+	}
+
+	// The kid alone must select the key from the published JWKS.
+	keySet, err := ks.GetKeySet()
+	if err != nil {
+		t.Fatalf("GetKeySet: %v", err)
+	}
+	if _, err := jwt.ParseString(set.AccessToken, jwt.WithKeySet(keySet)); err != nil {
+		t.Errorf("expected token to verify with kid lookup: %v", err)
+	}
+}
@@ -32,6 +32,8 @@ type Claims struct {
 	Iat time.Time `json:"iat"`
 	// Exp is the expiry time of the token (required).
 	Exp time.Time `json:"exp"`
+	// Jti is the unique token identifier, used for replay detection.
+	Jti string `json:"jti,omitempty"`
 	// Scope lists OAuth 2.0 scopes granted to the token.
 	Scope []string `json:"scope,omitempty"`
 	// Roles lists application roles assigned to the subject.
@@ -44,6 +46,13 @@ type Claims struct {
 	Ext map[string]interface{} `json:"ext,omitempty"`
 }
 
+// ClaimsTransformer adapts the claims extracted from a provider's token to the
+// application, for example mapping a "groups" claim in Ext onto Roles or deriving
+// Tenant. Ext holds the token's nested "ext" claim merged with every top-level
+// claim not mapped onto a Claims field. It may modify and return its argument or return a new Claims; an error
+// rejects the token.
+type ClaimsTransformer func(*Claims) (*Claims, error)
+
 // Validate checks that all required fields are present and within allowed bounds.
 func (c *Claims) Validate() error {
 	if c.Sub == "" {
@@ -24,6 +24,16 @@ type OIDCRPConfig struct {
 	// ClockSkew is the allowed clock skew when validating token timestamps.
 	// Minimum is zero, maximum is 5 minutes. Defaults to 30 seconds.
 	ClockSkew time.Duration
+	// JWE, when set, decrypts encrypted (JWE) tokens before their inner signed
+	// JWT is verified. Signed tokens are still accepted.
+	JWE *JWEValidator
+	// ClaimsTransformer, when set, is applied to the claims extracted from every
+	// validated token before they are checked with Claims.Validate. The Ext it
+	// receives also carries the provider's unmapped top-level claims.
+	ClaimsTransformer ClaimsTransformer
+	// BatchConcurrency bounds the number of tokens ValidateTokens verifies at
+	// once. Defaults to DefaultBatchConcurrency.
+	BatchConcurrency int
 }
 
 // Validate checks that the OIDCRPConfig is complete and valid.
@@ -43,6 +53,9 @@ func (c *OIDCRPConfig) Validate() error {
 	if c.ClockSkew == 0 {
 		c.ClockSkew = 30 * time.Second
 	}
+	if c.BatchConcurrency <= 0 {
+		c.BatchConcurrency = DefaultBatchConcurrency
+	}
 	const maxClockSkew = 5 * time.Minute
 	if c.ClockSkew > maxClockSkew {
 		return fmt.Errorf("oidc_rp_config: clock_skew must not exceed %s", maxClockSkew)
@@ -95,6 +108,8 @@ type SPIFFEConfig struct {
 	WorkloadSocket string
 	// AllowedIDs lists SPIFFE IDs that are permitted to authenticate.
 	// Each entry must begin with "spiffe://" (required, at least one entry).
+	// An entry of "spiffe://example.org/*" or the bare trust domain
+	// "spiffe://example.org" allows every ID in example.org.
 	AllowedIDs []string
 }
 
@@ -113,6 +128,9 @@ func (c *SPIFFEConfig) Validate() error {
 		if !strings.HasPrefix(id, "spiffe://") {
 			return fmt.Errorf("spiffe_config: allowed_ids[%d] %q must begin with \"spiffe://\"", i, id)
 		}
+		if _, err := parseAllowedSPIFFEID(id); err != nil {
+			return fmt.Errorf("spiffe_config: allowed_ids[%d] %q is invalid: %w", i, id, err)
+		}
 	}
 	return nil
 }
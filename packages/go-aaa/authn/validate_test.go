@@ -162,3 +162,32 @@ func TestSPIFFEConfig_Validate_InvalidIDPrefix(t *testing.T) {
 		t.Fatal("expected error for ID without spiffe:// prefix")
 	}
 }
+
+func TestSPIFFEConfig_Validate_TrustDomainWildcard(t *testing.T) {
+	cfg := authn.SPIFFEConfig{
+		TrustDomain:    "example.org",
+		WorkloadSocket: "/run/spire/sockets/agent.sock",
+		AllowedIDs:     []string{"spiffe://example.org/*", "spiffe://other.org"},
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("expected trust-domain entries to be valid, got: %v", err)
+	}
+}
+
+func TestSPIFFEConfig_Validate_InvalidPatterns(t *testing.T) {
+	for _, id := range []string{
+		"spiffe://example.org/service/*",
+		"spiffe://*.example.org/*",
+		"spiffe://example.org/svc*",
+		"spiffe://Example.org/service",
+	} {
+		cfg := authn.SPIFFEConfig{
+			TrustDomain:    "example.org",
+			WorkloadSocket: "/run/spire/sockets/agent.sock",
+			AllowedIDs:     []string{id},
+		}
+		if err := cfg.Validate(); err == nil {
+			t.Errorf("expected error for allowed id %q", id)
+		}
+	}
+}
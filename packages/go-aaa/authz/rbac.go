@@ -85,6 +85,37 @@ func HasAllScopes(scopes []string, required ...string) bool {
 	return true
 }
 
+// Decision describes the result of an authorization check, including which of the
+// required scopes were absent from the granted set.
+type Decision struct {
+	// Allowed is true when every required scope was granted.
+	Allowed bool
+	// MissingScopes lists the required scopes not present in GrantedScopes, in the
+	// order they were required.
+	MissingScopes []string
+	// GrantedScopes is the resolved set of scopes the check was evaluated against.
+	GrantedScopes []string
+}
+
+// Explain evaluates granted against required and returns a Decision describing
+// the outcome. Unlike HasAllScopes it reports every missing scope rather than
+// stopping at the first.
+func Explain(granted []string, required ...string) Decision {
+	var missing []string
+	for _, req := range required {
+		if !HasScope(granted, req) {
+			missing = append(missing, req)
+		}
+	}
+	out := make([]string, len(granted))
+	copy(out, granted)
+	return Decision{
+		Allowed:       len(missing) == 0,
+		MissingScopes: missing,
+		GrantedScopes: out,
+	}
+}
+
 // ValidateScopes checks that every entry in scopes follows the "resource:action" format.
 // It returns an error describing the first violation found.
 func ValidateScopes(scopes []string) error {
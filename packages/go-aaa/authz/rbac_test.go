@@ -121,3 +121,41 @@ func TestValidateScopes_MultipleColonsAllowed(t *testing.T) {
 		t.Errorf("expected no error for multi-part scope, got %v", err)
 	}
 }
+
+func TestExplain_AllGranted(t *testing.T) {
+	d := Explain([]string{"report:read", "report:write"}, "report:read")
+	if !d.Allowed {
+		t.Error("expected Allowed when all required scopes are granted")
+	}
+	if len(d.MissingScopes) != 0 {
+		t.Errorf("expected no missing scopes, got %v", d.MissingScopes)
+	}
+	if len(d.GrantedScopes) != 2 {
+		t.Errorf("expected 2 granted scopes, got %v", d.GrantedScopes)
+	}
+}
+
+func TestExplain_PartialMatch(t *testing.T) {
+	d := Explain([]string{"report:read", "user:write"}, "report:read", "report:delete", "admin:all")
+	if d.Allowed {
+		t.Error("expected Allowed to be false for partial match")
+	}
+	want := []string{"report:delete", "admin:all"}
+	if len(d.MissingScopes) != len(want) {
+		t.Fatalf("expected missing %v, got %v", want, d.MissingScopes)
+	}
+	for i, s := range want {
+		if d.MissingScopes[i] != s {
+			t.Errorf("missing[%d]: expected %q, got %q", i, s, d.MissingScopes[i])
+		}
+	}
+}
+
+func TestExplain_GrantedScopesIsCopy(t *testing.T) {
+	granted := []string{"report:read"}
+	d := Explain(granted, "report:read")
+	d.GrantedScopes[0] = "tampered"
+	if granted[0] != "report:read" {
+		t.Error("Explain should not alias the caller's granted slice")
+	}
+}
@@ -0,0 +1,65 @@
+package authz
+
+import (
+	"context"
+	"sync"
+)
+
+// TenantRBACEnforcer holds per-tenant role registries so that the same role name
+// can grant different scopes in different tenants. Lookups for a tenant without
+// its own definition of a role fall back to the default registry.
+type TenantRBACEnforcer struct {
+	mu       sync.RWMutex
+	fallback *RBACEnforcer
+	tenants  map[string]*RBACEnforcer
+}
+
+// NewTenantRBACEnforcer creates a TenantRBACEnforcer that falls back to the given
+// default registry. A nil fallback is replaced with an empty registry.
+func NewTenantRBACEnforcer(fallback *RBACEnforcer) *TenantRBACEnforcer {
+	if fallback == nil {
+		fallback = NewRBACEnforcer()
+	}
+	return &TenantRBACEnforcer{
+		fallback: fallback,
+		tenants:  make(map[string]*RBACEnforcer),
+	}
+}
+
+// RegisterTenantRole adds or replaces a role in the registry for the given tenant.
+func (t *TenantRBACEnforcer) RegisterTenantRole(tenant string, role Role) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	e, ok := t.tenants[tenant]
+	if !ok {
+		e = NewRBACEnforcer()
+		t.tenants[tenant] = e
+	}
+	e.RegisterRole(role)
+}
+
+// Default returns the fallback registry consulted when a tenant does not define a role.
+func (t *TenantRBACEnforcer) Default() *RBACEnforcer {
+	return t.fallback
+}
+
+// ScopesForTenantRole returns the scopes assigned to the named role within tenant,
+// falling back to the default registry when the tenant does not define the role.
+// The boolean reports whether the role was found in either registry.
+func (t *TenantRBACEnforcer) ScopesForTenantRole(tenant, roleName string) ([]string, bool) {
+	t.mu.RLock()
+	e, ok := t.tenants[tenant]
+	t.mu.RUnlock()
+	if ok {
+		if scopes, found := e.ScopesForRole(roleName); found {
+			return scopes, true
+		}
+	}
+	return t.fallback.ScopesForRole(roleName)
+}
+
+// ScopesForRoleInContext resolves the named role using the tenant carried by the
+// Claims in ctx. When no tenant is present only the default registry is consulted.
+func (t *TenantRBACEnforcer) ScopesForRoleInContext(ctx context.Context, roleName string) ([]string, bool) {
+	return t.ScopesForTenantRole(TenantFromContext(ctx), roleName)
+}
@@ -0,0 +1,60 @@
+package authz
+
+import (
+	"context"
+	"testing"
+)
+
+func TestTenantRBACEnforcer_SameRoleDiffersPerTenant(t *testing.T) {
+	te := NewTenantRBACEnforcer(nil)
+	te.RegisterTenantRole("tenant-a", Role{Name: "editor", Scopes: []string{"doc:write"}})
+	te.RegisterTenantRole("tenant-b", Role{Name: "editor", Scopes: []string{"doc:read"}})
+
+	a, ok := te.ScopesForTenantRole("tenant-a", "editor")
+	if !ok || len(a) != 1 || a[0] != "doc:write" {
+		t.Errorf("expected tenant-a editor to grant doc:write, got %v (found=%v)", a, ok)
+	}
+	b, ok := te.ScopesForTenantRole("tenant-b", "editor")
+	if !ok || len(b) != 1 || b[0] != "doc:read" {
+		t.Errorf("expected tenant-b editor to grant doc:read, got %v (found=%v)", b, ok)
+	}
+}
+
+func TestTenantRBACEnforcer_FallsBackToDefault(t *testing.T) {
+	te := NewTenantRBACEnforcer(NewRBACEnforcer(Role{Name: "viewer", Scopes: []string{"report:read"}}))
+	te.RegisterTenantRole("tenant-a", Role{Name: "editor", Scopes: []string{"doc:write"}})
+
+	scopes, ok := te.ScopesForTenantRole("tenant-a", "viewer")
+	if !ok || len(scopes) != 1 || scopes[0] != "report:read" {
+		t.Errorf("expected fallback viewer scopes, got %v (found=%v)", scopes, ok)
+	}
+	if _, ok := te.ScopesForTenantRole("tenant-b", "editor"); ok {
+		t.Error("expected tenant-a role to be invisible to tenant-b")
+	}
+}
+
+func TestTenantRBACEnforcer_TenantOverridesDefault(t *testing.T) {
+	te := NewTenantRBACEnforcer(NewRBACEnforcer(Role{Name: "editor", Scopes: []string{"doc:read"}}))
+	te.RegisterTenantRole("tenant-a", Role{Name: "editor", Scopes: []string{"doc:write"}})
+
+	scopes, _ := te.ScopesForTenantRole("tenant-a", "editor")
+	if len(scopes) != 1 || scopes[0] != "doc:write" {
+		t.Errorf("expected tenant definition to override default, got %v", scopes)
+	}
+}
+
+func TestTenantRBACEnforcer_ScopesForRoleInContext(t *testing.T) {
+	te := NewTenantRBACEnforcer(NewRBACEnforcer(Role{Name: "editor", Scopes: []string{"doc:read"}}))
+	te.RegisterTenantRole("tenant-a", Role{Name: "editor", Scopes: []string{"doc:write"}})
+
+	ctx := ContextWithClaims(context.Background(), makeClaims("u", "tenant-a"))
+	scopes, _ := te.ScopesForRoleInContext(ctx, "editor")
+	if len(scopes) != 1 || scopes[0] != "doc:write" {
+		t.Errorf("expected tenant-a scopes from context, got %v", scopes)
+	}
+
+	scopes, _ = te.ScopesForRoleInContext(context.Background(), "editor")
+	if len(scopes) != 1 || scopes[0] != "doc:read" {
+		t.Errorf("expected default scopes without tenant, got %v", scopes)
+	}
+}
@@ -0,0 +1,189 @@
+package crypto
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"golang.org/x/crypto/scrypt"
+)
+
+// encryptedFileVersion is the current encrypted key file format version.
+const encryptedFileVersion = 1
+
+// Key derivation identifiers recorded in the encrypted file header.
+const (
+	kdfScrypt = "scrypt"
+	kdfNone   = "none"
+)
+
+// scrypt parameters; N=2^15, r=8, p=1 is the interactive-login recommendation.
+const (
+	scryptN       = 1 << 15
+	scryptR       = 8
+	scryptP       = 1
+	scryptSaltLen = 16
+	aesKeyLen     = 32
+)
+
+// ErrDecryptKeyFile is returned when an encrypted key file cannot be
+// decrypted, typically because the passphrase or key is wrong.
+var ErrDecryptKeyFile = errors.New("decrypt key file: wrong passphrase or key, or file is corrupted")
+
+// ErrPlaintextKeyFile is returned when encryption is configured but the key
+// file is stored unencrypted and WithPlaintextMigration was not given.
+var ErrPlaintextKeyFile = errors.New("key file is not encrypted but encryption is configured")
+
+// encryptedKeyFile is the on-disk envelope for an encrypted FileKeyStore. The
+// ciphertext is the AES-256-GCM sealed plaintext fileKeyStoreData JSON; the
+// header fields are bound to it as additional authenticated data.
+type encryptedKeyFile struct {
+	Version    int    `json:"version"`
+	KDF        string `json:"kdf"`
+	Salt       []byte `json:"salt,omitempty"`
+	Nonce      []byte `json:"nonce"`
+	Ciphertext []byte `json:"ciphertext"`
+}
+
+// FileKeyStoreOption configures a FileKeyStore.
+type FileKeyStoreOption func(*FileKeyStore)
+
+// WithPassphrase encrypts the key file with AES-256-GCM under a key derived
+// from passphrase with scrypt. A fresh salt is generated on every save.
+func WithPassphrase(passphrase []byte) FileKeyStoreOption {
+	return func(fks *FileKeyStore) {
+		fks.encryption = &keyFileEncryption{passphrase: passphrase}
+	}
+}
+
+// WithEncryptionKey encrypts the key file with AES-256-GCM using key directly.
+// key must be 32 bytes.
+func WithEncryptionKey(key []byte) FileKeyStoreOption {
+	return func(fks *FileKeyStore) {
+		fks.encryption = &keyFileEncryption{key: key}
+	}
+}
+
+// WithPlaintextMigration allows an encrypted FileKeyStore to load an existing
+// plaintext key file and rewrite it encrypted. Without it, a plaintext file is
+// rejected with ErrPlaintextKeyFile, so a file replaced with an unencrypted key
+// is not silently accepted.
+func WithPlaintextMigration() FileKeyStoreOption {
+	return func(fks *FileKeyStore) {
+		fks.migratePlaintext = true
+	}
+}
+
+// keyFileEncryption holds the secret used to seal a FileKeyStore's file.
+// Exactly one of passphrase and key is set.
+type keyFileEncryption struct {
+	passphrase []byte
+	key        []byte
+}
+
+func (e *keyFileEncryption) validate() error {
+	if e.key != nil && len(e.key) != aesKeyLen {
+		return fmt.Errorf("encryption key must be %d bytes, got %d", aesKeyLen, len(e.key))
+	}
+	if e.key == nil && len(e.passphrase) == 0 {
+		return errors.New("passphrase must not be empty")
+	}
+	return nil
+}
+
+// seal encrypts plaintext into a serialized encryptedKeyFile.
+func (e *keyFileEncryption) seal(plaintext []byte) ([]byte, error) {
+	env := encryptedKeyFile{Version: encryptedFileVersion, KDF: kdfNone}
+	key := e.key
+	if key == nil {
+		env.KDF = kdfScrypt
+		env.Salt = make([]byte, scryptSaltLen)
+		if _, err := rand.Read(env.Salt); err != nil {
+			return nil, fmt.Errorf("generate salt: %w", err)
+		}
+		var err error
+		if key, err = e.deriveKey(env.Salt); err != nil {
+			return nil, err
+		}
+	}
+
+	aead, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	env.Nonce = make([]byte, aead.NonceSize())
+	if _, err := rand.Read(env.Nonce); err != nil {
+		return nil, fmt.Errorf("generate nonce: %w", err)
+	}
+	env.Ciphertext = aead.Seal(nil, env.Nonce, plaintext, env.additionalData())
+
+	return json.MarshalIndent(env, "", "  ")
+}
+
+// open decrypts a parsed encryptedKeyFile.
+func (e *keyFileEncryption) open(env *encryptedKeyFile) ([]byte, error) {
+	if env.Version != encryptedFileVersion {
+		return nil, fmt.Errorf("unsupported encrypted key file version %d", env.Version)
+	}
+
+	var key []byte
+	switch env.KDF {
+	case kdfScrypt:
+		if e.passphrase == nil {
+			return nil, errors.New("key file is passphrase-encrypted but no passphrase was configured")
+		}
+		var err error
+		if key, err = e.deriveKey(env.Salt); err != nil {
+			return nil, err
+		}
+	case kdfNone:
+		if e.key == nil {
+			return nil, errors.New("key file is key-encrypted but no encryption key was configured")
+		}
+		key = e.key
+	default:
+		return nil, fmt.Errorf("unsupported key derivation %q", env.KDF)
+	}
+
+	aead, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(env.Nonce) != aead.NonceSize() {
+		return nil, ErrDecryptKeyFile
+	}
+	plaintext, err := aead.Open(nil, env.Nonce, env.Ciphertext, env.additionalData())
+	if err != nil {
+		return nil, ErrDecryptKeyFile
+	}
+	return plaintext, nil
+}
+
+func (e *keyFileEncryption) deriveKey(salt []byte) ([]byte, error) {
+	key, err := scrypt.Key(e.passphrase, salt, scryptN, scryptR, scryptP, aesKeyLen)
+	if err != nil {
+		return nil, fmt.Errorf("derive key: %w", err)
+	}
+	return key, nil
+}
+
+// additionalData binds the header to the ciphertext so it cannot be altered
+// (e.g. downgrading the KDF) without failing authentication.
+func (env *encryptedKeyFile) additionalData() []byte {
+	return []byte(fmt.Sprintf("go-aaa-keystore/v%d/%s/%x", env.Version, env.KDF, env.Salt))
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("create cipher: %w", err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("create gcm: %w", err)
+	}
+	return aead, nil
+}
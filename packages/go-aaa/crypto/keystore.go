@@ -5,13 +5,16 @@
 package crypto
 
 import (
+	stdcrypto "crypto"
 	"crypto/ecdsa"
 	"crypto/elliptic"
 	"crypto/rand"
 	"crypto/rsa"
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"os"
+	"path/filepath"
 	"sync"
 
 	"github.com/lestrrat-go/jwx/v2/jwa"
@@ -91,6 +94,9 @@ func (ks *MemoryKeyStore) RotateKey() error {
 	if err := setKeyAlgorithm(signingKey, ks.algorithm); err != nil {
 		return err
 	}
+	if err := setKeyID(signingKey); err != nil {
+		return fmt.Errorf("memory_keystore: %w", err)
+	}
 
 	publicKey, err := signingKey.PublicKey()
 	if err != nil {
@@ -117,25 +123,48 @@ type fileKeyStoreData struct {
 
 // FileKeyStore is a thread-safe, disk-backed key store. It persists the current
 // signing key to a JSON file, loading it on creation and writing after each rotation.
+// The file is plaintext unless WithPassphrase or WithEncryptionKey is given.
 type FileKeyStore struct {
-	mu        sync.RWMutex
-	algorithm Algorithm
-	filePath  string
-	inner     *MemoryKeyStore
+	// rotateMu serializes rotations, including their disk writes. mu only
+	// guards swapping inner, so readers never wait on key generation, key file
+	// encryption or disk I/O.
+	rotateMu         sync.Mutex
+	mu               sync.RWMutex
+	algorithm        Algorithm
+	filePath         string
+	inner            *MemoryKeyStore
+	encryption       *keyFileEncryption
+	migratePlaintext bool
 }
 
 // NewFileKeyStore creates a FileKeyStore backed by filePath. If the file exists and
 // contains a valid key, it is loaded; otherwise a new key is generated and saved.
-func NewFileKeyStore(algorithm Algorithm, filePath string) (*FileKeyStore, error) {
+// When encryption is configured, an existing plaintext file is rejected unless
+// WithPlaintextMigration is given, in which case it is loaded and rewritten
+// encrypted.
+func NewFileKeyStore(algorithm Algorithm, filePath string, opts ...FileKeyStoreOption) (*FileKeyStore, error) {
 	fks := &FileKeyStore{
 		algorithm: algorithm,
 		filePath:  filePath,
 	}
+	for _, o := range opts {
+		o(fks)
+	}
+	if fks.encryption != nil {
+		if err := fks.encryption.validate(); err != nil {
+			return nil, fmt.Errorf("file_keystore: %w", err)
+		}
+	}
 
-	loaded, err := fks.loadFromDisk()
+	loaded, wasPlaintext, err := fks.loadFromDisk()
 	if err != nil {
 		return nil, fmt.Errorf("file_keystore: failed to load key from %q: %w", filePath, err)
 	}
+	if loaded && wasPlaintext && fks.encryption != nil {
+		if err := fks.saveToDisk(); err != nil {
+			return nil, fmt.Errorf("file_keystore: failed to encrypt existing key file %q: %w", filePath, err)
+		}
+	}
 	if !loaded {
 		inner, err := NewMemoryKeyStore(algorithm)
 		if err != nil {
@@ -165,53 +194,94 @@ func (fks *FileKeyStore) GetKeySet() (jwk.Set, error) {
 }
 
 // RotateKey generates a new key, replacing the current key both in memory and on disk.
+// The key is generated and written to disk before it is swapped in, so
+// concurrent GetSigningKey and GetKeySet calls are only blocked for the swap
+// and keep returning the previous key until the new one is persisted. If
+// saving fails, the previous key stays in use.
 func (fks *FileKeyStore) RotateKey() error {
-	fks.mu.Lock()
-	defer fks.mu.Unlock()
+	fks.rotateMu.Lock()
+	defer fks.rotateMu.Unlock()
 
-	if err := fks.inner.RotateKey(); err != nil {
+	next, err := NewMemoryKeyStore(fks.algorithm)
+	if err != nil {
+		return err
+	}
+	data, err := fks.marshalKeyFile(next.signingKey)
+	if err != nil {
 		return err
 	}
-	return fks.saveToDisk()
+	if err := fks.writeKeyFile(data); err != nil {
+		return err
+	}
+
+	fks.mu.Lock()
+	fks.inner = next
+	fks.mu.Unlock()
+	return nil
 }
 
 // loadFromDisk attempts to read and deserialize the key from the backing file.
-// It returns (true, nil) if the key was successfully loaded, (false, nil) if the
-// file does not exist, and (false, err) on any other error.
-func (fks *FileKeyStore) loadFromDisk() (bool, error) {
+// It returns loaded=true if the key was successfully loaded, loaded=false with a
+// nil error if the file does not exist, and an error on any other failure.
+// plaintext reports whether the file was stored unencrypted.
+func (fks *FileKeyStore) loadFromDisk() (loaded, plaintext bool, err error) {
 	data, err := os.ReadFile(fks.filePath)
 	if os.IsNotExist(err) {
-		return false, nil
+		return false, false, nil
 	}
 	if err != nil {
-		return false, fmt.Errorf("read file: %w", err)
+		return false, false, fmt.Errorf("read file: %w", err)
+	}
+
+	var env encryptedKeyFile
+	if err := json.Unmarshal(data, &env); err != nil {
+		return false, false, fmt.Errorf("unmarshal key data: %w", err)
+	}
+	plaintext = env.Ciphertext == nil
+	if plaintext && fks.encryption != nil && !fks.migratePlaintext {
+		return false, false, ErrPlaintextKeyFile
+	}
+	if !plaintext {
+		if fks.encryption == nil {
+			return false, false, fmt.Errorf("key file is encrypted but no passphrase or key was configured")
+		}
+		if data, err = fks.encryption.open(&env); err != nil {
+			return false, false, err
+		}
 	}
 
 	var stored fileKeyStoreData
 	if err := json.Unmarshal(data, &stored); err != nil {
-		return false, fmt.Errorf("unmarshal key data: %w", err)
+		return false, false, fmt.Errorf("unmarshal key data: %w", err)
 	}
 
 	keySet, err := jwk.ParseString(string(stored.PrivateKey))
 	if err != nil {
-		return false, fmt.Errorf("parse jwk: %w", err)
+		return false, false, fmt.Errorf("parse jwk: %w", err)
 	}
 	if keySet.Len() == 0 {
-		return false, fmt.Errorf("key file contains no keys")
+		return false, false, fmt.Errorf("key file contains no keys")
 	}
 
 	signingKey, ok := keySet.Key(0)
 	if !ok {
-		return false, fmt.Errorf("failed to retrieve key at index 0")
+		return false, false, fmt.Errorf("failed to retrieve key at index 0")
+	}
+	// Files written before key IDs were assigned carry none; the ID is derived
+	// from the key, so computing it on load yields the same value.
+	if signingKey.KeyID() == "" {
+		if err := setKeyID(signingKey); err != nil {
+			return false, false, err
+		}
 	}
 
 	publicKey, err := signingKey.PublicKey()
 	if err != nil {
-		return false, fmt.Errorf("derive public key: %w", err)
+		return false, false, fmt.Errorf("derive public key: %w", err)
 	}
 	pubSet := jwk.NewSet()
 	if err := pubSet.AddKey(publicKey); err != nil {
-		return false, fmt.Errorf("add public key to set: %w", err)
+		return false, false, fmt.Errorf("add public key to set: %w", err)
 	}
 
 	inner := &MemoryKeyStore{
@@ -220,7 +290,7 @@ func (fks *FileKeyStore) loadFromDisk() (bool, error) {
 		keySet:     pubSet,
 	}
 	fks.inner = inner
-	return true, nil
+	return true, plaintext, nil
 }
 
 // saveToDisk serializes the current private key to the backing file.
@@ -230,10 +300,19 @@ func (fks *FileKeyStore) saveToDisk() error {
 	if err != nil {
 		return err
 	}
+	data, err := fks.marshalKeyFile(signingKey)
+	if err != nil {
+		return err
+	}
+	return fks.writeKeyFile(data)
+}
 
+// marshalKeyFile serializes signingKey into the key file contents, encrypting
+// them when encryption is configured.
+func (fks *FileKeyStore) marshalKeyFile(signingKey jwk.Key) ([]byte, error) {
 	keyJSON, err := json.Marshal(signingKey)
 	if err != nil {
-		return fmt.Errorf("marshal signing key: %w", err)
+		return nil, fmt.Errorf("marshal signing key: %w", err)
 	}
 
 	stored := fileKeyStoreData{
@@ -242,10 +321,45 @@ func (fks *FileKeyStore) saveToDisk() error {
 	}
 	data, err := json.MarshalIndent(stored, "", "  ") // #nosec G117 -- keystore legitimately serializes private key material
 	if err != nil {
-		return fmt.Errorf("marshal key data: %w", err)
+		return nil, fmt.Errorf("marshal key data: %w", err)
+	}
+	if fks.encryption != nil {
+		if data, err = fks.encryption.seal(data); err != nil {
+			return nil, fmt.Errorf("encrypt key data: %w", err)
+		}
+	}
+	return data, nil
+}
+
+// writeKeyFile replaces the backing file with data. It writes a temporary file
+// in the same directory and renames it over the old one, so a crash mid-write
+// never leaves a truncated key file.
+func (fks *FileKeyStore) writeKeyFile(data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(fks.filePath), filepath.Base(fks.filePath)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("create temp key file: %w", err)
 	}
+	defer os.Remove(tmp.Name()) // no-op once renamed
 
-	return os.WriteFile(fks.filePath, data, 0o600)
+	if err := tmp.Chmod(0o600); err != nil {
+		tmp.Close()
+		return fmt.Errorf("chmod temp key file: %w", err)
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("write temp key file: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("sync temp key file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("close temp key file: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), fks.filePath); err != nil {
+		return fmt.Errorf("replace key file: %w", err)
+	}
+	return nil
 }
 
 // generateKey creates a new raw private key for the given algorithm.
@@ -271,3 +385,14 @@ func setKeyAlgorithm(key jwk.Key, algorithm Algorithm) error {
 		return fmt.Errorf("unsupported algorithm %q", algorithm)
 	}
 }
+
+// setKeyID sets the key's "kid" to its RFC 7638 JWK thumbprint (SHA-256,
+// base64url without padding), so the ID is stable for the key and identical on
+// the private key and the public key derived from it.
+func setKeyID(key jwk.Key) error {
+	tp, err := key.Thumbprint(stdcrypto.SHA256)
+	if err != nil {
+		return fmt.Errorf("compute key thumbprint: %w", err)
+	}
+	return key.Set(jwk.KeyIDKey, base64.RawURLEncoding.EncodeToString(tp))
+}
@@ -1,9 +1,16 @@
 package crypto_test
 
 import (
+	"bytes"
+	stdcrypto "crypto"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/penguintechinc/penguin-libs/packages/go-aaa/crypto"
 )
@@ -184,3 +191,323 @@ func TestFileKeyStore_GetKeySet_NotEmpty(t *testing.T) {
 		t.Fatal("expected at least one public key in key set")
 	}
 }
+
+// thumbprint returns the RFC 7638 thumbprint of ks's current signing key.
+func thumbprint(t *testing.T, ks crypto.KeyStore) string {
+	t.Helper()
+	key, err := ks.GetSigningKey()
+	if err != nil {
+		t.Fatalf("GetSigningKey: %v", err)
+	}
+	tp, err := key.Thumbprint(stdcrypto.SHA256)
+	if err != nil {
+		t.Fatalf("Thumbprint: %v", err)
+	}
+	return string(tp)
+}
+
+func TestFileKeyStore_PassphraseRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "keystore.json")
+	passphrase := []byte("correct horse battery staple")
+
+	ks1, err := crypto.NewFileKeyStore(crypto.AlgorithmES256, path, crypto.WithPassphrase(passphrase))
+	if err != nil {
+		t.Fatalf("NewFileKeyStore (first): %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read key file: %v", err)
+	}
+	if strings.Contains(string(data), "private_key") || strings.Contains(string(data), `"d"`) {
+		t.Fatal("encrypted key file contains plaintext key material")
+	}
+	for _, field := range []string{`"version"`, `"salt"`, `"nonce"`, `"ciphertext"`} {
+		if !strings.Contains(string(data), field) {
+			t.Errorf("expected header field %s in key file", field)
+		}
+	}
+
+	ks2, err := crypto.NewFileKeyStore(crypto.AlgorithmES256, path, crypto.WithPassphrase(passphrase))
+	if err != nil {
+		t.Fatalf("NewFileKeyStore (second): %v", err)
+	}
+	if thumbprint(t, ks1) != thumbprint(t, ks2) {
+		t.Error("expected the same key after reloading the encrypted file")
+	}
+}
+
+func TestFileKeyStore_WrongPassphraseFails(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "keystore.json")
+
+	if _, err := crypto.NewFileKeyStore(crypto.AlgorithmRS256, path, crypto.WithPassphrase([]byte("right"))); err != nil {
+		t.Fatalf("NewFileKeyStore: %v", err)
+	}
+
+	_, err := crypto.NewFileKeyStore(crypto.AlgorithmRS256, path, crypto.WithPassphrase([]byte("wrong")))
+	if !errors.Is(err, crypto.ErrDecryptKeyFile) {
+		t.Fatalf("expected ErrDecryptKeyFile, got %v", err)
+	}
+}
+
+func TestFileKeyStore_EncryptionKeyRoundTripAndRotation(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "keystore.json")
+	key := bytes.Repeat([]byte{0x42}, 32)
+
+	ks, err := crypto.NewFileKeyStore(crypto.AlgorithmES256, path, crypto.WithEncryptionKey(key))
+	if err != nil {
+		t.Fatalf("NewFileKeyStore: %v", err)
+	}
+	if err := ks.RotateKey(); err != nil {
+		t.Fatalf("RotateKey: %v", err)
+	}
+
+	reloaded, err := crypto.NewFileKeyStore(crypto.AlgorithmES256, path, crypto.WithEncryptionKey(key))
+	if err != nil {
+		t.Fatalf("reload: %v", err)
+	}
+	if thumbprint(t, ks) != thumbprint(t, reloaded) {
+		t.Error("expected reloaded key to match the rotated key")
+	}
+
+	otherKey := bytes.Repeat([]byte{0x24}, 32)
+	if _, err := crypto.NewFileKeyStore(crypto.AlgorithmES256, path, crypto.WithEncryptionKey(otherKey)); !errors.Is(err, crypto.ErrDecryptKeyFile) {
+		t.Errorf("expected ErrDecryptKeyFile for wrong key, got %v", err)
+	}
+}
+
+func TestFileKeyStore_EncryptedFileRequiresSecret(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "keystore.json")
+
+	if _, err := crypto.NewFileKeyStore(crypto.AlgorithmES256, path, crypto.WithPassphrase([]byte("secret"))); err != nil {
+		t.Fatalf("NewFileKeyStore: %v", err)
+	}
+	if _, err := crypto.NewFileKeyStore(crypto.AlgorithmES256, path); err == nil {
+		t.Error("expected error loading an encrypted file without a passphrase")
+	}
+}
+
+func TestFileKeyStore_EncryptsExistingPlaintextFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "keystore.json")
+
+	plain, err := crypto.NewFileKeyStore(crypto.AlgorithmES256, path)
+	if err != nil {
+		t.Fatalf("NewFileKeyStore (plaintext): %v", err)
+	}
+
+	encrypted, err := crypto.NewFileKeyStore(crypto.AlgorithmES256, path,
+		crypto.WithPassphrase([]byte("migrate")), crypto.WithPlaintextMigration())
+	if err != nil {
+		t.Fatalf("NewFileKeyStore (encrypted): %v", err)
+	}
+	if thumbprint(t, plain) != thumbprint(t, encrypted) {
+		t.Error("expected migration to keep the existing key")
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read key file: %v", err)
+	}
+	if !strings.Contains(string(data), `"ciphertext"`) {
+		t.Error("expected plaintext file to be rewritten encrypted")
+	}
+}
+
+func TestFileKeyStore_RejectsPlaintextFileWhenEncrypted(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "keystore.json")
+	if _, err := crypto.NewFileKeyStore(crypto.AlgorithmES256, path); err != nil {
+		t.Fatalf("NewFileKeyStore (plaintext): %v", err)
+	}
+	before, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read key file: %v", err)
+	}
+
+	_, err = crypto.NewFileKeyStore(crypto.AlgorithmES256, path, crypto.WithEncryptionKey(make([]byte, 32)))
+	if !errors.Is(err, crypto.ErrPlaintextKeyFile) {
+		t.Fatalf("expected ErrPlaintextKeyFile, got %v", err)
+	}
+	after, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read key file: %v", err)
+	}
+	if string(before) != string(after) {
+		t.Error("expected the rejected plaintext file to be left untouched")
+	}
+}
+
+func TestFileKeyStore_InvalidEncryptionKeyLength(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "keystore.json")
+	if _, err := crypto.NewFileKeyStore(crypto.AlgorithmES256, path, crypto.WithEncryptionKey([]byte("short"))); err == nil {
+		t.Error("expected error for a non-32-byte encryption key")
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Error("expected no key file to be written for an invalid configuration")
+	}
+}
+
+func TestFileKeyStore_RotateKeyDoesNotBlockReaders(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "keystore.json")
+	// Passphrase encryption makes each save slow enough (scrypt) that a reader
+	// blocked on it would stand out.
+	ks, err := crypto.NewFileKeyStore(crypto.AlgorithmES256, path, crypto.WithPassphrase([]byte("rotation")))
+	if err != nil {
+		t.Fatalf("NewFileKeyStore: %v", err)
+	}
+
+	const (
+		rotations = 3
+		readers   = 4
+	)
+	issued := map[string]bool{thumbprint(t, ks): true}
+	done := make(chan struct{})
+
+	type readerResult struct {
+		seen    map[string]bool
+		maxWait time.Duration
+		err     error
+	}
+	results := make(chan readerResult, readers)
+	for range readers {
+		go func() {
+			res := readerResult{seen: make(map[string]bool)}
+			defer func() { results <- res }()
+			for {
+				select {
+				case <-done:
+					return
+				default:
+				}
+				start := time.Now()
+				key, err := ks.GetSigningKey()
+				if wait := time.Since(start); wait > res.maxWait {
+					res.maxWait = wait
+				}
+				if err != nil {
+					res.err = err
+					return
+				}
+				tp, err := key.Thumbprint(stdcrypto.SHA256)
+				if err != nil {
+					res.err = err
+					return
+				}
+				res.seen[string(tp)] = true
+				time.Sleep(100 * time.Microsecond)
+			}
+		}()
+	}
+
+	minRotation := time.Duration(1<<63 - 1)
+	for range rotations {
+		start := time.Now()
+		if err := ks.RotateKey(); err != nil {
+			t.Fatalf("RotateKey: %v", err)
+		}
+		minRotation = min(minRotation, time.Since(start))
+		issued[thumbprint(t, ks)] = true
+	}
+	close(done)
+
+	for range readers {
+		res := <-results
+		if res.err != nil {
+			t.Fatalf("reader: %v", res.err)
+		}
+		for tp := range res.seen {
+			if !issued[tp] {
+				t.Error("reader observed a signing key the store never issued")
+			}
+		}
+		if res.maxWait >= minRotation/2 {
+			t.Errorf("GetSigningKey blocked for %v, rotations take at least %v", res.maxWait, minRotation)
+		}
+	}
+
+	reloaded, err := crypto.NewFileKeyStore(crypto.AlgorithmES256, path, crypto.WithPassphrase([]byte("rotation")))
+	if err != nil {
+		t.Fatalf("reload: %v", err)
+	}
+	if thumbprint(t, reloaded) != thumbprint(t, ks) {
+		t.Error("key file does not hold the current signing key")
+	}
+}
+
+func TestMemoryKeyStore_KeyIDIsThumbprint(t *testing.T) {
+	for _, alg := range []crypto.Algorithm{crypto.AlgorithmRS256, crypto.AlgorithmES256} {
+		t.Run(string(alg), func(t *testing.T) {
+			ks, err := crypto.NewMemoryKeyStore(alg)
+			if err != nil {
+				t.Fatalf("NewMemoryKeyStore: %v", err)
+			}
+			key, err := ks.GetSigningKey()
+			if err != nil {
+				t.Fatalf("GetSigningKey: %v", err)
+			}
+			want := base64.RawURLEncoding.EncodeToString([]byte(thumbprint(t, ks)))
+			if key.KeyID() != want {
+				t.Errorf("expected kid %q (RFC 7638 thumbprint), got %q", want, key.KeyID())
+			}
+
+			keySet, err := ks.GetKeySet()
+			if err != nil {
+				t.Fatalf("GetKeySet: %v", err)
+			}
+			if _, ok := keySet.LookupKeyID(want); !ok {
+				t.Error("expected the public key to carry the same kid")
+			}
+
+			if err := ks.RotateKey(); err != nil {
+				t.Fatalf("RotateKey: %v", err)
+			}
+			rotated, err := ks.GetSigningKey()
+			if err != nil {
+				t.Fatalf("GetSigningKey: %v", err)
+			}
+			if rotated.KeyID() == "" || rotated.KeyID() == key.KeyID() {
+				t.Errorf("expected a new kid after rotation, got %q", rotated.KeyID())
+			}
+		})
+	}
+}
+
+func TestFileKeyStore_AssignsKeyIDToFileWithoutOne(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "keystore.json")
+	ks, err := crypto.NewFileKeyStore(crypto.AlgorithmES256, path)
+	if err != nil {
+		t.Fatalf("NewFileKeyStore: %v", err)
+	}
+	key, err := ks.GetSigningKey()
+	if err != nil {
+		t.Fatalf("GetSigningKey: %v", err)
+	}
+
+	// Strip the kid, as in files written before key IDs were assigned.
+	var stored map[string]any
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read key file: %v", err)
+	}
+	if err := json.Unmarshal(data, &stored); err != nil {
+		t.Fatalf("unmarshal key file: %v", err)
+	}
+	delete(stored["private_key"].(map[string]any), "kid")
+	if data, err = json.Marshal(stored); err != nil {
+		t.Fatalf("marshal key file: %v", err)
+	}
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatalf("write key file: %v", err)
+	}
+
+	reloaded, err := crypto.NewFileKeyStore(crypto.AlgorithmES256, path)
+	if err != nil {
+		t.Fatalf("reload: %v", err)
+	}
+	got, err := reloaded.GetSigningKey()
+	if err != nil {
+		t.Fatalf("GetSigningKey: %v", err)
+	}
+	if got.KeyID() != key.KeyID() {
+		t.Errorf("expected kid %q to be recomputed on load, got %q", key.KeyID(), got.KeyID())
+	}
+}
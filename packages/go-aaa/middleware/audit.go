@@ -2,39 +2,67 @@ package middleware
 
 import (
 	"context"
+	"net"
 
 	"connectrpc.com/connect"
 
 	"github.com/penguintechinc/penguin-libs/packages/go-aaa/audit"
+	"github.com/penguintechinc/penguin-libs/packages/go-aaa/authn"
 	"github.com/penguintechinc/penguin-libs/packages/go-aaa/authz"
 )
 
 // NewAuditInterceptor returns a ConnectRPC interceptor that automatically emits an
 // audit event after each RPC completes. The event type is EventAuthzGranted on success
-// and EventAuthzDenied on failure. Events whose type appears in the WithSkipAuditTypes
-// option are silently suppressed.
+// and EventAuthzDenied on failure, and the event records the wall time spent in the
+// handler. Events whose type appears in the WithSkipAuditTypes option are silently
+// suppressed. The subject and tenant are taken from the Claims present once the
+// handler returns, including those stored by an authentication interceptor that
+// this one wraps.
 func NewAuditInterceptor(emitter *audit.Emitter, opts ...InterceptorOption) connect.UnaryInterceptorFunc {
 	cfg := applyOptions(opts)
 	return func(next connect.UnaryFunc) connect.UnaryFunc {
 		return func(ctx context.Context, req connect.AnyRequest) (connect.AnyResponse, error) {
 			procedure := req.Spec().Procedure
-			subject := subjectFromContext(ctx)
+			holder := &claimsHolder{claims: authz.ClaimsFromContext(ctx)}
 
-			resp, err := next(ctx, req)
+			timer := audit.StartTimer()
+			resp, err := next(context.WithValue(ctx, claimsHolderKey{}, holder), req)
+			elapsed := timer.Stop()
 
 			eventType, outcome := classifyResult(err)
 			if cfg.skipAuditTypes[eventType] {
 				return resp, err
 			}
 
-			event := audit.NewAuditEvent(eventType, subject, "rpc", procedure, outcome)
-			_ = emitter.Emit(event)
+			if holder.claims != nil {
+				ctx = authz.ContextWithClaims(ctx, holder.claims)
+			}
+			event := audit.NewAuditEvent(eventType, subjectFromContext(ctx), "rpc", procedure, outcome, append(requestMetadata(req), elapsed)...)
+			_ = emitter.EmitContext(ctx, event)
 
 			return resp, err
 		}
 	}
 }
 
+// claimsHolderKey is the context key of the claimsHolder set by NewAuditInterceptor.
+type claimsHolderKey struct{}
+
+// claimsHolder receives the Claims stored by interceptors nested inside
+// NewAuditInterceptor, which cannot see their context.
+type claimsHolder struct {
+	claims *authn.Claims
+}
+
+// contextWithClaims stores claims in ctx and reports them to the enclosing
+// audit interceptor, if any.
+func contextWithClaims(ctx context.Context, claims *authn.Claims) context.Context {
+	if holder, ok := ctx.Value(claimsHolderKey{}).(*claimsHolder); ok {
+		holder.claims = claims
+	}
+	return authz.ContextWithClaims(ctx, claims)
+}
+
 // subjectFromContext extracts the subject from Claims in context, falling back to
 // "anonymous" when no claims are present.
 func subjectFromContext(ctx context.Context) string {
@@ -45,6 +73,29 @@ func subjectFromContext(ctx context.Context) string {
 	return claims.Sub
 }
 
+// requestMetadata returns event options carrying the procedure and protocol as
+// details, plus the peer IP, User-Agent, and request identifier of req. The
+// request ID is read from X-Request-ID, falling back to X-Correlation-ID.
+func requestMetadata(req connect.AnyRequest) []audit.EventOption {
+	requestID := req.Header().Get("X-Request-ID")
+	if requestID == "" {
+		requestID = req.Header().Get("X-Correlation-ID")
+	}
+	sourceIP := req.Peer().Addr
+	if host, _, err := net.SplitHostPort(sourceIP); err == nil {
+		sourceIP = host
+	}
+	return []audit.EventOption{
+		audit.WithDetails(map[string]interface{}{
+			"procedure": req.Spec().Procedure,
+			"protocol":  req.Peer().Protocol,
+		}),
+		audit.WithSourceIP(sourceIP),
+		audit.WithUserAgent(req.Header().Get("User-Agent")),
+		audit.WithRequestID(requestID),
+	}
+}
+
 // classifyResult maps an RPC outcome to an EventType and Outcome pair.
 func classifyResult(err error) (audit.EventType, audit.Outcome) {
 	if err == nil {
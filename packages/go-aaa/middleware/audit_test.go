@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"testing"
+	"time"
 
 	"connectrpc.com/connect"
 
@@ -26,9 +27,30 @@ func buildAuditEmitter(events *[]audit.AuditEvent) *audit.Emitter {
 		if s, ok := m["subject"].(string); ok {
 			e.Subject = s
 		}
+		if r, ok := m["resource"].(string); ok {
+			e.Resource = r
+		}
 		if o, ok := m["outcome"].(string); ok {
 			e.Outcome = audit.Outcome(o)
 		}
+		if tenant, ok := m["tenant"].(string); ok {
+			e.Tenant = tenant
+		}
+		if ip, ok := m["source_ip"].(string); ok {
+			e.SourceIP = ip
+		}
+		if ua, ok := m["user_agent"].(string); ok {
+			e.UserAgent = ua
+		}
+		if rid, ok := m["request_id"].(string); ok {
+			e.RequestID = rid
+		}
+		if d, ok := m["duration_ms"].(float64); ok {
+			e.DurationMs = d
+		}
+		if d, ok := m["details"].(map[string]interface{}); ok {
+			e.Details = d
+		}
 		*events = append(*events, e)
 	})
 	return audit.NewEmitter(sink)
@@ -158,6 +180,81 @@ func TestAuditInterceptor_ErrorPropagates(t *testing.T) {
 	}
 }
 
+func TestAuditInterceptor_RecordsRequestMetadata(t *testing.T) {
+	var received []audit.AuditEvent
+	emitter := buildAuditEmitter(&received)
+	interceptor := NewAuditInterceptor(emitter)
+
+	req := connect.NewRequest(&struct{}{})
+	req.Header().Set("User-Agent", "test-agent/1.0")
+	req.Header().Set("X-Correlation-ID", "corr-7")
+	_, _ = interceptor(noopNext)(context.Background(), req)
+
+	if len(received) != 1 {
+		t.Fatalf("expected 1 audit event, got %d", len(received))
+	}
+	if received[0].UserAgent != "test-agent/1.0" {
+		t.Errorf("expected user agent test-agent/1.0, got %q", received[0].UserAgent)
+	}
+	if received[0].RequestID != "corr-7" {
+		t.Errorf("expected request ID from X-Correlation-ID, got %q", received[0].RequestID)
+	}
+}
+
+func TestAuditInterceptor_SourceIPStripsPort(t *testing.T) {
+	var received []audit.AuditEvent
+	interceptor := NewAuditInterceptor(buildAuditEmitter(&received))
+
+	for _, tc := range []struct{ addr, want string }{
+		{"203.0.113.7:51234", "203.0.113.7"},
+		{"[2001:db8::1]:443", "2001:db8::1"},
+		{"203.0.113.8", "203.0.113.8"},
+	} {
+		received = nil
+		_, _ = interceptor(noopNext)(context.Background(), newPeerRequest(tc.addr))
+		if len(received) != 1 || received[0].SourceIP != tc.want {
+			t.Errorf("peer %q: expected source IP %q, got %+v", tc.addr, tc.want, received)
+		}
+	}
+}
+
+func TestAuditInterceptor_DetailsIncludeProcedure(t *testing.T) {
+	var received []audit.AuditEvent
+	emitter := buildAuditEmitter(&received)
+	interceptor := NewAuditInterceptor(emitter)
+
+	_, _ = interceptor(noopNext)(context.Background(), newProcedureRequest("/svc.Foo/Bar"))
+
+	if len(received) != 1 {
+		t.Fatalf("expected 1 audit event, got %d", len(received))
+	}
+	if received[0].Details["procedure"] != "/svc.Foo/Bar" {
+		t.Errorf("expected procedure detail /svc.Foo/Bar, got %v", received[0].Details["procedure"])
+	}
+	if _, ok := received[0].Details["protocol"]; !ok {
+		t.Error("expected protocol detail to be present")
+	}
+}
+
+func TestAuditInterceptor_RecordsDuration(t *testing.T) {
+	var received []audit.AuditEvent
+	emitter := buildAuditEmitter(&received)
+	interceptor := NewAuditInterceptor(emitter)
+
+	slowNext := func(_ context.Context, _ connect.AnyRequest) (connect.AnyResponse, error) {
+		time.Sleep(5 * time.Millisecond)
+		return nil, nil
+	}
+	_, _ = interceptor(slowNext)(context.Background(), connect.NewRequest(&struct{}{}))
+
+	if len(received) != 1 {
+		t.Fatalf("expected 1 audit event, got %d", len(received))
+	}
+	if received[0].DurationMs < 5 {
+		t.Errorf("expected duration of at least 5ms, got %v", received[0].DurationMs)
+	}
+}
+
 func TestSubjectFromContext_NoClaims(t *testing.T) {
 	if s := subjectFromContext(context.Background()); s != "anonymous" {
 		t.Errorf("expected anonymous, got %q", s)
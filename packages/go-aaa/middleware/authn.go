@@ -6,53 +6,79 @@ import (
 	"crypto/x509"
 	"fmt"
 	"net"
+	"slices"
 
 	"connectrpc.com/connect"
 
 	"github.com/penguintechinc/penguin-libs/packages/go-aaa/authn"
-	"github.com/penguintechinc/penguin-libs/packages/go-aaa/authz"
 )
 
 // NewOIDCInterceptor returns a ConnectRPC interceptor that validates Bearer tokens
 // using the provided OIDCRelyingParty. On success the extracted Claims are stored
 // in the request context via authz.ContextWithClaims.
+//
+// With WithRequiredAudience, tokens whose audience does not include the required
+// value are rejected with CodePermissionDenied. With WithReplayCache, a token
+// presented a second time is rejected with CodeUnauthenticated.
 func NewOIDCInterceptor(rp *authn.OIDCRelyingParty, opts ...InterceptorOption) connect.UnaryInterceptorFunc {
-	cfg := applyOptions(opts)
+	return newOIDCInterceptor(rp.ValidateToken, applyOptions(opts))
+}
+
+// tokenValidator validates a raw bearer token and returns its claims.
+type tokenValidator func(ctx context.Context, token string) (*authn.Claims, error)
+
+func newOIDCInterceptor(validate tokenValidator, cfg interceptorConfig) connect.UnaryInterceptorFunc {
 	return func(next connect.UnaryFunc) connect.UnaryFunc {
 		return func(ctx context.Context, req connect.AnyRequest) (connect.AnyResponse, error) {
-			if cfg.publicProcedures[req.Spec().Procedure] {
+			if cfg.isPublic(req.Spec().Procedure) {
 				return next(ctx, req)
 			}
 
-			auth := req.Header().Get("Authorization")
-			if len(auth) < 8 || auth[:7] != "Bearer " {
+			token, ok := bearerToken(req)
+			if !ok {
 				return nil, connect.NewError(connect.CodeUnauthenticated, fmt.Errorf("missing bearer token"))
 			}
 
-			claims, err := rp.ValidateToken(ctx, auth[7:])
+			claims, err := validate(ctx, token)
 			if err != nil {
 				return nil, connect.NewError(connect.CodeUnauthenticated, fmt.Errorf("invalid token: %w", err))
 			}
+			if cfg.requiredAudience != "" && !slices.Contains(claims.Aud, cfg.requiredAudience) {
+				return nil, connect.NewError(connect.CodePermissionDenied, fmt.Errorf("token audience does not include %q", cfg.requiredAudience))
+			}
+			if cfg.replayCache != nil {
+				if claims.Jti == "" {
+					return nil, connect.NewError(connect.CodeUnauthenticated, fmt.Errorf("token has no jti"))
+				}
+				seen, err := cfg.replayCache.Seen(ctx, claims.Jti, claims.Exp)
+				if err != nil {
+					return nil, connect.NewError(connect.CodeUnavailable, fmt.Errorf("replay cache: %w", err))
+				}
+				if seen {
+					return nil, connect.NewError(connect.CodeUnauthenticated, fmt.Errorf("token replay detected"))
+				}
+			}
 
-			ctx = authz.ContextWithClaims(ctx, claims)
+			ctx = contextWithClaims(ctx, claims)
 			return next(ctx, req)
 		}
 	}
 }
 
 // NewSPIFFEInterceptor returns a ConnectRPC interceptor that validates the mTLS peer
-// certificate chain using the provided SPIFFEAuthenticator. On success synthetic Claims
-// are stored in the request context using the matched SPIFFE ID as the subject.
+// certificate chain using the provided SPIFFEAuthenticator, which verifies it against
+// the trust bundle before checking the SPIFFE ID. On success synthetic Claims are
+// stored in the request context using the matched SPIFFE ID as the subject.
 //
 // The interceptor extracts peer certificates from the TLS connection state. This requires
 // the server to use mutual TLS with ClientAuth set to at least tls.RequestClientCert.
-// The TLS net.Conn must be stored in the context under connContextKey so the interceptor
-// can retrieve the connection state.
+// The TLS net.Conn must be stored in the context under ConnContextKey so the interceptor
+// can retrieve the connection state; WithConnContext does this.
 func NewSPIFFEInterceptor(sa *authn.SPIFFEAuthenticator, opts ...InterceptorOption) connect.UnaryInterceptorFunc {
 	cfg := applyOptions(opts)
 	return func(next connect.UnaryFunc) connect.UnaryFunc {
 		return func(ctx context.Context, req connect.AnyRequest) (connect.AnyResponse, error) {
-			if cfg.publicProcedures[req.Spec().Procedure] {
+			if cfg.isPublic(req.Spec().Procedure) {
 				return next(ctx, req)
 			}
 
@@ -70,12 +96,55 @@ func NewSPIFFEInterceptor(sa *authn.SPIFFEAuthenticator, opts ...InterceptorOpti
 				Sub: spiffeID,
 				Iss: "spiffe",
 			}
-			ctx = authz.ContextWithClaims(ctx, claims)
+			ctx = contextWithClaims(ctx, claims)
 			return next(ctx, req)
 		}
 	}
 }
 
+// NewSPIFFEJWTInterceptor returns a ConnectRPC interceptor that validates a
+// JWT-SVID sent as a Bearer token in the Authorization header using
+// SPIFFEAuthenticator.ValidateJWTSVID. The token's aud claim must contain
+// audience, normally this service's own SPIFFE ID. On success synthetic Claims
+// are stored in the request context using the SPIFFE ID as the subject.
+func NewSPIFFEJWTInterceptor(sa *authn.SPIFFEAuthenticator, audience string, opts ...InterceptorOption) connect.UnaryInterceptorFunc {
+	cfg := applyOptions(opts)
+	return func(next connect.UnaryFunc) connect.UnaryFunc {
+		return func(ctx context.Context, req connect.AnyRequest) (connect.AnyResponse, error) {
+			if cfg.isPublic(req.Spec().Procedure) {
+				return next(ctx, req)
+			}
+
+			token, ok := bearerToken(req)
+			if !ok {
+				return nil, connect.NewError(connect.CodeUnauthenticated, fmt.Errorf("spiffe: missing bearer JWT-SVID"))
+			}
+
+			spiffeID, err := sa.ValidateJWTSVID(ctx, token, audience)
+			if err != nil {
+				return nil, connect.NewError(connect.CodeUnauthenticated, fmt.Errorf("spiffe: JWT-SVID validation failed: %w", err))
+			}
+
+			claims := &authn.Claims{
+				Sub: spiffeID,
+				Iss: "spiffe",
+				Aud: []string{audience},
+			}
+			ctx = contextWithClaims(ctx, claims)
+			return next(ctx, req)
+		}
+	}
+}
+
+// bearerToken returns the token from a "Bearer" Authorization header.
+func bearerToken(req connect.AnyRequest) (string, bool) {
+	auth := req.Header().Get("Authorization")
+	if len(auth) < 8 || auth[:7] != "Bearer " {
+		return "", false
+	}
+	return auth[7:], true
+}
+
 // connContextKey is the context key used to store the raw net.Conn for TLS inspection.
 // Callers must store the connection in the context using this key before the interceptor runs.
 type connContextKey struct{}
@@ -85,27 +154,40 @@ type connContextKey struct{}
 // NewSPIFFEInterceptor.
 var ConnContextKey = connContextKey{}
 
-// tlsPeerCertsFromContext retrieves TLS peer certificates from the net.Conn stored in
-// ctx under ConnContextKey. Returns an error when the connection is absent, not a TLS
-// connection, or has no peer certificates.
+// WithConnContext returns a function suitable for http.Server.ConnContext that
+// stores each accepted connection under ConnContextKey, so NewSPIFFEInterceptor can
+// read the peer certificates of the request's connection:
+//
+//	srv := &http.Server{Handler: mux, TLSConfig: mtlsConfig, ConnContext: middleware.WithConnContext()}
+//
+// With go-h3, set server.Config.ConnContext instead to wire both H2 and H3.
+func WithConnContext() func(ctx context.Context, c net.Conn) context.Context {
+	return func(ctx context.Context, c net.Conn) context.Context {
+		return context.WithValue(ctx, ConnContextKey, c)
+	}
+}
+
+// tlsConnectionStater is implemented by *tls.Conn and by connection adapters, such
+// as go-h3's HTTP/3 one, that expose the TLS state of a non-TCP transport.
+type tlsConnectionStater interface {
+	ConnectionState() tls.ConnectionState
+}
+
+// tlsPeerCertsFromContext retrieves TLS peer certificates from the connection stored
+// in ctx under ConnContextKey. Returns an error when the connection is absent, does
+// not expose TLS state, or has no peer certificates.
 func tlsPeerCertsFromContext(ctx context.Context) ([]*x509.Certificate, error) {
 	connVal := ctx.Value(connContextKey{})
 	if connVal == nil {
 		return nil, fmt.Errorf("no connection found in context; set ConnContextKey via http.Server.ConnContext")
 	}
 
-	tlsConn, ok := connVal.(*tls.Conn)
+	conn, ok := connVal.(tlsConnectionStater)
 	if !ok {
-		// connVal may be stored as the net.Conn interface; unwrap and retry.
-		if nc, isConn := connVal.(net.Conn); isConn {
-			tlsConn, ok = nc.(*tls.Conn)
-		}
-		if !ok {
-			return nil, fmt.Errorf("connection value in context (type %T) is not a *tls.Conn", connVal)
-		}
+		return nil, fmt.Errorf("connection value in context (type %T) is not a TLS connection", connVal)
 	}
 
-	state := tlsConn.ConnectionState()
+	state := conn.ConnectionState()
 	if len(state.PeerCertificates) == 0 {
 		return nil, fmt.Errorf("no peer certificates in TLS connection state")
 	}
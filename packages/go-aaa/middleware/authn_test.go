@@ -2,14 +2,34 @@ package middleware
 
 import (
 	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
 	"errors"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
 	"testing"
 	"time"
 
 	"connectrpc.com/connect"
+	gooidc "github.com/coreos/go-oidc/v3/oidc"
+	"github.com/lestrrat-go/jwx/v2/jwa"
+	"github.com/lestrrat-go/jwx/v2/jws"
+	"github.com/lestrrat-go/jwx/v2/jwt"
+	"github.com/spiffe/go-spiffe/v2/bundle/jwtbundle"
+	"github.com/spiffe/go-spiffe/v2/bundle/x509bundle"
+	"github.com/spiffe/go-spiffe/v2/spiffeid"
 
 	"github.com/penguintechinc/penguin-libs/packages/go-aaa/authn"
 	"github.com/penguintechinc/penguin-libs/packages/go-aaa/authz"
+	aaacrypto "github.com/penguintechinc/penguin-libs/packages/go-aaa/crypto"
 )
 
 func validClaims(sub string) *authn.Claims {
@@ -94,34 +114,315 @@ func TestOIDCInterceptor_PublicProcedure_SkipsValidation(t *testing.T) {
 	}
 }
 
-// buildFakeRPInterceptor constructs a connect.UnaryInterceptorFunc using a fakeRP.
-// Because OIDCRelyingParty is a concrete struct, we replicate the interceptor logic
-// here with the fakeRP interface to keep tests hermetic.
-func buildFakeRPInterceptor(validateFn func(string) (*authn.Claims, error)) connect.UnaryInterceptorFunc {
-	return buildFakeRPInterceptorWithOpts(validateFn)
-}
+func TestOIDCInterceptor_RequiredAudience(t *testing.T) {
+	tests := []struct {
+		name string
+		aud  []string
+		want connect.Code
+	}{
+		{"matching audience", []string{"orders"}, 0},
+		{"one of several audiences", []string{"billing", "orders"}, 0},
+		{"foreign audience", []string{"billing"}, connect.CodePermissionDenied},
+		{"foreign multi-audience", []string{"billing", "inventory"}, connect.CodePermissionDenied},
+		{"no audience", nil, connect.CodePermissionDenied},
+	}
 
-func buildFakeRPInterceptorWithOpts(validateFn func(string) (*authn.Claims, error), opts ...InterceptorOption) connect.UnaryInterceptorFunc {
-	cfg := applyOptions(opts)
-	return func(next connect.UnaryFunc) connect.UnaryFunc {
-		return func(ctx context.Context, req connect.AnyRequest) (connect.AnyResponse, error) {
-			if cfg.publicProcedures[req.Spec().Procedure] {
-				return next(ctx, req)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rp := buildFakeRPInterceptorWithOpts(func(_ string) (*authn.Claims, error) {
+				claims := validClaims("user-abc")
+				claims.Aud = tt.aud
+				return claims, nil
+			}, WithRequiredAudience("orders"))
+
+			req := connect.NewRequest(&struct{}{})
+			req.Header().Set("Authorization", "Bearer token")
+
+			called := false
+			_, err := rp(func(_ context.Context, _ connect.AnyRequest) (connect.AnyResponse, error) {
+				called = true
+				return nil, nil
+			})(context.Background(), req)
+
+			if tt.want == 0 {
+				if err != nil || !called {
+					t.Fatalf("expected request to be allowed, got err=%v called=%v", err, called)
+				}
+				return
 			}
-			auth := req.Header().Get("Authorization")
-			if len(auth) < 8 || auth[:7] != "Bearer " {
-				return nil, connect.NewError(connect.CodeUnauthenticated, errors.New("missing bearer token"))
+			if connect.CodeOf(err) != tt.want {
+				t.Errorf("expected %v, got %v", tt.want, err)
 			}
-			claims, err := validateFn(auth[7:])
-			if err != nil {
-				return nil, connect.NewError(connect.CodeUnauthenticated, err)
+			if called {
+				t.Error("handler must not run for a foreign audience")
 			}
-			ctx = authz.ContextWithClaims(ctx, claims)
-			return next(ctx, req)
+		})
+	}
+}
+
+func TestOIDCInterceptor_NoRequiredAudienceAcceptsAny(t *testing.T) {
+	rp := buildFakeRPInterceptor(func(_ string) (*authn.Claims, error) {
+		claims := validClaims("user-abc")
+		claims.Aud = []string{"anything"}
+		return claims, nil
+	})
+
+	req := connect.NewRequest(&struct{}{})
+	req.Header().Set("Authorization", "Bearer token")
+	if _, err := rp(noopNext)(context.Background(), req); err != nil {
+		t.Errorf("expected no error without a required audience, got %v", err)
+	}
+}
+
+func TestOIDCInterceptor_RejectsRefreshToken(t *testing.T) {
+	ks, err := aaacrypto.NewMemoryKeyStore(aaacrypto.AlgorithmES256)
+	if err != nil {
+		t.Fatalf("NewMemoryKeyStore: %v", err)
+	}
+	var provider *authn.OIDCProvider
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, _ *http.Request) {
+		doc, err := provider.DiscoveryDocument()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
 		}
+		_, _ = w.Write(doc)
+	})
+	mux.HandleFunc("/.well-known/jwks.json", func(w http.ResponseWriter, _ *http.Request) {
+		set, err := ks.GetKeySet()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(set)
+	})
+	srv := httptest.NewTLSServer(mux)
+	defer srv.Close()
+
+	provider, err = authn.NewOIDCProvider(authn.OIDCProviderConfig{
+		Issuer: srv.URL, Audiences: []string{"app"}, Algorithm: "ES256",
+	}, ks)
+	if err != nil {
+		t.Fatalf("NewOIDCProvider: %v", err)
+	}
+	rp, err := authn.NewOIDCRelyingParty(gooidc.ClientContext(context.Background(), srv.Client()),
+		authn.OIDCRPConfig{IssuerURL: srv.URL, ClientID: "app"})
+	if err != nil {
+		t.Fatalf("NewOIDCRelyingParty: %v", err)
 	}
+	ts, err := provider.IssueTokenSet(context.Background(), validClaims("user-abc"))
+	if err != nil {
+		t.Fatalf("IssueTokenSet: %v", err)
+	}
+
+	interceptor := NewOIDCInterceptor(rp)
+	call := func(token string) error {
+		req := connect.NewRequest(&struct{}{})
+		req.Header().Set("Authorization", "Bearer "+token)
+		_, err := interceptor(noopNext)(context.Background(), req)
+		return err
+	}
+	if err := call(ts.AccessToken); err != nil {
+		t.Fatalf("access token: unexpected error: %v", err)
+	}
+	if err := call(ts.RefreshToken); connect.CodeOf(err) != connect.CodeUnauthenticated {
+		t.Errorf("refresh token: expected CodeUnauthenticated, got %v", err)
+	}
+}
+
+// buildFakeRPInterceptor constructs the OIDC interceptor around validateFn instead
+// of a real OIDCRelyingParty, which is a concrete struct, to keep tests hermetic.
+func buildFakeRPInterceptor(validateFn func(string) (*authn.Claims, error)) connect.UnaryInterceptorFunc {
+	return buildFakeRPInterceptorWithOpts(validateFn)
+}
+
+func buildFakeRPInterceptorWithOpts(validateFn func(string) (*authn.Claims, error), opts ...InterceptorOption) connect.UnaryInterceptorFunc {
+	return newOIDCInterceptor(func(_ context.Context, token string) (*authn.Claims, error) {
+		return validateFn(token)
+	}, applyOptions(opts))
 }
 
 func noopNext(_ context.Context, _ connect.AnyRequest) (connect.AnyResponse, error) {
 	return nil, nil
 }
+
+// spiffeClientCert returns a client certificate carrying id as its URI SAN,
+// signed by a fresh CA, and an X.509 bundle for example.org trusting that CA.
+func spiffeClientCert(t *testing.T, id string) (tls.Certificate, *x509bundle.Bundle) {
+	t.Helper()
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("generate CA key: %v", err)
+	}
+	caTmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	caDER, err := x509.CreateCertificate(rand.Reader, caTmpl, caTmpl, &caKey.PublicKey, caKey)
+	if err != nil {
+		t.Fatalf("create CA certificate: %v", err)
+	}
+	caCert, err := x509.ParseCertificate(caDER)
+	if err != nil {
+		t.Fatalf("parse CA certificate: %v", err)
+	}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	uri, err := url.Parse(id)
+	if err != nil {
+		t.Fatalf("parse SPIFFE ID: %v", err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+		URIs:         []*url.URL{uri},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, caCert, &key.PublicKey, caKey)
+	if err != nil {
+		t.Fatalf("create certificate: %v", err)
+	}
+	bundle := x509bundle.FromX509Authorities(spiffeid.RequireTrustDomainFromString("example.org"), []*x509.Certificate{caCert})
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}, bundle
+}
+
+func TestSPIFFEInterceptor_WithConnContext_MTLS(t *testing.T) {
+	const workloadID = "spiffe://example.org/workload"
+	clientCert, bundle := spiffeClientCert(t, workloadID)
+	sa, err := authn.NewSPIFFEAuthenticator(authn.SPIFFEConfig{
+		TrustDomain:    "example.org",
+		WorkloadSocket: "unix:///tmp/agent.sock",
+		AllowedIDs:     []string{workloadID},
+	}, authn.WithX509BundleSource(bundle))
+	if err != nil {
+		t.Fatalf("NewSPIFFEAuthenticator: %v", err)
+	}
+
+	interceptor := NewSPIFFEInterceptor(sa)
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var sub string
+		_, err := interceptor(func(ctx context.Context, _ connect.AnyRequest) (connect.AnyResponse, error) {
+			sub = authz.ClaimsFromContext(ctx).Sub
+			return nil, nil
+		})(r.Context(), connect.NewRequest(&struct{}{}))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+		_, _ = w.Write([]byte(sub))
+	}))
+	srv.EnableHTTP2 = true
+	srv.TLS = &tls.Config{ClientAuth: tls.RequireAnyClientCert}
+	srv.Config.ConnContext = WithConnContext()
+	srv.StartTLS()
+	defer srv.Close()
+
+	client := srv.Client()
+	client.Transport.(*http.Transport).TLSClientConfig.Certificates = []tls.Certificate{clientCert}
+
+	resp, err := client.Get(srv.URL)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", resp.StatusCode, body)
+	}
+	if string(body) != workloadID {
+		t.Errorf("expected subject %q from peer certificate, got %q", workloadID, body)
+	}
+}
+
+func TestSPIFFEInterceptor_NoConnInContext(t *testing.T) {
+	sa, err := authn.NewSPIFFEAuthenticator(authn.SPIFFEConfig{
+		TrustDomain:    "example.org",
+		WorkloadSocket: "unix:///tmp/agent.sock",
+		AllowedIDs:     []string{"spiffe://example.org/workload"},
+	})
+	if err != nil {
+		t.Fatalf("NewSPIFFEAuthenticator: %v", err)
+	}
+
+	_, err = NewSPIFFEInterceptor(sa)(noopNext)(context.Background(), connect.NewRequest(&struct{}{}))
+	if connect.CodeOf(err) != connect.CodeUnauthenticated {
+		t.Errorf("expected CodeUnauthenticated, got %v", connect.CodeOf(err))
+	}
+}
+
+// signedJWTSVID returns an ES256 JWT-SVID for sub and aud and a bundle for
+// example.org trusting its signing key.
+func signedJWTSVID(t *testing.T, sub, aud string) (string, *jwtbundle.Bundle) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	tok, err := jwt.NewBuilder().Subject(sub).Audience([]string{aud}).Expiration(time.Now().Add(5 * time.Minute)).Build()
+	if err != nil {
+		t.Fatalf("build token: %v", err)
+	}
+	hdrs := jws.NewHeaders()
+	_ = hdrs.Set(jws.KeyIDKey, "authority-1")
+	signed, err := jwt.Sign(tok, jwt.WithKey(jwa.ES256, key, jws.WithProtectedHeaders(hdrs)))
+	if err != nil {
+		t.Fatalf("sign token: %v", err)
+	}
+	bundle := jwtbundle.FromJWTAuthorities(spiffeid.RequireTrustDomainFromString("example.org"),
+		map[string]crypto.PublicKey{"authority-1": &key.PublicKey})
+	return string(signed), bundle
+}
+
+func TestSPIFFEJWTInterceptor(t *testing.T) {
+	const (
+		workloadID = "spiffe://example.org/workload"
+		audience   = "spiffe://example.org/billing"
+	)
+	token, bundle := signedJWTSVID(t, workloadID, audience)
+	sa, err := authn.NewSPIFFEAuthenticator(authn.SPIFFEConfig{
+		TrustDomain:    "example.org",
+		WorkloadSocket: "unix:///tmp/agent.sock",
+		AllowedIDs:     []string{workloadID},
+	}, authn.WithJWTBundleSource(bundle))
+	if err != nil {
+		t.Fatalf("NewSPIFFEAuthenticator: %v", err)
+	}
+	interceptor := NewSPIFFEJWTInterceptor(sa, audience)
+
+	req := connect.NewRequest(&struct{}{})
+	req.Header().Set("Authorization", "Bearer "+token)
+	var sub string
+	_, err = interceptor(func(ctx context.Context, _ connect.AnyRequest) (connect.AnyResponse, error) {
+		sub = authz.ClaimsFromContext(ctx).Sub
+		return nil, nil
+	})(context.Background(), req)
+	if err != nil {
+		t.Fatalf("expected JWT-SVID to be accepted: %v", err)
+	}
+	if sub != workloadID {
+		t.Errorf("expected subject %q, got %q", workloadID, sub)
+	}
+
+	_, err = interceptor(noopNext)(context.Background(), connect.NewRequest(&struct{}{}))
+	if connect.CodeOf(err) != connect.CodeUnauthenticated {
+		t.Errorf("missing header: expected CodeUnauthenticated, got %v", connect.CodeOf(err))
+	}
+
+	other := connect.NewRequest(&struct{}{})
+	other.Header().Set("Authorization", "Bearer "+token)
+	_, err = NewSPIFFEJWTInterceptor(sa, "spiffe://example.org/other")(noopNext)(context.Background(), other)
+	if connect.CodeOf(err) != connect.CodeUnauthenticated {
+		t.Errorf("wrong audience: expected CodeUnauthenticated, got %v", connect.CodeOf(err))
+	}
+}
@@ -3,31 +3,53 @@ package middleware
 import (
 	"context"
 	"fmt"
+	"strings"
 
 	"connectrpc.com/connect"
 
+	"github.com/penguintechinc/penguin-libs/packages/go-aaa/audit"
+	"github.com/penguintechinc/penguin-libs/packages/go-aaa/authn"
 	"github.com/penguintechinc/penguin-libs/packages/go-aaa/authz"
 )
 
 // ProcedureScopes maps fully-qualified ConnectRPC procedure paths to the list of
-// OAuth 2.0 scopes required to invoke them. Procedures absent from the map are
-// allowed without scope enforcement.
+// OAuth 2.0 scopes required to invoke them. Keys may be path.Match globs such as
+// "/svc.Admin/*"; an exact key takes precedence over globs, and among matching globs
+// the most specific one wins. Procedures matching no key are allowed without scope
+// enforcement.
 type ProcedureScopes map[string][]string
 
+// roleLookup resolves a role name to its scopes for the request in ctx.
+type roleLookup func(ctx context.Context, role string) ([]string, bool)
+
 // NewAuthzInterceptor returns a ConnectRPC interceptor that checks whether the Claims
 // stored in the request context contain all scopes required for the procedure being
 // invoked. It must run after an authentication interceptor.
 func NewAuthzInterceptor(enforcer *authz.RBACEnforcer, procedures ProcedureScopes, opts ...InterceptorOption) connect.UnaryInterceptorFunc {
-	cfg := applyOptions(opts)
+	lookup := func(_ context.Context, role string) ([]string, bool) {
+		return enforcer.ScopesForRole(role)
+	}
+	return newAuthzInterceptor(lookup, procedures, applyOptions(opts))
+}
+
+// NewTenantAuthzInterceptor is like NewAuthzInterceptor but resolves role scopes
+// from the registry of the tenant carried by the request's Claims, falling back to
+// the enforcer's default registry.
+func NewTenantAuthzInterceptor(enforcer *authz.TenantRBACEnforcer, procedures ProcedureScopes, opts ...InterceptorOption) connect.UnaryInterceptorFunc {
+	return newAuthzInterceptor(enforcer.ScopesForRoleInContext, procedures, applyOptions(opts))
+}
+
+// newAuthzInterceptor builds the authorization interceptor around the given role lookup.
+func newAuthzInterceptor(lookup roleLookup, procedures ProcedureScopes, cfg interceptorConfig) connect.UnaryInterceptorFunc {
 	return func(next connect.UnaryFunc) connect.UnaryFunc {
 		return func(ctx context.Context, req connect.AnyRequest) (connect.AnyResponse, error) {
 			procedure := req.Spec().Procedure
 
-			if cfg.publicProcedures[procedure] {
+			if cfg.isPublic(procedure) {
 				return next(ctx, req)
 			}
 
-			required, ok := procedures[procedure]
+			required, ok := lookupProcedure(procedures, procedure)
 			if !ok || len(required) == 0 {
 				// No scope requirements defined for this procedure.
 				return next(ctx, req)
@@ -35,15 +57,21 @@ func NewAuthzInterceptor(enforcer *authz.RBACEnforcer, procedures ProcedureScope
 
 			claims := authz.ClaimsFromContext(ctx)
 			if claims == nil {
+				cfg.emitAuthzDecision(ctx, req, nil, required)
 				return nil, connect.NewError(connect.CodePermissionDenied, fmt.Errorf("no claims in context; authentication required"))
 			}
 
 			// Collect all scopes granted directly on the claims plus any from
-			// roles resolved through the enforcer.
-			grantedScopes := resolveScopes(enforcer, claims.Scope, claims.Roles)
+			// roles resolved through the role registry.
+			grantedScopes := mergeScopes(func(role string) ([]string, bool) {
+				return lookup(ctx, role)
+			}, claims.Scope, claims.Roles)
 
-			if !authz.HasAllScopes(grantedScopes, required...) {
-				return nil, connect.NewError(connect.CodePermissionDenied, fmt.Errorf("insufficient scopes for procedure %q", procedure))
+			decision := authz.Explain(grantedScopes, required...)
+			cfg.emitAuthzDecision(ctx, req, claims, decision.MissingScopes)
+			if !decision.Allowed {
+				return nil, connect.NewError(connect.CodePermissionDenied, fmt.Errorf("insufficient scopes for procedure %q: missing %s",
+					procedure, strings.Join(decision.MissingScopes, ", ")))
 			}
 
 			return next(ctx, req)
@@ -51,9 +79,40 @@ func NewAuthzInterceptor(enforcer *authz.RBACEnforcer, procedures ProcedureScope
 	}
 }
 
-// resolveScopes merges direct scopes with scopes derived from role membership
-// using the enforcer's registry.
-func resolveScopes(enforcer *authz.RBACEnforcer, directScopes, roles []string) []string {
+// emitAuthzDecision emits the audit event for an authorization decision when
+// WithAuditEmitter is set: EventAuthzGranted if missing is empty, otherwise
+// EventAuthzDenied listing the missing scopes. claims is nil when the request
+// carried none.
+func (cfg interceptorConfig) emitAuthzDecision(ctx context.Context, req connect.AnyRequest, claims *authn.Claims, missing []string) {
+	if cfg.auditEmitter == nil {
+		return
+	}
+	eventType, outcome := audit.EventAuthzGranted, audit.OutcomeSuccess
+	if len(missing) > 0 {
+		eventType, outcome = audit.EventAuthzDenied, audit.OutcomeFailure
+	}
+	if cfg.skipAuditTypes[eventType] {
+		return
+	}
+
+	opts := requestMetadata(req)
+	subject := "anonymous"
+	if claims != nil {
+		if claims.Sub != "" {
+			subject = claims.Sub
+		}
+		opts = append(opts, audit.WithTenant(claims.Tenant))
+	}
+	if len(missing) > 0 {
+		opts = append(opts, audit.WithDetails(map[string]interface{}{"missing_scopes": missing}))
+	}
+	event := audit.NewAuditEvent(eventType, subject, "rpc", req.Spec().Procedure, outcome, opts...)
+	_ = cfg.auditEmitter.EmitContext(ctx, event)
+}
+
+// mergeScopes merges direct scopes with the scopes lookup returns for each role,
+// dropping duplicates while preserving first-seen order.
+func mergeScopes(lookup func(role string) ([]string, bool), directScopes, roles []string) []string {
 	seen := make(map[string]bool, len(directScopes))
 	merged := make([]string, 0, len(directScopes))
 
@@ -65,7 +124,7 @@ func resolveScopes(enforcer *authz.RBACEnforcer, directScopes, roles []string) [
 	}
 
 	for _, role := range roles {
-		roleScopes, ok := enforcer.ScopesForRole(role)
+		roleScopes, ok := lookup(role)
 		if !ok {
 			continue
 		}
@@ -2,11 +2,15 @@ package middleware
 
 import (
 	"context"
+	"errors"
+	"slices"
+	"strings"
 	"testing"
 	"time"
 
 	"connectrpc.com/connect"
 
+	"github.com/penguintechinc/penguin-libs/packages/go-aaa/audit"
 	"github.com/penguintechinc/penguin-libs/packages/go-aaa/authn"
 	"github.com/penguintechinc/penguin-libs/packages/go-aaa/authz"
 )
@@ -57,6 +61,27 @@ func TestAuthzInterceptor_InsufficientScopes(t *testing.T) {
 	}
 }
 
+func TestAuthzInterceptor_DeniedErrorListsMissingScopes(t *testing.T) {
+	enforcer := authz.NewRBACEnforcer()
+	procedures := ProcedureScopes{"": {"report:read", "report:write", "report:delete"}}
+	interceptor := NewAuthzInterceptor(enforcer, procedures)
+
+	ctx := ctxWithClaims("u", []string{"report:read"}, nil, "")
+	req := connect.NewRequest(&struct{}{})
+
+	_, err := interceptor(noopNext)(ctx, req)
+	if err == nil {
+		t.Fatal("expected error for insufficient scopes, got nil")
+	}
+	msg := err.Error()
+	if !strings.Contains(msg, "report:write, report:delete") {
+		t.Errorf("expected error to list missing scopes, got %q", msg)
+	}
+	if strings.Contains(msg, "report:read") {
+		t.Errorf("expected granted scope to be omitted from error, got %q", msg)
+	}
+}
+
 func TestAuthzInterceptor_ScopesFromRole(t *testing.T) {
 	enforcer := authz.NewRBACEnforcer(authz.Role{Name: "editor", Scopes: []string{"doc:write", "doc:read"}})
 	procedures := ProcedureScopes{"": {"doc:write"}}
@@ -114,18 +139,178 @@ func TestAuthzInterceptor_PublicProcedure_Bypasses(t *testing.T) {
 	}
 }
 
-func TestResolveScopes_DeduplicatesScopes(t *testing.T) {
-	enforcer := authz.NewRBACEnforcer(authz.Role{Name: "viewer", Scopes: []string{"report:read"}})
+func TestAuthzInterceptor_AuditEmitter_DenialEmitsDenied(t *testing.T) {
+	var received []audit.AuditEvent
+	enforcer := authz.NewRBACEnforcer()
+	procedures := ProcedureScopes{"/svc.Report/Delete": {"report:read", "report:delete"}}
+	interceptor := NewAuthzInterceptor(enforcer, procedures, WithAuditEmitter(buildAuditEmitter(&received)))
+
+	ctx := ctxWithClaims("user-42", []string{"report:read"}, nil, "")
+	_, err := interceptor(noopNext)(ctx, newProcedureRequest("/svc.Report/Delete"))
+	if connect.CodeOf(err) != connect.CodePermissionDenied {
+		t.Fatalf("expected CodePermissionDenied, got %v", err)
+	}
+
+	if len(received) != 1 {
+		t.Fatalf("expected exactly 1 audit event, got %d", len(received))
+	}
+	ev := received[0]
+	if ev.Type != audit.EventAuthzDenied {
+		t.Errorf("expected EventAuthzDenied, got %q", ev.Type)
+	}
+	if ev.Outcome != audit.OutcomeFailure {
+		t.Errorf("expected OutcomeFailure, got %q", ev.Outcome)
+	}
+	if ev.Subject != "user-42" {
+		t.Errorf("expected subject user-42, got %q", ev.Subject)
+	}
+	if ev.Resource != "/svc.Report/Delete" {
+		t.Errorf("expected resource /svc.Report/Delete, got %q", ev.Resource)
+	}
+	missing, _ := ev.Details["missing_scopes"].([]string)
+	if len(missing) != 1 || missing[0] != "report:delete" {
+		t.Errorf("expected missing_scopes [report:delete], got %v", ev.Details["missing_scopes"])
+	}
+}
+
+func TestAuthzInterceptor_AuditEmitter_NoClaimsEmitsDenied(t *testing.T) {
+	var received []audit.AuditEvent
+	enforcer := authz.NewRBACEnforcer()
+	procedures := ProcedureScopes{"": {"report:read"}}
+	interceptor := NewAuthzInterceptor(enforcer, procedures, WithAuditEmitter(buildAuditEmitter(&received)))
+
+	_, _ = interceptor(noopNext)(context.Background(), connect.NewRequest(&struct{}{}))
+
+	if len(received) != 1 {
+		t.Fatalf("expected exactly 1 audit event, got %d", len(received))
+	}
+	if received[0].Type != audit.EventAuthzDenied || received[0].Subject != "anonymous" {
+		t.Errorf("expected anonymous EventAuthzDenied, got %q for %q", received[0].Type, received[0].Subject)
+	}
+}
+
+func TestAuthzInterceptor_AuditEmitter_GrantEmitsGranted(t *testing.T) {
+	var received []audit.AuditEvent
+	enforcer := authz.NewRBACEnforcer()
+	procedures := ProcedureScopes{"/svc.Report/Get": {"report:read"}}
+	interceptor := NewAuthzInterceptor(enforcer, procedures, WithAuditEmitter(buildAuditEmitter(&received)))
+
+	ctx := ctxWithClaims("user-42", []string{"report:read"}, nil, "")
+	if _, err := interceptor(noopNext)(ctx, newProcedureRequest("/svc.Report/Get")); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if len(received) != 1 {
+		t.Fatalf("expected exactly 1 audit event, got %d", len(received))
+	}
+	if received[0].Type != audit.EventAuthzGranted {
+		t.Errorf("expected EventAuthzGranted, got %q", received[0].Type)
+	}
+	if _, ok := received[0].Details["missing_scopes"]; ok {
+		t.Error("expected no missing_scopes on a granted event")
+	}
+}
+
+func TestAuthzInterceptor_AuditEmitter_SkipTypes(t *testing.T) {
+	var received []audit.AuditEvent
+	enforcer := authz.NewRBACEnforcer()
+	procedures := ProcedureScopes{"": {"report:read"}}
+	interceptor := NewAuthzInterceptor(enforcer, procedures,
+		WithAuditEmitter(buildAuditEmitter(&received)), WithSkipAuditTypes(audit.EventAuthzGranted))
+
+	ctx := ctxWithClaims("u", []string{"report:read"}, nil, "")
+	_, _ = interceptor(noopNext)(ctx, connect.NewRequest(&struct{}{}))
+
+	if len(received) != 0 {
+		t.Errorf("expected skipped granted event, got %d events", len(received))
+	}
+}
+
+func TestTenantAuthzInterceptor_RoleResolvesPerTenant(t *testing.T) {
+	enforcer := authz.NewTenantRBACEnforcer(nil)
+	enforcer.RegisterTenantRole("tenant-a", authz.Role{Name: "editor", Scopes: []string{"doc:write"}})
+	enforcer.RegisterTenantRole("tenant-b", authz.Role{Name: "editor", Scopes: []string{"doc:read"}})
+	procedures := ProcedureScopes{"": {"doc:write"}}
+	interceptor := NewTenantAuthzInterceptor(enforcer, procedures)
+	req := connect.NewRequest(&struct{}{})
+
+	ctxA := ctxWithClaims("u", nil, []string{"editor"}, "tenant-a")
+	if _, err := interceptor(noopNext)(ctxA, req); err != nil {
+		t.Errorf("expected tenant-a editor to be allowed, got %v", err)
+	}
+
+	ctxB := ctxWithClaims("u", nil, []string{"editor"}, "tenant-b")
+	_, err := interceptor(noopNext)(ctxB, req)
+	if connect.CodeOf(err) != connect.CodePermissionDenied {
+		t.Errorf("expected tenant-b editor to be denied, got %v", err)
+	}
+}
+
+func TestTenantAuthzInterceptor_FallsBackToDefaultRoles(t *testing.T) {
+	enforcer := authz.NewTenantRBACEnforcer(authz.NewRBACEnforcer(authz.Role{Name: "editor", Scopes: []string{"doc:write"}}))
+	procedures := ProcedureScopes{"": {"doc:write"}}
+	interceptor := NewTenantAuthzInterceptor(enforcer, procedures)
 
-	scopes := resolveScopes(enforcer, []string{"report:read"}, []string{"viewer"})
-	count := 0
-	for _, s := range scopes {
-		if s == "report:read" {
-			count++
+	ctx := ctxWithClaims("u", nil, []string{"editor"}, "tenant-c")
+	if _, err := interceptor(noopNext)(ctx, connect.NewRequest(&struct{}{})); err != nil {
+		t.Errorf("expected default editor role to apply, got %v", err)
+	}
+}
+
+func TestAuthzInterceptor_GlobWithExactOverride(t *testing.T) {
+	enforcer := authz.NewRBACEnforcer()
+	procedures := ProcedureScopes{
+		"/svc.Admin/*":   {"admin:write"},
+		"/svc.Admin/Get": {"admin:read"},
+	}
+	interceptor := NewAuthzInterceptor(enforcer, procedures)
+	ctx := ctxWithClaims("u", []string{"admin:read"}, nil, "")
+
+	for _, proc := range []string{"/svc.Admin/Create", "/svc.Admin/Delete"} {
+		_, err := interceptor(noopNext)(ctx, newProcedureRequest(proc))
+		if connect.CodeOf(err) != connect.CodePermissionDenied {
+			t.Errorf("%s: expected glob requirement to deny, got %v", proc, err)
 		}
 	}
-	if count != 1 {
-		t.Errorf("expected report:read to appear exactly once, appeared %d times", count)
+
+	if _, err := interceptor(noopNext)(ctx, newProcedureRequest("/svc.Admin/Get")); err != nil {
+		t.Errorf("expected exact override to allow admin:read, got %v", err)
+	}
+}
+
+func TestAuthzInterceptor_GlobPublicProcedures(t *testing.T) {
+	enforcer := authz.NewRBACEnforcer()
+	procedures := ProcedureScopes{"/svc.Health/*": {"admin:all"}}
+	interceptor := NewAuthzInterceptor(enforcer, procedures, WithPublicProcedures("/svc.Health/*"))
+
+	_, err := interceptor(noopNext)(context.Background(), newProcedureRequest("/svc.Health/Check"))
+	if err != nil {
+		t.Fatalf("expected glob public procedure to bypass, got %v", err)
+	}
+}
+
+// newProcedureRequest builds a request whose Spec reports the given procedure.
+func newProcedureRequest(procedure string) connect.AnyRequest {
+	return &procedureRequest{Request: connect.NewRequest(&struct{}{}), procedure: procedure}
+}
+
+// procedureRequest overrides Spec so tests can target a specific procedure path.
+type procedureRequest struct {
+	*connect.Request[struct{}]
+	procedure string
+}
+
+func (r *procedureRequest) Spec() connect.Spec {
+	return connect.Spec{Procedure: r.procedure}
+}
+
+func TestMergeScopes_DeduplicatesScopes(t *testing.T) {
+	enforcer := authz.NewRBACEnforcer(authz.Role{Name: "viewer", Scopes: []string{"report:read", "report:list"}})
+
+	scopes := mergeScopes(enforcer.ScopesForRole, []string{"report:read", "report:read"}, []string{"viewer", "viewer"})
+	want := []string{"report:read", "report:list"}
+	if !slices.Equal(scopes, want) {
+		t.Errorf("scopes = %v, want %v", scopes, want)
 	}
 }
 
@@ -163,3 +348,52 @@ func TestTenantInterceptor_PublicProcedure_Bypasses(t *testing.T) {
 		t.Fatalf("expected no error for public procedure, got %v", err)
 	}
 }
+
+// headerTenant extracts the target tenant from the X-Tenant-ID request header.
+func headerTenant(_ context.Context, req connect.AnyRequest) (string, error) {
+	return req.Header().Get("X-Tenant-ID"), nil
+}
+
+func TestTenantInterceptor_TargetTenant(t *testing.T) {
+	tests := []struct {
+		name   string
+		target string
+		want   connect.Code
+	}{
+		{"matching tenant", "tenant-xyz", 0},
+		{"no target tenant", "", 0},
+		{"foreign tenant", "tenant-abc", connect.CodePermissionDenied},
+	}
+
+	interceptor := NewTenantInterceptor(WithTargetTenant(headerTenant))
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := connect.NewRequest(&struct{}{})
+			if tt.target != "" {
+				req.Header().Set("X-Tenant-ID", tt.target)
+			}
+
+			_, err := interceptor(noopNext)(ctxWithClaims("u", nil, nil, "tenant-xyz"), req)
+			if tt.want == 0 {
+				if err != nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+				return
+			}
+			if connect.CodeOf(err) != tt.want {
+				t.Errorf("expected %v, got %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestTenantInterceptor_TargetTenantExtractorError(t *testing.T) {
+	interceptor := NewTenantInterceptor(WithTargetTenant(func(context.Context, connect.AnyRequest) (string, error) {
+		return "", errors.New("unsupported message type")
+	}))
+
+	_, err := interceptor(noopNext)(ctxWithClaims("u", nil, nil, "tenant-xyz"), connect.NewRequest(&struct{}{}))
+	if connect.CodeOf(err) != connect.CodeInvalidArgument {
+		t.Errorf("expected CodeInvalidArgument, got %v", err)
+	}
+}
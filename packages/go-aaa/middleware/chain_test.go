@@ -0,0 +1,152 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"connectrpc.com/connect"
+
+	"github.com/penguintechinc/penguin-libs/packages/go-aaa/audit"
+	"github.com/penguintechinc/penguin-libs/packages/go-aaa/authn"
+	"github.com/penguintechinc/penguin-libs/packages/go-aaa/authz"
+)
+
+// recordingInterceptor appends name to *order each time it runs.
+func recordingInterceptor(name string, order *[]string) connect.UnaryInterceptorFunc {
+	return func(next connect.UnaryFunc) connect.UnaryFunc {
+		return func(ctx context.Context, req connect.AnyRequest) (connect.AnyResponse, error) {
+			*order = append(*order, name)
+			return next(ctx, req)
+		}
+	}
+}
+
+func TestChain_OrdersStagesRegardlessOfRegistration(t *testing.T) {
+	var order []string
+	interceptor := NewChain().
+		Use(StageAuthz, recordingInterceptor("authz", &order)).
+		Use(StageTenant, recordingInterceptor("tenant", &order)).
+		Use(StageAuthn, recordingInterceptor("authn", &order)).
+		Use(StageLogging, recordingInterceptor("logging", &order)).
+		Use(StageAudit, recordingInterceptor("audit", &order)).
+		Use(StageMetrics, recordingInterceptor("metrics", &order)).
+		Use(StageSession, recordingInterceptor("session", &order)).
+		Build()
+
+	if _, err := interceptor.WrapUnary(noopNext)(context.Background(), connect.NewRequest(&struct{}{})); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := []string{"logging", "metrics", "audit", "authn", "session", "tenant", "authz"}
+	if !slices.Equal(order, want) {
+		t.Errorf("order = %v, want %v", order, want)
+	}
+}
+
+func TestChain_AuditAttributesEventsToAuthenticatedSubject(t *testing.T) {
+	var received []audit.AuditEvent
+	rp := buildFakeRPInterceptor(func(token string) (*authn.Claims, error) {
+		if token != "good-token" {
+			return nil, errors.New("bad token")
+		}
+		claims := validClaims("user-abc")
+		claims.Tenant = "acme"
+		return claims, nil
+	})
+	interceptor := NewChain().
+		Use(StageAuthn, rp).
+		Use(StageAudit, NewAuditInterceptor(buildAuditEmitter(&received))).
+		Build()
+
+	for _, tc := range []struct {
+		token, wantSubject, wantTenant string
+		wantType                       audit.EventType
+	}{
+		{"good-token", "user-abc", "acme", audit.EventAuthzGranted},
+		{"bad-token", "anonymous", "", audit.EventAuthFailure},
+	} {
+		received = nil
+		req := connect.NewRequest(&struct{}{})
+		req.Header().Set("Authorization", "Bearer "+tc.token)
+		_, _ = interceptor.WrapUnary(noopNext)(context.Background(), req)
+
+		if len(received) != 1 {
+			t.Fatalf("token %s: expected 1 audit event, got %d", tc.token, len(received))
+		}
+		got := received[0]
+		if got.Type != tc.wantType || got.Subject != tc.wantSubject || got.Tenant != tc.wantTenant {
+			t.Errorf("token %s: got type=%s subject=%q tenant=%q, want type=%s subject=%q tenant=%q",
+				tc.token, got.Type, got.Subject, got.Tenant, tc.wantType, tc.wantSubject, tc.wantTenant)
+		}
+	}
+}
+
+func TestChain_AuthnPopulatesClaimsBeforeAuthz(t *testing.T) {
+	rp := buildFakeRPInterceptor(func(token string) (*authn.Claims, error) {
+		if token != "good-token" {
+			return nil, errors.New("bad token")
+		}
+		claims := validClaims("user-abc")
+		claims.Scope = []string{"report:read"}
+		return claims, nil
+	})
+	az := NewAuthzInterceptor(authz.NewRBACEnforcer(), ProcedureScopes{"": {"report:read"}})
+
+	interceptor := NewChain().Use(StageAuthz, az).Use(StageAuthn, rp).Build()
+
+	req := connect.NewRequest(&struct{}{})
+	req.Header().Set("Authorization", "Bearer good-token")
+	if _, err := interceptor.WrapUnary(noopNext)(context.Background(), req); err != nil {
+		t.Fatalf("expected authz to see authn claims, got %v", err)
+	}
+}
+
+func TestChain_UnauthenticatedRejectedBeforeAuthz(t *testing.T) {
+	var order []string
+	rp := buildFakeRPInterceptor(func(_ string) (*authn.Claims, error) { return nil, nil })
+
+	interceptor := NewChain().
+		Use(StageAuthz, recordingInterceptor("authz", &order)).
+		Use(StageAuthn, rp).
+		Build()
+
+	_, err := interceptor.WrapUnary(noopNext)(context.Background(), connect.NewRequest(&struct{}{}))
+	if connect.CodeOf(err) != connect.CodeUnauthenticated {
+		t.Fatalf("expected CodeUnauthenticated, got %v", err)
+	}
+	if len(order) != 0 {
+		t.Errorf("authz must not run for unauthenticated requests, ran: %v", order)
+	}
+}
+
+func TestChain_WithoutRemovesStages(t *testing.T) {
+	var order []string
+	c := NewChain().
+		Use(StageAuthn, recordingInterceptor("authn", &order)).
+		Use(StageAudit, recordingInterceptor("audit", &order)).
+		Use(StageAuthz, recordingInterceptor("authz", &order)).
+		Without(StageAudit)
+
+	if got, want := c.Stages(), []Stage{StageAuthn, StageAuthz}; !slices.Equal(got, want) {
+		t.Fatalf("Stages() = %v, want %v", got, want)
+	}
+	if _, err := c.Build().WrapUnary(noopNext)(context.Background(), connect.NewRequest(&struct{}{})); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := []string{"authn", "authz"}; !slices.Equal(order, want) {
+		t.Errorf("order = %v, want %v", order, want)
+	}
+}
+
+func TestChain_EmptyPassesThrough(t *testing.T) {
+	called := false
+	_, err := NewChain().Build().WrapUnary(func(context.Context, connect.AnyRequest) (connect.AnyResponse, error) {
+		called = true
+		return nil, nil
+	})(context.Background(), connect.NewRequest(&struct{}{}))
+	if err != nil || !called {
+		t.Errorf("expected handler to be called directly, err=%v called=%v", err, called)
+	}
+}
@@ -0,0 +1,117 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/netip"
+	"strings"
+
+	"connectrpc.com/connect"
+)
+
+// NewIPAllowlistInterceptor returns a ConnectRPC interceptor that denies requests
+// whose client IP is outside cidrs with CodePermissionDenied. Entries may be
+// CIDRs ("10.0.0.0/8") or single addresses ("192.0.2.10").
+//
+// The client IP is the peer address of the connection. X-Forwarded-For is only
+// consulted when the peer is a proxy listed via WithTrustedProxies; the header is
+// then read right to left, skipping trusted proxies, and the first untrusted hop
+// is taken as the client.
+func NewIPAllowlistInterceptor(cidrs []string, opts ...InterceptorOption) (connect.UnaryInterceptorFunc, error) {
+	cfg := applyOptions(opts)
+	allowed, err := parsePrefixes(cidrs)
+	if err != nil {
+		return nil, fmt.Errorf("ip allowlist: %w", err)
+	}
+	trusted, err := parsePrefixes(cfg.trustedProxies)
+	if err != nil {
+		return nil, fmt.Errorf("ip allowlist: trusted proxies: %w", err)
+	}
+
+	return func(next connect.UnaryFunc) connect.UnaryFunc {
+		return func(ctx context.Context, req connect.AnyRequest) (connect.AnyResponse, error) {
+			if cfg.isPublic(req.Spec().Procedure) {
+				return next(ctx, req)
+			}
+
+			ip, ok := clientIP(req, trusted)
+			if !ok {
+				return nil, connect.NewError(connect.CodePermissionDenied, fmt.Errorf("client address %q is not a valid IP", req.Peer().Addr))
+			}
+			if !containsAddr(allowed, ip) {
+				return nil, connect.NewError(connect.CodePermissionDenied, fmt.Errorf("client address %s is not allowed", ip))
+			}
+			return next(ctx, req)
+		}
+	}, nil
+}
+
+// clientIP resolves the client address of req, following X-Forwarded-For only
+// through trusted proxies.
+func clientIP(req connect.AnyRequest, trusted []netip.Prefix) (netip.Addr, bool) {
+	ip, ok := parseIP(req.Peer().Addr)
+	if !ok || !containsAddr(trusted, ip) {
+		return ip, ok
+	}
+
+	var hops []string
+	for _, h := range req.Header().Values("X-Forwarded-For") {
+		hops = append(hops, strings.Split(h, ",")...)
+	}
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop, ok := parseIP(strings.TrimSpace(hops[i]))
+		if !ok {
+			// A malformed hop cannot be attributed; stop at the last trusted one.
+			return ip, true
+		}
+		ip = hop
+		if !containsAddr(trusted, hop) {
+			return ip, true
+		}
+	}
+	return ip, true
+}
+
+// parseIP parses addr as an IP, with or without a port.
+func parseIP(addr string) (netip.Addr, bool) {
+	if host, _, err := net.SplitHostPort(addr); err == nil {
+		addr = host
+	}
+	ip, err := netip.ParseAddr(addr)
+	if err != nil {
+		return netip.Addr{}, false
+	}
+	return ip.Unmap(), true
+}
+
+// parsePrefixes parses CIDRs and bare addresses into prefixes.
+func parsePrefixes(entries []string) ([]netip.Prefix, error) {
+	prefixes := make([]netip.Prefix, 0, len(entries))
+	for _, e := range entries {
+		if strings.Contains(e, "/") {
+			p, err := netip.ParsePrefix(e)
+			if err != nil {
+				return nil, fmt.Errorf("invalid CIDR %q: %w", e, err)
+			}
+			prefixes = append(prefixes, p.Masked())
+			continue
+		}
+		ip, err := netip.ParseAddr(e)
+		if err != nil {
+			return nil, fmt.Errorf("invalid address %q: %w", e, err)
+		}
+		ip = ip.Unmap()
+		prefixes = append(prefixes, netip.PrefixFrom(ip, ip.BitLen()))
+	}
+	return prefixes, nil
+}
+
+func containsAddr(prefixes []netip.Prefix, ip netip.Addr) bool {
+	for _, p := range prefixes {
+		if p.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
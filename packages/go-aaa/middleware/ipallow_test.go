@@ -0,0 +1,118 @@
+package middleware
+
+import (
+	"context"
+	"testing"
+
+	"connectrpc.com/connect"
+)
+
+// peerRequest overrides the peer address of a request, which connect.NewRequest
+// leaves empty.
+type peerRequest struct {
+	*connect.Request[struct{}]
+	addr string
+}
+
+func (r peerRequest) Peer() connect.Peer { return connect.Peer{Addr: r.addr} }
+
+func newPeerRequest(addr string, xff ...string) peerRequest {
+	req := connect.NewRequest(&struct{}{})
+	for _, h := range xff {
+		req.Header().Add("X-Forwarded-For", h)
+	}
+	return peerRequest{Request: req, addr: addr}
+}
+
+func mustIPAllowlist(t *testing.T, cidrs []string, opts ...InterceptorOption) connect.UnaryInterceptorFunc {
+	t.Helper()
+	interceptor, err := NewIPAllowlistInterceptor(cidrs, opts...)
+	if err != nil {
+		t.Fatalf("NewIPAllowlistInterceptor: %v", err)
+	}
+	return interceptor
+}
+
+func TestIPAllowlistInterceptor_PeerAddress(t *testing.T) {
+	interceptor := mustIPAllowlist(t, []string{"10.0.0.0/8", "192.0.2.10", "fd00::/8"})
+
+	tests := []struct {
+		name  string
+		addr  string
+		allow bool
+	}{
+		{"inside CIDR", "10.1.2.3:5000", true},
+		{"single address", "192.0.2.10:443", true},
+		{"ipv6 inside CIDR", "[fd00::1]:443", true},
+		{"ipv4-mapped ipv6", "[::ffff:10.0.0.1]:443", true},
+		{"outside", "203.0.113.5:5000", false},
+		{"neighbour of single address", "192.0.2.11:443", false},
+		{"unparseable", "not-an-ip", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := interceptor(noopNext)(context.Background(), newPeerRequest(tt.addr))
+			if tt.allow && err != nil {
+				t.Fatalf("expected allowed, got %v", err)
+			}
+			if !tt.allow && connect.CodeOf(err) != connect.CodePermissionDenied {
+				t.Fatalf("expected CodePermissionDenied, got %v", err)
+			}
+		})
+	}
+}
+
+func TestIPAllowlistInterceptor_SpoofedXFFWithoutTrustedProxy(t *testing.T) {
+	interceptor := mustIPAllowlist(t, []string{"10.0.0.0/8"})
+
+	_, err := interceptor(noopNext)(context.Background(), newPeerRequest("203.0.113.5:5000", "10.0.0.1"))
+	if connect.CodeOf(err) != connect.CodePermissionDenied {
+		t.Fatalf("expected X-Forwarded-For to be ignored without trusted proxies, got %v", err)
+	}
+}
+
+func TestIPAllowlistInterceptor_TrustedProxy(t *testing.T) {
+	interceptor := mustIPAllowlist(t, []string{"10.0.0.0/8"}, WithTrustedProxies("172.16.0.0/12"))
+
+	tests := []struct {
+		name  string
+		peer  string
+		xff   []string
+		allow bool
+	}{
+		{"client behind proxy", "172.16.0.2:80", []string{"10.1.1.1"}, true},
+		{"outside client behind proxy", "172.16.0.2:80", []string{"203.0.113.5"}, false},
+		{"spoofed leftmost hop", "172.16.0.2:80", []string{"10.1.1.1, 203.0.113.5"}, false},
+		{"chained trusted proxies", "172.16.0.2:80", []string{"10.1.1.1", "172.16.0.9"}, true},
+		{"untrusted peer ignores header", "203.0.113.9:80", []string{"10.1.1.1"}, false},
+		{"proxy without header", "172.16.0.2:80", nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := interceptor(noopNext)(context.Background(), newPeerRequest(tt.peer, tt.xff...))
+			if tt.allow && err != nil {
+				t.Fatalf("expected allowed, got %v", err)
+			}
+			if !tt.allow && connect.CodeOf(err) != connect.CodePermissionDenied {
+				t.Fatalf("expected CodePermissionDenied, got %v", err)
+			}
+		})
+	}
+}
+
+func TestIPAllowlistInterceptor_PublicProcedureBypasses(t *testing.T) {
+	interceptor := mustIPAllowlist(t, []string{"10.0.0.0/8"}, WithPublicProcedures(""))
+
+	if _, err := interceptor(noopNext)(context.Background(), newPeerRequest("203.0.113.5:5000")); err != nil {
+		t.Fatalf("expected public procedure to bypass the allowlist, got %v", err)
+	}
+}
+
+func TestNewIPAllowlistInterceptor_InvalidEntries(t *testing.T) {
+	if _, err := NewIPAllowlistInterceptor([]string{"10.0.0.0/33"}); err == nil {
+		t.Error("expected error for invalid CIDR")
+	}
+	if _, err := NewIPAllowlistInterceptor([]string{"10.0.0.0/8"}, WithTrustedProxies("proxy.internal")); err == nil {
+		t.Error("expected error for invalid trusted proxy")
+	}
+}
@@ -10,13 +10,20 @@ import (
 type interceptorConfig struct {
 	publicProcedures map[string]bool
 	skipAuditTypes   map[audit.EventType]bool
+	requiredAudience string
+	replayCache      ReplayCache
+	targetTenant     TenantExtractor
+	trustedProxies   []string
+	auditEmitter     *audit.Emitter
+	sessionCreate    map[string]bool
 }
 
 // InterceptorOption is a functional option that modifies interceptor behavior.
 type InterceptorOption func(*interceptorConfig)
 
 // WithPublicProcedures marks the listed procedure paths as exempt from authentication
-// and authorization checks.
+// and authorization checks. Entries may be globs, following the same matching rules
+// as ProcedureScopes keys.
 func WithPublicProcedures(procedures ...string) InterceptorOption {
 	return func(cfg *interceptorConfig) {
 		if cfg.publicProcedures == nil {
@@ -41,6 +48,79 @@ func WithSkipAuditTypes(types ...audit.EventType) InterceptorOption {
 	}
 }
 
+// WithRequiredAudience makes the OIDC interceptor reject tokens whose aud claim
+// does not contain aud with CodePermissionDenied. Use the audience this service
+// is registered under so tokens minted for other services are not accepted.
+func WithRequiredAudience(aud string) InterceptorOption {
+	return func(cfg *interceptorConfig) {
+		cfg.requiredAudience = aud
+	}
+}
+
+// WithReplayCache makes the OIDC interceptor reject any token whose jti claim
+// has already been presented, with CodeUnauthenticated. Tokens without a jti are
+// rejected too, since they cannot be tracked. A nil cache uses a
+// NewMemoryReplayCache of DefaultReplayCacheSize; share one cache across the
+// interceptors of a process, or supply a distributed one across replicas.
+func WithReplayCache(cache ReplayCache) InterceptorOption {
+	return func(cfg *interceptorConfig) {
+		if cache == nil {
+			cache = NewMemoryReplayCache(0)
+		}
+		cfg.replayCache = cache
+	}
+}
+
+// WithTargetTenant makes the tenant interceptor compare the tenant claim with
+// the tenant of the resource being accessed, as returned by extract, and deny
+// mismatches with CodePermissionDenied. Without it the interceptor only checks
+// that a tenant claim is present.
+func WithTargetTenant(extract TenantExtractor) InterceptorOption {
+	return func(cfg *interceptorConfig) {
+		cfg.targetTenant = extract
+	}
+}
+
+// WithTrustedProxies lists the proxy addresses or CIDRs whose X-Forwarded-For
+// header NewIPAllowlistInterceptor believes. Without it the header is ignored and
+// only the peer address is used, so clients cannot spoof their address.
+func WithTrustedProxies(cidrs ...string) InterceptorOption {
+	return func(cfg *interceptorConfig) {
+		cfg.trustedProxies = append(cfg.trustedProxies, cidrs...)
+	}
+}
+
+// WithAuditEmitter makes the authz interceptors emit an EventAuthzDenied event,
+// with the missing scopes in its details, for every denial and an
+// EventAuthzGranted event for every procedure that passes its scope check.
+// Types listed in WithSkipAuditTypes are not emitted.
+func WithAuditEmitter(emitter *audit.Emitter) InterceptorOption {
+	return func(cfg *interceptorConfig) {
+		cfg.auditEmitter = emitter
+	}
+}
+
+// WithSessionCreateProcedures lists the procedure paths, such as a login RPC, on
+// which NewSessionInterceptor starts a session for a request without
+// SessionHeader. Entries may be globs, following the same matching rules as
+// ProcedureScopes keys.
+func WithSessionCreateProcedures(procedures ...string) InterceptorOption {
+	return func(cfg *interceptorConfig) {
+		if cfg.sessionCreate == nil {
+			cfg.sessionCreate = make(map[string]bool, len(procedures))
+		}
+		for _, p := range procedures {
+			cfg.sessionCreate[p] = true
+		}
+	}
+}
+
+// isPublic reports whether procedure matches an entry registered via WithPublicProcedures.
+func (cfg interceptorConfig) isPublic(procedure string) bool {
+	public, _ := lookupProcedure(cfg.publicProcedures, procedure)
+	return public
+}
+
 // applyOptions builds an interceptorConfig from the provided options.
 func applyOptions(opts []InterceptorOption) interceptorConfig {
 	cfg := interceptorConfig{}
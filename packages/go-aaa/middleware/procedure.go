@@ -0,0 +1,67 @@
+package middleware
+
+import (
+	"path"
+	"strings"
+)
+
+// globMeta lists the characters that mark a procedure key as a glob pattern.
+const globMeta = "*?["
+
+// lookupProcedure returns the value stored in m for procedure. An exact key always
+// takes precedence; otherwise the most specific glob key (as understood by
+// path.Match, e.g. "/svc.Admin/*") that matches is used. Specificity is the number
+// of literal characters in the pattern, with ties broken by lexical order so that
+// overlapping patterns resolve deterministically.
+func lookupProcedure[V any](m map[string]V, procedure string) (V, bool) {
+	if v, ok := m[procedure]; ok {
+		return v, true
+	}
+
+	var (
+		best      string
+		bestScore = -1
+		found     bool
+	)
+	for pattern := range m {
+		if !strings.ContainsAny(pattern, globMeta) {
+			continue
+		}
+		if ok, err := path.Match(pattern, procedure); err != nil || !ok {
+			continue
+		}
+		score := literalLen(pattern)
+		if score > bestScore || (score == bestScore && pattern < best) {
+			best, bestScore, found = pattern, score, true
+		}
+	}
+	if !found {
+		var zero V
+		return zero, false
+	}
+	return m[best], true
+}
+
+// literalLen counts the non-wildcard characters in a glob pattern. Character
+// classes count as a single literal position.
+func literalLen(pattern string) int {
+	n := 0
+	inClass := false
+	for i := 0; i < len(pattern); i++ {
+		switch c := pattern[i]; {
+		case inClass:
+			if c == ']' {
+				inClass = false
+				n++
+			}
+		case c == '[':
+			inClass = true
+		case c == '\\' && i+1 < len(pattern):
+			i++
+			n++
+		case c != '*' && c != '?':
+			n++
+		}
+	}
+	return n
+}
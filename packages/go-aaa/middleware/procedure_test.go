@@ -0,0 +1,46 @@
+package middleware
+
+import "testing"
+
+func TestLookupProcedure_ExactMatch(t *testing.T) {
+	m := map[string]int{"/svc.Foo/Bar": 1}
+	if v, ok := lookupProcedure(m, "/svc.Foo/Bar"); !ok || v != 1 {
+		t.Errorf("expected exact match 1, got %d (found=%v)", v, ok)
+	}
+	if _, ok := lookupProcedure(m, "/svc.Foo/Baz"); ok {
+		t.Error("expected no match for unlisted procedure")
+	}
+}
+
+func TestLookupProcedure_ExactBeatsGlob(t *testing.T) {
+	m := map[string]int{"/svc.Admin/*": 1, "/svc.Admin/Get": 2}
+	if v, _ := lookupProcedure(m, "/svc.Admin/Get"); v != 2 {
+		t.Errorf("expected exact key to win, got %d", v)
+	}
+	if v, _ := lookupProcedure(m, "/svc.Admin/Delete"); v != 1 {
+		t.Errorf("expected glob key to match, got %d", v)
+	}
+}
+
+func TestLookupProcedure_MostSpecificGlobWins(t *testing.T) {
+	m := map[string]int{"/svc.*/*": 1, "/svc.Admin/*": 2, "/svc.Admin/Del*": 3}
+	cases := map[string]int{
+		"/svc.Admin/DeleteUser": 3,
+		"/svc.Admin/GetUser":    2,
+		"/svc.Public/Ping":      1,
+	}
+	for proc, want := range cases {
+		for i := 0; i < 20; i++ {
+			if got, _ := lookupProcedure(m, proc); got != want {
+				t.Fatalf("%s: expected %d, got %d", proc, want, got)
+			}
+		}
+	}
+}
+
+func TestLookupProcedure_GlobDoesNotCrossSlash(t *testing.T) {
+	m := map[string]int{"/svc.Admin/*": 1}
+	if _, ok := lookupProcedure(m, "/svc.Other/Get"); ok {
+		t.Error("expected glob to be limited to its service")
+	}
+}
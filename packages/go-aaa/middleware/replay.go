@@ -0,0 +1,110 @@
+package middleware
+
+import (
+	"container/heap"
+	"context"
+	"sync"
+	"time"
+)
+
+// DefaultReplayCacheSize is the number of token identifiers a MemoryReplayCache
+// created with a non-positive size retains.
+const DefaultReplayCacheSize = 100_000
+
+// ReplayCache records token identifiers (jti claims) that have been presented,
+// so a token cannot be used more than once within its validity window.
+// Implementations backed by a shared store (e.g. Redis SET NX with expiry) let
+// replicas of a service detect replays across instances.
+type ReplayCache interface {
+	// Seen atomically records jti until exp and reports whether it had already
+	// been recorded and not yet expired.
+	Seen(ctx context.Context, jti string, exp time.Time) (bool, error)
+}
+
+// MemoryReplayCache is an in-process ReplayCache. Entries are kept until their
+// token expires; when the cache is full the entry closest to expiry is evicted
+// to make room, so size it above the number of tokens expected within one
+// token lifetime.
+type MemoryReplayCache struct {
+	mu      sync.Mutex
+	size    int
+	entries map[string]time.Time
+	byExp   jtiHeap
+	now     func() time.Time
+}
+
+// NewMemoryReplayCache returns a MemoryReplayCache holding at most size
+// identifiers. A non-positive size uses DefaultReplayCacheSize.
+func NewMemoryReplayCache(size int) *MemoryReplayCache {
+	if size <= 0 {
+		size = DefaultReplayCacheSize
+	}
+	return &MemoryReplayCache{
+		size:    size,
+		entries: make(map[string]time.Time),
+		now:     time.Now,
+	}
+}
+
+// Seen implements ReplayCache.
+func (c *MemoryReplayCache) Seen(_ context.Context, jti string, exp time.Time) (bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.now()
+	c.prune(now)
+
+	if _, ok := c.entries[jti]; ok {
+		return true, nil
+	}
+	if !exp.After(now) {
+		// Already expired; token validation rejects it, nothing to remember.
+		return false, nil
+	}
+
+	for len(c.entries) >= c.size {
+		oldest := heap.Pop(&c.byExp).(jtiEntry)
+		delete(c.entries, oldest.jti)
+	}
+	c.entries[jti] = exp
+	heap.Push(&c.byExp, jtiEntry{jti: jti, exp: exp})
+	return false, nil
+}
+
+// Len returns the number of identifiers currently retained.
+func (c *MemoryReplayCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.entries)
+}
+
+// prune drops entries whose tokens have expired. Callers must hold c.mu.
+func (c *MemoryReplayCache) prune(now time.Time) {
+	for len(c.byExp) > 0 && !c.byExp[0].exp.After(now) {
+		oldest := heap.Pop(&c.byExp).(jtiEntry)
+		delete(c.entries, oldest.jti)
+	}
+}
+
+// jtiEntry is a recorded identifier and the expiry of the token carrying it.
+type jtiEntry struct {
+	jti string
+	exp time.Time
+}
+
+// jtiHeap is a min-heap of entries ordered by expiry.
+type jtiHeap []jtiEntry
+
+func (h jtiHeap) Len() int           { return len(h) }
+func (h jtiHeap) Less(i, j int) bool { return h[i].exp.Before(h[j].exp) }
+func (h jtiHeap) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+
+func (h *jtiHeap) Push(x any) { *h = append(*h, x.(jtiEntry)) }
+
+func (h *jtiHeap) Pop() any {
+	old := *h
+	n := len(old)
+	e := old[n-1]
+	*h = old[:n-1]
+	return e
+}
@@ -0,0 +1,114 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"connectrpc.com/connect"
+
+	"github.com/penguintechinc/penguin-libs/packages/go-aaa/authn"
+)
+
+func TestMemoryReplayCache_SeenOnSecondUse(t *testing.T) {
+	c := NewMemoryReplayCache(10)
+	exp := time.Now().Add(time.Minute)
+
+	if seen, _ := c.Seen(context.Background(), "jti-1", exp); seen {
+		t.Fatal("first use must not be reported as seen")
+	}
+	if seen, _ := c.Seen(context.Background(), "jti-1", exp); !seen {
+		t.Fatal("second use must be reported as seen")
+	}
+	if seen, _ := c.Seen(context.Background(), "jti-2", exp); seen {
+		t.Fatal("a different jti must not be reported as seen")
+	}
+}
+
+func TestMemoryReplayCache_ForgetsExpiredTokens(t *testing.T) {
+	now := time.Now()
+	c := NewMemoryReplayCache(10)
+	c.now = func() time.Time { return now }
+
+	_, _ = c.Seen(context.Background(), "jti-1", now.Add(time.Minute))
+	now = now.Add(2 * time.Minute)
+
+	if seen, _ := c.Seen(context.Background(), "jti-2", now.Add(time.Minute)); seen {
+		t.Fatal("unexpected replay")
+	}
+	if c.Len() != 1 {
+		t.Errorf("expected expired entry to be pruned, Len() = %d", c.Len())
+	}
+}
+
+func TestMemoryReplayCache_EvictsClosestToExpiryWhenFull(t *testing.T) {
+	now := time.Now()
+	c := NewMemoryReplayCache(2)
+	c.now = func() time.Time { return now }
+
+	_, _ = c.Seen(context.Background(), "late", now.Add(time.Hour))
+	_, _ = c.Seen(context.Background(), "soon", now.Add(time.Minute))
+	_, _ = c.Seen(context.Background(), "new", now.Add(time.Hour))
+
+	if c.Len() != 2 {
+		t.Fatalf("Len() = %d, want 2", c.Len())
+	}
+	if seen, _ := c.Seen(context.Background(), "late", now.Add(time.Hour)); !seen {
+		t.Error("expected the later-expiring entry to be retained")
+	}
+}
+
+func TestOIDCInterceptor_ReplayRejected(t *testing.T) {
+	rp := buildFakeRPInterceptorWithOpts(func(_ string) (*authn.Claims, error) {
+		claims := validClaims("user-abc")
+		claims.Jti = "token-1"
+		return claims, nil
+	}, WithReplayCache(nil))
+
+	call := func() error {
+		req := connect.NewRequest(&struct{}{})
+		req.Header().Set("Authorization", "Bearer token")
+		_, err := rp(noopNext)(context.Background(), req)
+		return err
+	}
+
+	if err := call(); err != nil {
+		t.Fatalf("first use: expected no error, got %v", err)
+	}
+	if err := call(); connect.CodeOf(err) != connect.CodeUnauthenticated {
+		t.Fatalf("replay: expected CodeUnauthenticated, got %v", err)
+	}
+}
+
+func TestOIDCInterceptor_ReplayCacheRequiresJTI(t *testing.T) {
+	rp := buildFakeRPInterceptorWithOpts(func(_ string) (*authn.Claims, error) {
+		return validClaims("user-abc"), nil
+	}, WithReplayCache(NewMemoryReplayCache(10)))
+
+	req := connect.NewRequest(&struct{}{})
+	req.Header().Set("Authorization", "Bearer token")
+	if _, err := rp(noopNext)(context.Background(), req); connect.CodeOf(err) != connect.CodeUnauthenticated {
+		t.Fatalf("expected CodeUnauthenticated for a token without jti, got %v", err)
+	}
+}
+
+type failingReplayCache struct{}
+
+func (failingReplayCache) Seen(context.Context, string, time.Time) (bool, error) {
+	return false, errors.New("store unreachable")
+}
+
+func TestOIDCInterceptor_ReplayCacheErrorFailsClosed(t *testing.T) {
+	rp := buildFakeRPInterceptorWithOpts(func(_ string) (*authn.Claims, error) {
+		claims := validClaims("user-abc")
+		claims.Jti = "token-1"
+		return claims, nil
+	}, WithReplayCache(failingReplayCache{}))
+
+	req := connect.NewRequest(&struct{}{})
+	req.Header().Set("Authorization", "Bearer token")
+	if _, err := rp(noopNext)(context.Background(), req); connect.CodeOf(err) != connect.CodeUnavailable {
+		t.Fatalf("expected CodeUnavailable, got %v", err)
+	}
+}
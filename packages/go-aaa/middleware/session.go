@@ -0,0 +1,149 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"connectrpc.com/connect"
+
+	"github.com/penguintechinc/penguin-libs/packages/go-aaa/audit"
+	"github.com/penguintechinc/penguin-libs/packages/go-aaa/authz"
+	"github.com/penguintechinc/penguin-libs/packages/go-aaa/session"
+)
+
+// SessionHeader carries the session identifier on requests and responses.
+const SessionHeader = "X-Session-ID"
+
+// NewSessionInterceptor returns a ConnectRPC interceptor that ties authenticated
+// requests to a server-side session. It must run after an authentication
+// interceptor that stores Claims in the context; requests without claims pass
+// through untouched.
+//
+// A request without SessionHeader to a procedure listed in
+// WithSessionCreateProcedures starts a new session, emits EventSessionCreated,
+// and receives the session ID in SessionHeader of the response. If the handler
+// fails, the new session is revoked again and EventSessionDestroyed is emitted.
+// On any other procedure it is rejected with CodeUnauthenticated. A request
+// carrying a session ID touches it, sliding its expiry to ttl from now. Unknown or revoked sessions are rejected with
+// CodeUnauthenticated; an expired session presented by its owner additionally
+// emits EventSessionDestroyed. A session presented by a subject or tenant other
+// than its owner's is rejected with CodePermissionDenied and left untouched.
+// The current session is available to handlers via session.FromContext.
+// emitter may be nil to disable auditing.
+func NewSessionInterceptor(store session.Store, emitter *audit.Emitter, ttl time.Duration, opts ...InterceptorOption) connect.UnaryInterceptorFunc {
+	cfg := applyOptions(opts)
+	return func(next connect.UnaryFunc) connect.UnaryFunc {
+		return func(ctx context.Context, req connect.AnyRequest) (connect.AnyResponse, error) {
+			claims := authz.ClaimsFromContext(ctx)
+			if cfg.isPublic(req.Spec().Procedure) || claims == nil {
+				return next(ctx, req)
+			}
+
+			var (
+				sess    *session.Session
+				created bool
+				err     error
+			)
+			if id := req.Header().Get(SessionHeader); id != "" {
+				// Check ownership before Touch so a foreign caller cannot extend
+				// someone else's session.
+				sess, err = store.Get(ctx, id)
+				if errors.Is(err, session.ErrExpired) && sess != nil && sess.Subject == claims.Sub && sess.Tenant == claims.Tenant {
+					emitSessionEvent(ctx, emitter, req, audit.EventSessionDestroyed, sess.Subject, sess.Tenant, "expired")
+				}
+				if err != nil {
+					return nil, sessionError(err)
+				}
+				if sess.Subject != claims.Sub || sess.Tenant != claims.Tenant {
+					return nil, connect.NewError(connect.CodePermissionDenied, fmt.Errorf("session does not belong to the authenticated subject and tenant"))
+				}
+				if sess, err = store.Touch(ctx, id, ttl); err != nil {
+					return nil, sessionError(err)
+				}
+			} else {
+				if create, _ := lookupProcedure(cfg.sessionCreate, req.Spec().Procedure); !create {
+					return nil, connect.NewError(connect.CodeUnauthenticated, fmt.Errorf("missing %s header", SessionHeader))
+				}
+				sess, err = store.Create(ctx, claims.Sub, claims.Tenant, ttl)
+				if err != nil {
+					return nil, connect.NewError(connect.CodeInternal, fmt.Errorf("session creation failed: %w", err))
+				}
+				created = true
+				emitSessionEvent(ctx, emitter, req, audit.EventSessionCreated, sess.Subject, sess.Tenant, "")
+			}
+
+			resp, err := next(session.ContextWithSession(ctx, sess), req)
+			if !created {
+				return resp, err
+			}
+			if err != nil {
+				// The client never learns the ID of a failed call's session, so
+				// do not leave it live.
+				if revokeErr := store.Revoke(ctx, sess.ID); revokeErr == nil {
+					emitSessionEvent(ctx, emitter, req, audit.EventSessionDestroyed, sess.Subject, sess.Tenant, "handler failed")
+				}
+				return resp, err
+			}
+			if resp != nil {
+				resp.Header().Set(SessionHeader, sess.ID)
+			}
+			return resp, nil
+		}
+	}
+}
+
+// sessionError maps a session.Store lookup error to a connect error.
+func sessionError(err error) error {
+	switch {
+	case errors.Is(err, session.ErrExpired):
+		return connect.NewError(connect.CodeUnauthenticated, fmt.Errorf("session expired"))
+	case errors.Is(err, session.ErrNotFound):
+		return connect.NewError(connect.CodeUnauthenticated, fmt.Errorf("session not found or revoked"))
+	default:
+		return connect.NewError(connect.CodeInternal, fmt.Errorf("session lookup failed: %w", err))
+	}
+}
+
+// RevokeSession ends the session stored in ctx by NewSessionInterceptor and
+// emits EventSessionDestroyed. It returns session.ErrNotFound when ctx carries
+// no session. emitter may be nil to disable auditing.
+func RevokeSession(ctx context.Context, store session.Store, emitter *audit.Emitter) error {
+	sess := session.FromContext(ctx)
+	if sess == nil {
+		return session.ErrNotFound
+	}
+	if err := store.Revoke(ctx, sess.ID); err != nil {
+		return err
+	}
+	if emitter != nil {
+		event := audit.NewAuditEvent(audit.EventSessionDestroyed, sess.Subject, "session.revoke", "session", audit.OutcomeSuccess,
+			audit.WithTenant(sess.Tenant),
+			audit.WithDetails(map[string]interface{}{"reason": "revoked"}),
+		)
+		_ = emitter.EmitContext(ctx, event)
+	}
+	return nil
+}
+
+// emitSessionEvent records a session lifecycle event for req. reason, when set,
+// is recorded in the event details.
+func emitSessionEvent(ctx context.Context, emitter *audit.Emitter, req connect.AnyRequest, eventType audit.EventType, subject, tenant, reason string) {
+	if emitter == nil {
+		return
+	}
+	action := "session.create"
+	if eventType == audit.EventSessionDestroyed {
+		action = "session.revoke"
+		if reason == "expired" {
+			action = "session.expire"
+		}
+	}
+	opts := append(requestMetadata(req), audit.WithTenant(tenant))
+	if reason != "" {
+		opts = append(opts, audit.WithDetails(map[string]interface{}{"reason": reason}))
+	}
+	event := audit.NewAuditEvent(eventType, subject, action, "session", audit.OutcomeSuccess, opts...)
+	_ = emitter.EmitContext(ctx, event)
+}
@@ -0,0 +1,289 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"connectrpc.com/connect"
+
+	"github.com/penguintechinc/penguin-libs/packages/go-aaa/audit"
+	"github.com/penguintechinc/penguin-libs/packages/go-aaa/session"
+)
+
+// sessionEchoNext returns a response and records the session seen by the handler.
+func sessionEchoNext(seen **session.Session) connect.UnaryFunc {
+	return func(ctx context.Context, _ connect.AnyRequest) (connect.AnyResponse, error) {
+		*seen = session.FromContext(ctx)
+		return connect.NewResponse(&struct{}{}), nil
+	}
+}
+
+// countingStore counts the sessions created through it.
+type countingStore struct {
+	session.Store
+	creates int
+}
+
+func (s *countingStore) Create(ctx context.Context, subject, tenant string, ttl time.Duration) (*session.Session, error) {
+	s.creates++
+	return s.Store.Create(ctx, subject, tenant, ttl)
+}
+
+func TestSessionInterceptor_CreatesSessionAndEmitsCreated(t *testing.T) {
+	var received []audit.AuditEvent
+	store := session.NewMemoryStore()
+	interceptor := NewSessionInterceptor(store, buildAuditEmitter(&received), time.Hour,
+		WithSessionCreateProcedures("/auth.v1.AuthService/Login"))
+
+	var seen *session.Session
+	ctx := ctxWithClaims("user-1", nil, nil, "acme")
+	resp, err := interceptor(sessionEchoNext(&seen))(ctx, newProcedureRequest("/auth.v1.AuthService/Login"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if seen == nil || seen.Subject != "user-1" || seen.Tenant != "acme" {
+		t.Fatalf("handler saw session %+v", seen)
+	}
+	if got := resp.Header().Get(SessionHeader); got != seen.ID {
+		t.Errorf("response %s = %q, want %q", SessionHeader, got, seen.ID)
+	}
+	if _, err := store.Get(ctx, seen.ID); err != nil {
+		t.Errorf("expected session to be stored: %v", err)
+	}
+	if len(received) != 1 || received[0].Type != audit.EventSessionCreated || received[0].Subject != "user-1" {
+		t.Errorf("expected one session.created event for user-1, got %+v", received)
+	}
+}
+
+func TestSessionInterceptor_MissingSessionOutsideCreateProcedures(t *testing.T) {
+	store := &countingStore{Store: session.NewMemoryStore()}
+	interceptor := NewSessionInterceptor(store, nil, time.Hour,
+		WithSessionCreateProcedures("/auth.v1.AuthService/Login"))
+
+	ctx := ctxWithClaims("user-1", nil, nil, "acme")
+	_, err := interceptor(noopNext)(ctx, newProcedureRequest("/svc.Reports/List"))
+	if connect.CodeOf(err) != connect.CodeUnauthenticated {
+		t.Fatalf("expected CodeUnauthenticated, got %v", err)
+	}
+	if store.creates != 0 {
+		t.Errorf("expected no session to be created, got %d", store.creates)
+	}
+}
+
+func TestSessionInterceptor_CreatedSessionRevokedOnError(t *testing.T) {
+	var received []audit.AuditEvent
+	store := session.NewMemoryStore()
+	interceptor := NewSessionInterceptor(store, buildAuditEmitter(&received), time.Hour,
+		WithSessionCreateProcedures("/auth.v1.AuthService/Login"))
+
+	var seen *session.Session
+	handlerErr := errors.New("handler failed")
+	failing := func(ctx context.Context, _ connect.AnyRequest) (connect.AnyResponse, error) {
+		seen = session.FromContext(ctx)
+		return nil, handlerErr
+	}
+	ctx := ctxWithClaims("user-1", nil, nil, "acme")
+	_, err := interceptor(failing)(ctx, newProcedureRequest("/auth.v1.AuthService/Login"))
+
+	if !errors.Is(err, handlerErr) {
+		t.Fatalf("expected the handler error, got %v", err)
+	}
+	if seen == nil {
+		t.Fatal("expected the handler to see the created session")
+	}
+	if _, err := store.Get(ctx, seen.ID); !errors.Is(err, session.ErrNotFound) {
+		t.Errorf("expected the session to be revoked, got %v", err)
+	}
+	if len(received) != 2 || received[1].Type != audit.EventSessionDestroyed {
+		t.Errorf("expected created and destroyed events, got %+v", received)
+	}
+}
+
+func TestSessionInterceptor_ExistingSessionIsTouched(t *testing.T) {
+	var received []audit.AuditEvent
+	store := session.NewMemoryStore()
+	interceptor := NewSessionInterceptor(store, buildAuditEmitter(&received), time.Hour)
+
+	ctx := ctxWithClaims("user-1", nil, nil, "")
+	existing, _ := store.Create(ctx, "user-1", "", time.Hour)
+
+	req := connect.NewRequest(&struct{}{})
+	req.Header().Set(SessionHeader, existing.ID)
+
+	var seen *session.Session
+	resp, err := interceptor(sessionEchoNext(&seen))(ctx, req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if seen == nil || seen.ID != existing.ID {
+		t.Fatalf("expected handler to see existing session, got %+v", seen)
+	}
+	if !seen.LastSeen.After(existing.LastSeen) && !seen.LastSeen.Equal(existing.LastSeen) {
+		t.Error("expected LastSeen not to move backwards")
+	}
+	if resp.Header().Get(SessionHeader) != "" {
+		t.Error("expected no session header when reusing a session")
+	}
+	if len(received) != 0 {
+		t.Errorf("expected no audit events for an existing session, got %d", len(received))
+	}
+}
+
+func TestSessionInterceptor_ExpiredSessionEmitsDestroyed(t *testing.T) {
+	var received []audit.AuditEvent
+	store := session.NewMemoryStore()
+	interceptor := NewSessionInterceptor(store, buildAuditEmitter(&received), time.Hour)
+
+	ctx := ctxWithClaims("user-1", nil, nil, "")
+	short, _ := store.Create(ctx, "user-1", "", 10*time.Millisecond)
+	time.Sleep(20 * time.Millisecond)
+
+	req := connect.NewRequest(&struct{}{})
+	req.Header().Set(SessionHeader, short.ID)
+	_, err := interceptor(noopNext)(ctx, req)
+
+	if connect.CodeOf(err) != connect.CodeUnauthenticated {
+		t.Fatalf("expected CodeUnauthenticated, got %v", err)
+	}
+	if len(received) != 1 || received[0].Type != audit.EventSessionDestroyed {
+		t.Fatalf("expected one session.destroyed event, got %+v", received)
+	}
+	if received[0].Details["reason"] != "expired" {
+		t.Errorf("expected reason expired, got %v", received[0].Details["reason"])
+	}
+}
+
+func TestSessionInterceptor_ExpiredForeignSessionNotAudited(t *testing.T) {
+	var received []audit.AuditEvent
+	store := session.NewMemoryStore()
+	interceptor := NewSessionInterceptor(store, buildAuditEmitter(&received), time.Hour)
+
+	short, _ := store.Create(context.Background(), "user-1", "acme", 10*time.Millisecond)
+	time.Sleep(20 * time.Millisecond)
+
+	req := connect.NewRequest(&struct{}{})
+	req.Header().Set(SessionHeader, short.ID)
+	_, err := interceptor(noopNext)(ctxWithClaims("user-2", nil, nil, "globex"), req)
+
+	if connect.CodeOf(err) != connect.CodeUnauthenticated {
+		t.Fatalf("expected CodeUnauthenticated, got %v", err)
+	}
+	if len(received) != 0 {
+		t.Errorf("expected no audit event for another subject's expired session, got %+v", received)
+	}
+}
+
+func TestSessionInterceptor_RevokedSessionRejected(t *testing.T) {
+	store := session.NewMemoryStore()
+	interceptor := NewSessionInterceptor(store, nil, time.Hour)
+
+	ctx := ctxWithClaims("user-1", nil, nil, "")
+	s, _ := store.Create(ctx, "user-1", "", time.Hour)
+	_ = store.Revoke(ctx, s.ID)
+
+	req := connect.NewRequest(&struct{}{})
+	req.Header().Set(SessionHeader, s.ID)
+	_, err := interceptor(noopNext)(ctx, req)
+	if connect.CodeOf(err) != connect.CodeUnauthenticated {
+		t.Fatalf("expected CodeUnauthenticated, got %v", err)
+	}
+}
+
+func TestSessionInterceptor_ForeignSubjectDenied(t *testing.T) {
+	store := session.NewMemoryStore()
+	interceptor := NewSessionInterceptor(store, nil, time.Hour)
+
+	victim, _ := store.Create(context.Background(), "user-1", "", time.Hour)
+
+	req := connect.NewRequest(&struct{}{})
+	req.Header().Set(SessionHeader, victim.ID)
+	_, err := interceptor(noopNext)(ctxWithClaims("user-2", nil, nil, ""), req)
+	if connect.CodeOf(err) != connect.CodePermissionDenied {
+		t.Fatalf("expected CodePermissionDenied, got %v", err)
+	}
+}
+
+func TestSessionInterceptor_ForeignSubjectDoesNotExtendSession(t *testing.T) {
+	store := session.NewMemoryStore()
+	interceptor := NewSessionInterceptor(store, nil, 24*time.Hour)
+
+	victim, _ := store.Create(context.Background(), "user-1", "", time.Hour)
+
+	req := connect.NewRequest(&struct{}{})
+	req.Header().Set(SessionHeader, victim.ID)
+	if _, err := interceptor(noopNext)(ctxWithClaims("user-2", nil, nil, ""), req); connect.CodeOf(err) != connect.CodePermissionDenied {
+		t.Fatalf("expected CodePermissionDenied, got %v", err)
+	}
+
+	after, err := store.Get(context.Background(), victim.ID)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if !after.ExpiresAt.Equal(victim.ExpiresAt) {
+		t.Errorf("ExpiresAt moved from %v to %v after a foreign request", victim.ExpiresAt, after.ExpiresAt)
+	}
+}
+
+func TestSessionInterceptor_ForeignTenantDenied(t *testing.T) {
+	store := session.NewMemoryStore()
+	interceptor := NewSessionInterceptor(store, nil, time.Hour)
+
+	s, _ := store.Create(context.Background(), "user-1", "acme", time.Hour)
+
+	req := connect.NewRequest(&struct{}{})
+	req.Header().Set(SessionHeader, s.ID)
+	_, err := interceptor(noopNext)(ctxWithClaims("user-1", nil, nil, "globex"), req)
+	if connect.CodeOf(err) != connect.CodePermissionDenied {
+		t.Fatalf("expected CodePermissionDenied, got %v", err)
+	}
+}
+
+func TestSessionInterceptor_NoClaimsPassesThrough(t *testing.T) {
+	store := session.NewMemoryStore()
+	interceptor := NewSessionInterceptor(store, nil, time.Hour)
+
+	var seen *session.Session
+	if _, err := interceptor(sessionEchoNext(&seen))(context.Background(), connect.NewRequest(&struct{}{})); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if seen != nil {
+		t.Errorf("expected no session for unauthenticated request, got %+v", seen)
+	}
+}
+
+func TestRevokeSession_EmitsDestroyed(t *testing.T) {
+	var received []audit.AuditEvent
+	emitter := buildAuditEmitter(&received)
+	store := session.NewMemoryStore()
+	interceptor := NewSessionInterceptor(store, emitter, time.Hour,
+		WithSessionCreateProcedures("/auth.v1.AuthService/Login"))
+
+	var sessionID string
+	logout := func(ctx context.Context, _ connect.AnyRequest) (connect.AnyResponse, error) {
+		sessionID = session.FromContext(ctx).ID
+		return connect.NewResponse(&struct{}{}), RevokeSession(ctx, store, emitter)
+	}
+
+	if _, err := interceptor(logout)(ctxWithClaims("user-1", nil, nil, ""), newProcedureRequest("/auth.v1.AuthService/Login")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, err := store.Get(context.Background(), sessionID); !errors.Is(err, session.ErrNotFound) {
+		t.Errorf("expected session to be revoked, got %v", err)
+	}
+	if len(received) != 2 {
+		t.Fatalf("expected created and destroyed events, got %d", len(received))
+	}
+	if received[1].Type != audit.EventSessionDestroyed || received[1].Details["reason"] != "revoked" {
+		t.Errorf("expected session.destroyed with reason revoked, got %+v", received[1])
+	}
+}
+
+func TestRevokeSession_NoSession(t *testing.T) {
+	if err := RevokeSession(context.Background(), session.NewMemoryStore(), nil); !errors.Is(err, session.ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+}
@@ -9,14 +9,23 @@ import (
 	"github.com/penguintechinc/penguin-libs/packages/go-aaa/authz"
 )
 
+// TenantExtractor returns the tenant owning the resource a request targets, for
+// example from a request message field or header. An empty result means the
+// request does not address a specific tenant's resources; an error rejects the
+// request with CodeInvalidArgument.
+type TenantExtractor func(ctx context.Context, req connect.AnyRequest) (string, error)
+
 // NewTenantInterceptor returns a ConnectRPC interceptor that enforces the presence
 // of a non-empty tenant claim on every non-public procedure. It must run after an
 // authentication interceptor that stores Claims in the context.
+//
+// With WithTargetTenant, the claim must also match the tenant of the targeted
+// resource, preventing cross-tenant access.
 func NewTenantInterceptor(opts ...InterceptorOption) connect.UnaryInterceptorFunc {
 	cfg := applyOptions(opts)
 	return func(next connect.UnaryFunc) connect.UnaryFunc {
 		return func(ctx context.Context, req connect.AnyRequest) (connect.AnyResponse, error) {
-			if cfg.publicProcedures[req.Spec().Procedure] {
+			if cfg.isPublic(req.Spec().Procedure) {
 				return next(ctx, req)
 			}
 
@@ -25,6 +34,16 @@ func NewTenantInterceptor(opts ...InterceptorOption) connect.UnaryInterceptorFun
 				return nil, connect.NewError(connect.CodePermissionDenied, fmt.Errorf("missing tenant claim"))
 			}
 
+			if cfg.targetTenant != nil {
+				target, err := cfg.targetTenant(ctx, req)
+				if err != nil {
+					return nil, connect.NewError(connect.CodeInvalidArgument, fmt.Errorf("resolve target tenant: %w", err))
+				}
+				if target != "" && target != tenant {
+					return nil, connect.NewError(connect.CodePermissionDenied, fmt.Errorf("tenant %q may not access resources of tenant %q", tenant, target))
+				}
+			}
+
 			return next(ctx, req)
 		}
 	}
@@ -0,0 +1,108 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// MemoryStore is a thread-safe, in-memory Store. Expired sessions are removed
+// when they are next looked up.
+type MemoryStore struct {
+	mu       sync.Mutex
+	sessions map[string]Session
+	now      func() time.Time
+}
+
+// NewMemoryStore creates an empty MemoryStore.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		sessions: make(map[string]Session),
+		now:      time.Now,
+	}
+}
+
+// Create starts a new session for subject that expires after ttl.
+func (m *MemoryStore) Create(_ context.Context, subject, tenant string, ttl time.Duration) (*Session, error) {
+	if subject == "" {
+		return nil, fmt.Errorf("session: subject is required")
+	}
+	if ttl <= 0 {
+		return nil, fmt.Errorf("session: ttl must be positive")
+	}
+	id, err := newID()
+	if err != nil {
+		return nil, err
+	}
+
+	now := m.now()
+	s := Session{
+		ID:        id,
+		Subject:   subject,
+		Tenant:    tenant,
+		CreatedAt: now,
+		LastSeen:  now,
+		ExpiresAt: now.Add(ttl),
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.sessions[id] = s
+	return &s, nil
+}
+
+// Get returns a copy of the session with id.
+func (m *MemoryStore) Get(_ context.Context, id string) (*Session, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	s, err := m.lookup(id)
+	if errors.Is(err, ErrExpired) {
+		return &s, err
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// Touch updates LastSeen and slides ExpiresAt to ttl from now.
+func (m *MemoryStore) Touch(_ context.Context, id string, ttl time.Duration) (*Session, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	s, err := m.lookup(id)
+	if err != nil {
+		return nil, err
+	}
+	now := m.now()
+	s.LastSeen = now
+	s.ExpiresAt = now.Add(ttl)
+	m.sessions[id] = s
+	return &s, nil
+}
+
+// Revoke removes the session with id.
+func (m *MemoryStore) Revoke(_ context.Context, id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.sessions[id]; !ok {
+		return ErrNotFound
+	}
+	delete(m.sessions, id)
+	return nil
+}
+
+// lookup returns the live session with id, deleting it if it has expired.
+// Must be called with m.mu held.
+func (m *MemoryStore) lookup(id string) (Session, error) {
+	s, ok := m.sessions[id]
+	if !ok {
+		return Session{}, ErrNotFound
+	}
+	if s.Expired(m.now()) {
+		delete(m.sessions, id)
+		return s, ErrExpired
+	}
+	return s, nil
+}
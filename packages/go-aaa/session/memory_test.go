@@ -0,0 +1,136 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// newTestStore returns a MemoryStore whose clock is controlled by the returned
+// advance function.
+func newTestStore() (*MemoryStore, func(time.Duration)) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	store := NewMemoryStore()
+	store.now = func() time.Time { return now }
+	return store, func(d time.Duration) { now = now.Add(d) }
+}
+
+func TestMemoryStore_CreateAndGet(t *testing.T) {
+	store, _ := newTestStore()
+	ctx := context.Background()
+
+	s, err := store.Create(ctx, "user-1", "acme", time.Hour)
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if s.ID == "" || s.Subject != "user-1" || s.Tenant != "acme" {
+		t.Fatalf("unexpected session: %+v", s)
+	}
+	if got := s.ExpiresAt.Sub(s.CreatedAt); got != time.Hour {
+		t.Errorf("expected 1h lifetime, got %v", got)
+	}
+
+	got, err := store.Get(ctx, s.ID)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if *got != *s {
+		t.Errorf("Get returned %+v, want %+v", got, s)
+	}
+}
+
+func TestMemoryStore_IDsAreUnique(t *testing.T) {
+	store, _ := newTestStore()
+	seen := make(map[string]bool)
+	for i := 0; i < 100; i++ {
+		s, err := store.Create(context.Background(), "user-1", "", time.Hour)
+		if err != nil {
+			t.Fatalf("Create: %v", err)
+		}
+		if seen[s.ID] {
+			t.Fatalf("duplicate session id %q", s.ID)
+		}
+		seen[s.ID] = true
+	}
+}
+
+func TestMemoryStore_TouchExtendsExpiry(t *testing.T) {
+	store, advance := newTestStore()
+	ctx := context.Background()
+
+	s, _ := store.Create(ctx, "user-1", "", time.Hour)
+	advance(45 * time.Minute)
+
+	touched, err := store.Touch(ctx, s.ID, time.Hour)
+	if err != nil {
+		t.Fatalf("Touch: %v", err)
+	}
+	if !touched.LastSeen.After(s.LastSeen) {
+		t.Error("expected LastSeen to advance")
+	}
+	if want := s.ExpiresAt.Add(45 * time.Minute); !touched.ExpiresAt.Equal(want) {
+		t.Errorf("ExpiresAt = %v, want %v", touched.ExpiresAt, want)
+	}
+
+	// Past the original expiry but within the extended one.
+	advance(30 * time.Minute)
+	if _, err := store.Get(ctx, s.ID); err != nil {
+		t.Errorf("expected touched session to still be valid, got %v", err)
+	}
+}
+
+func TestMemoryStore_Expire(t *testing.T) {
+	store, advance := newTestStore()
+	ctx := context.Background()
+
+	s, _ := store.Create(ctx, "user-1", "", time.Minute)
+	advance(time.Minute)
+
+	expired, err := store.Get(ctx, s.ID)
+	if !errors.Is(err, ErrExpired) {
+		t.Fatalf("expected ErrExpired, got %v", err)
+	}
+	if expired == nil || expired.Subject != "user-1" {
+		t.Errorf("expected the expired session alongside ErrExpired, got %+v", expired)
+	}
+	if _, err := store.Touch(ctx, s.ID, time.Hour); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected expired session to be removed, got %v", err)
+	}
+}
+
+func TestMemoryStore_Revoke(t *testing.T) {
+	store, _ := newTestStore()
+	ctx := context.Background()
+
+	s, _ := store.Create(ctx, "user-1", "", time.Hour)
+	if err := store.Revoke(ctx, s.ID); err != nil {
+		t.Fatalf("Revoke: %v", err)
+	}
+	if _, err := store.Get(ctx, s.ID); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound after revoke, got %v", err)
+	}
+	if err := store.Revoke(ctx, s.ID); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound revoking twice, got %v", err)
+	}
+}
+
+func TestMemoryStore_CreateValidatesInput(t *testing.T) {
+	store, _ := newTestStore()
+	if _, err := store.Create(context.Background(), "", "", time.Hour); err == nil {
+		t.Error("expected error for empty subject")
+	}
+	if _, err := store.Create(context.Background(), "user-1", "", 0); err == nil {
+		t.Error("expected error for non-positive ttl")
+	}
+}
+
+func TestContextWithSession_RoundTrip(t *testing.T) {
+	if FromContext(context.Background()) != nil {
+		t.Error("expected nil session in empty context")
+	}
+	s := &Session{ID: "abc", Subject: "user-1"}
+	if got := FromContext(ContextWithSession(context.Background(), s)); got != s {
+		t.Errorf("FromContext = %v, want %v", got, s)
+	}
+}
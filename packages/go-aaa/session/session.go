@@ -0,0 +1,78 @@
+// Package session provides server-side session lifecycle management for
+// Penguin Tech applications. Sessions track an authenticated subject across
+// requests and back the session.created and session.destroyed audit events.
+package session
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrNotFound is returned when a session does not exist or has been revoked.
+var ErrNotFound = errors.New("session: not found")
+
+// ErrExpired is returned when a session exists but its expiry has passed.
+var ErrExpired = errors.New("session: expired")
+
+// Session is a server-side record of an authenticated subject.
+type Session struct {
+	// ID is the opaque, unguessable session identifier.
+	ID string `json:"id"`
+	// Subject is the authenticated subject that owns the session.
+	Subject string `json:"subject"`
+	// Tenant is the subject's tenant, when known.
+	Tenant string `json:"tenant,omitempty"`
+	// CreatedAt is when the session was established.
+	CreatedAt time.Time `json:"created_at"`
+	// LastSeen is when the session was last touched.
+	LastSeen time.Time `json:"last_seen"`
+	// ExpiresAt is when the session stops being valid unless touched.
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// Expired reports whether the session has expired as of now.
+func (s *Session) Expired(now time.Time) bool {
+	return !now.Before(s.ExpiresAt)
+}
+
+// Store persists sessions. Implementations must be safe for concurrent use.
+type Store interface {
+	// Create starts a new session for subject that expires after ttl.
+	Create(ctx context.Context, subject, tenant string, ttl time.Duration) (*Session, error)
+	// Get returns the session with id, ErrNotFound if it is unknown or revoked,
+	// or ErrExpired if it has expired. With ErrExpired it also returns the
+	// expired session, so callers can attribute the expiry to its owner.
+	Get(ctx context.Context, id string) (*Session, error)
+	// Touch records activity on the session and extends its expiry to ttl from now.
+	// It returns the same errors as Get.
+	Touch(ctx context.Context, id string, ttl time.Duration) (*Session, error)
+	// Revoke ends the session. Revoking an unknown session returns ErrNotFound.
+	Revoke(ctx context.Context, id string) error
+}
+
+// sessionKey is the unexported context key used to store the current Session.
+type sessionKey struct{}
+
+// ContextWithSession returns a new context carrying s.
+func ContextWithSession(ctx context.Context, s *Session) context.Context {
+	return context.WithValue(ctx, sessionKey{}, s)
+}
+
+// FromContext returns the Session stored in ctx, or nil if absent.
+func FromContext(ctx context.Context) *Session {
+	s, _ := ctx.Value(sessionKey{}).(*Session)
+	return s
+}
+
+// newID returns a 256-bit random session identifier encoded as base64url.
+func newID() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("session: generate id: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
@@ -0,0 +1,43 @@
+package logging
+
+import (
+	"context"
+
+	"go.uber.org/zap"
+)
+
+// CorrelationIDField is the log field name under which WithContext records the
+// request correlation ID.
+const CorrelationIDField = "correlation_id"
+
+// correlationIDKey is the unexported context key for request correlation IDs.
+// It is shared with servers built on go-h3, whose correlation interceptor
+// stores IDs through ContextWithCorrelationID.
+type correlationIDKey struct{}
+
+// ContextWithCorrelationID returns a new context carrying the given correlation ID.
+func ContextWithCorrelationID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, correlationIDKey{}, id)
+}
+
+// CorrelationIDFromContext extracts the correlation ID stored in ctx, or an empty
+// string if absent.
+func CorrelationIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(correlationIDKey{}).(string)
+	return id
+}
+
+// WithContext returns a logger that attaches the correlation ID carried by ctx
+// to every line it writes. The receiver is returned unchanged when ctx has no
+// correlation ID.
+func (l *SanitizedLogger) WithContext(ctx context.Context) *SanitizedLogger {
+	id := CorrelationIDFromContext(ctx)
+	if id == "" {
+		return l
+	}
+	return &SanitizedLogger{
+		logger:    l.logger.With(l.sanitizer.SanitizeField(zap.String(CorrelationIDField, id))),
+		name:      l.name,
+		sanitizer: l.sanitizer,
+	}
+}
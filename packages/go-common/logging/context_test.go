@@ -0,0 +1,71 @@
+package logging
+
+import (
+	"context"
+	"testing"
+)
+
+func TestCorrelationID_RoundTrip(t *testing.T) {
+	ctx := ContextWithCorrelationID(context.Background(), "corr-123")
+	if got := CorrelationIDFromContext(ctx); got != "corr-123" {
+		t.Errorf("expected corr-123, got %q", got)
+	}
+}
+
+func TestCorrelationIDFromContext_AbsentReturnsEmpty(t *testing.T) {
+	if got := CorrelationIDFromContext(context.Background()); got != "" {
+		t.Errorf("expected empty string, got %q", got)
+	}
+}
+
+func TestWithContext_AttachesCorrelationID(t *testing.T) {
+	capture := &captureSink{}
+	logger, err := NewLogger(LoggerConfig{Name: "ctx-test", Sinks: []Sink{capture}, JSON: true})
+	if err != nil {
+		t.Fatalf("NewLogger: %v", err)
+	}
+
+	ctx := ContextWithCorrelationID(context.Background(), "corr-456")
+	reqLogger := logger.WithContext(ctx)
+	reqLogger.Info("first")
+	reqLogger.Warn("second")
+
+	if capture.count() != 2 {
+		t.Fatalf("expected 2 events, got %d", capture.count())
+	}
+	for i := 0; i < 2; i++ {
+		if got := capture.get(i)[CorrelationIDField]; got != "corr-456" {
+			t.Errorf("event %d: %s = %v, want corr-456", i, CorrelationIDField, got)
+		}
+	}
+}
+
+func TestWithContext_NoCorrelationIDReturnsSameLogger(t *testing.T) {
+	capture := &captureSink{}
+	logger, err := NewLogger(LoggerConfig{Name: "ctx-test", Sinks: []Sink{capture}, JSON: true})
+	if err != nil {
+		t.Fatalf("NewLogger: %v", err)
+	}
+
+	if got := logger.WithContext(context.Background()); got != logger {
+		t.Error("expected the receiver to be returned when ctx has no correlation ID")
+	}
+	logger.Info("plain")
+	if _, ok := capture.get(0)[CorrelationIDField]; ok {
+		t.Errorf("unexpected %s field on plain logger", CorrelationIDField)
+	}
+}
+
+func TestWithContext_DoesNotMutateParent(t *testing.T) {
+	capture := &captureSink{}
+	logger, err := NewLogger(LoggerConfig{Name: "ctx-test", Sinks: []Sink{capture}, JSON: true})
+	if err != nil {
+		t.Fatalf("NewLogger: %v", err)
+	}
+
+	_ = logger.WithContext(ContextWithCorrelationID(context.Background(), "corr-789"))
+	logger.Info("parent")
+	if _, ok := capture.get(0)[CorrelationIDField]; ok {
+		t.Errorf("parent logger picked up %s", CorrelationIDField)
+	}
+}
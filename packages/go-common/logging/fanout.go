@@ -0,0 +1,95 @@
+package logging
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+const defaultFanOutTimeout = 2 * time.Second
+
+// ErrSinkTimeout is reported for a child sink that did not finish a
+// FanOutSink operation within the per-sink timeout.
+var ErrSinkTimeout = errors.New("sink operation timed out")
+
+// FanOutSink dispatches each operation to its child sinks concurrently, so a
+// slow network sink does not delay fast local ones. Each child has a bounded
+// time to finish; errors and timeouts are collected into one aggregate error.
+//
+// A child that times out keeps running in the background until its call
+// returns; FanOutSink stops waiting for it but cannot cancel it.
+type FanOutSink struct {
+	sinks   []Sink
+	timeout time.Duration
+}
+
+// NewFanOutSink returns a FanOutSink over sinks. A non-positive timeout
+// defaults to 2s.
+func NewFanOutSink(timeout time.Duration, sinks ...Sink) *FanOutSink {
+	if timeout <= 0 {
+		timeout = defaultFanOutTimeout
+	}
+	return &FanOutSink{sinks: sinks, timeout: timeout}
+}
+
+// Write sends the event to every child sink. Each child receives its own
+// shallow copy of the event so children cannot observe each other's mutations.
+func (s *FanOutSink) Write(event map[string]interface{}) error {
+	return s.each(func(sink Sink) error {
+		eventCopy := make(map[string]interface{}, len(event))
+		for k, v := range event {
+			eventCopy[k] = v
+		}
+		return sink.Write(eventCopy)
+	})
+}
+
+// Flush flushes every child sink.
+func (s *FanOutSink) Flush() error {
+	return s.each(Sink.Flush)
+}
+
+// Close closes every child sink.
+func (s *FanOutSink) Close() error {
+	return s.each(Sink.Close)
+}
+
+// each runs op against all children concurrently and waits for them up to a
+// shared deadline. Once the deadline passes, children that have not yet
+// finished are reported as timed out.
+func (s *FanOutSink) each(op func(Sink) error) error {
+	results := make([]chan error, len(s.sinks))
+	for i, sink := range s.sinks {
+		results[i] = make(chan error, 1)
+		go func(sink Sink, out chan<- error) {
+			out <- op(sink)
+		}(sink, results[i])
+	}
+
+	timer := time.NewTimer(s.timeout)
+	defer timer.Stop()
+	expired := false
+
+	var errs []error
+	for i, result := range results {
+		var err error
+		if expired {
+			select {
+			case err = <-result:
+			default:
+				err = fmt.Errorf("%w after %v", ErrSinkTimeout, s.timeout)
+			}
+		} else {
+			select {
+			case err = <-result:
+			case <-timer.C:
+				expired = true
+				err = fmt.Errorf("%w after %v", ErrSinkTimeout, s.timeout)
+			}
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("sink %d (%T): %w", i, s.sinks[i], err))
+		}
+	}
+	return errors.Join(errs...)
+}
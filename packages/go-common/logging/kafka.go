@@ -0,0 +1,183 @@
+package logging
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+const defaultKafkaLinger = time.Second
+
+// KafkaMessage is a single record handed to a KafkaProducer.
+type KafkaMessage struct {
+	Topic string
+	// Key is the partition key; nil lets the producer choose the partition.
+	Key   []byte
+	Value []byte
+}
+
+// KafkaProducer is the subset of a Kafka client used by KafkaSink. Adapters
+// for concrete clients (kafka-go, franz-go, sarama) implement it so go-common
+// does not depend on any one of them.
+type KafkaProducer interface {
+	// Produce synchronously delivers msgs, returning once the broker has
+	// acknowledged them or ctx expires.
+	Produce(ctx context.Context, msgs []KafkaMessage) error
+	// Close flushes any client-side buffers and releases the connection.
+	Close() error
+}
+
+// KafkaConfig holds configuration for the Kafka log sink.
+type KafkaConfig struct {
+	// Producer delivers batches to the cluster. Required.
+	Producer KafkaProducer
+	// Topic is the destination topic for every event. Required.
+	Topic string
+	// KeyField names the event field whose value becomes the partition key
+	// (e.g. "tenant"). Events without the field are sent without a key.
+	KeyField string
+	// BatchSize is the maximum number of events per Produce call. Defaults to 100.
+	BatchSize int
+	// Linger is how long events may wait in the buffer before a background
+	// flush. Defaults to 1s.
+	Linger time.Duration
+	// Timeout bounds each Produce call. Defaults to 10s.
+	Timeout time.Duration
+	// MaxRetries is the number of retry attempts on transient failure. Defaults to 3.
+	MaxRetries int
+}
+
+func (c *KafkaConfig) applyDefaults() {
+	if c.BatchSize <= 0 {
+		c.BatchSize = defaultBatchSize
+	}
+	if c.Linger <= 0 {
+		c.Linger = defaultKafkaLinger
+	}
+	if c.Timeout <= 0 {
+		c.Timeout = defaultTimeout
+	}
+	if c.MaxRetries <= 0 {
+		c.MaxRetries = defaultMaxRetries
+	}
+}
+
+// KafkaSink buffers log events and produces them as JSON messages to a Kafka
+// topic, flushing when a batch fills or the linger interval elapses. Failed
+// batches are retried with the same exponential backoff as KillKrillSink.
+type KafkaSink struct {
+	cfg KafkaConfig
+
+	mu     sync.Mutex
+	buffer []KafkaMessage
+
+	stopCh chan struct{}
+	wg     sync.WaitGroup
+}
+
+// NewKafkaSink creates a KafkaSink and starts a background flush goroutine.
+// Call Close() to stop the goroutine, drain remaining events, and close the producer.
+func NewKafkaSink(cfg KafkaConfig) (*KafkaSink, error) {
+	if cfg.Producer == nil {
+		return nil, errors.New("kafka: producer is required")
+	}
+	if cfg.Topic == "" {
+		return nil, errors.New("kafka: topic is required")
+	}
+	cfg.applyDefaults()
+
+	s := &KafkaSink{
+		cfg:    cfg,
+		buffer: make([]KafkaMessage, 0, cfg.BatchSize),
+		stopCh: make(chan struct{}),
+	}
+
+	s.wg.Add(1)
+	go s.flushLoop()
+
+	return s, nil
+}
+
+// Write encodes the event and appends it to the buffer, flushing immediately
+// if the batch is full.
+func (s *KafkaSink) Write(event map[string]interface{}) error {
+	value, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("kafka: marshal event: %w", err)
+	}
+	msg := KafkaMessage{Topic: s.cfg.Topic, Key: s.partitionKey(event), Value: value}
+
+	s.mu.Lock()
+	s.buffer = append(s.buffer, msg)
+	full := len(s.buffer) >= s.cfg.BatchSize
+	s.mu.Unlock()
+
+	if full {
+		return s.Flush()
+	}
+	return nil
+}
+
+// Flush drains the buffer and produces all pending events.
+func (s *KafkaSink) Flush() error {
+	s.mu.Lock()
+	if len(s.buffer) == 0 {
+		s.mu.Unlock()
+		return nil
+	}
+	batch := s.buffer
+	s.buffer = make([]KafkaMessage, 0, s.cfg.BatchSize)
+	s.mu.Unlock()
+
+	return s.produceWithRetry(batch)
+}
+
+// Close stops the background goroutine, flushes any remaining events, and
+// closes the producer.
+func (s *KafkaSink) Close() error {
+	close(s.stopCh)
+	s.wg.Wait()
+	return errors.Join(s.Flush(), s.cfg.Producer.Close())
+}
+
+func (s *KafkaSink) partitionKey(event map[string]interface{}) []byte {
+	if s.cfg.KeyField == "" {
+		return nil
+	}
+	v, ok := event[s.cfg.KeyField]
+	if !ok || v == nil {
+		return nil
+	}
+	return []byte(fmt.Sprint(v))
+}
+
+func (s *KafkaSink) flushLoop() {
+	defer s.wg.Done()
+
+	ticker := time.NewTicker(s.cfg.Linger)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			_ = s.Flush()
+		case <-s.stopCh:
+			return
+		}
+	}
+}
+
+func (s *KafkaSink) produceWithRetry(batch []KafkaMessage) error {
+	err := retryWithBackoff(context.Background(), s.cfg.MaxRetries, func() error {
+		ctx, cancel := context.WithTimeout(context.Background(), s.cfg.Timeout)
+		defer cancel()
+		return s.cfg.Producer.Produce(ctx, batch)
+	})
+	if err != nil {
+		return fmt.Errorf("kafka: all %d attempts failed, last error: %w", s.cfg.MaxRetries+1, err)
+	}
+	return nil
+}
@@ -2,6 +2,7 @@ package logging
 
 import (
 	"bytes"
+	"compress/gzip"
 	"context"
 	"encoding/json"
 	"fmt"
@@ -16,9 +17,21 @@ const (
 	defaultFlushInterval = 5 * time.Second
 	defaultTimeout       = 10 * time.Second
 	defaultMaxRetries    = 3
+	defaultCloseTimeout  = 30 * time.Second
 	eventsPath           = "/api/v1/events"
 )
 
+// KillKrillFormat selects how a KillKrillSink encodes each batch.
+type KillKrillFormat string
+
+const (
+	// KillKrillFormatArray sends the batch as a JSON array (application/json).
+	KillKrillFormatArray KillKrillFormat = "array"
+	// KillKrillFormatNDJSON sends one JSON event per line
+	// (application/x-ndjson), which the ingest endpoint can parse as a stream.
+	KillKrillFormatNDJSON KillKrillFormat = "ndjson"
+)
+
 // KillKrillConfig holds configuration for the KillKrill log sink.
 type KillKrillConfig struct {
 	// Endpoint is the base URL of the KillKrill service (e.g. "https://logs.example.com").
@@ -35,6 +48,13 @@ type KillKrillConfig struct {
 	Timeout time.Duration
 	// MaxRetries is the number of retry attempts on transient failure. Defaults to 3.
 	MaxRetries int
+	// Format is the request body encoding. Defaults to KillKrillFormatArray.
+	Format KillKrillFormat
+	// Compress gzip-encodes each batch body and sets Content-Encoding: gzip.
+	Compress bool
+	// CloseTimeout bounds Close, including its final flush and retries.
+	// Defaults to 30s.
+	CloseTimeout time.Duration
 }
 
 func (c *KillKrillConfig) applyDefaults() {
@@ -50,6 +70,12 @@ func (c *KillKrillConfig) applyDefaults() {
 	if c.MaxRetries <= 0 {
 		c.MaxRetries = defaultMaxRetries
 	}
+	if c.Format == "" {
+		c.Format = KillKrillFormatArray
+	}
+	if c.CloseTimeout <= 0 {
+		c.CloseTimeout = defaultCloseTimeout
+	}
 }
 
 // KillKrillSink buffers log events and periodically flushes them to the
@@ -61,6 +87,10 @@ type KillKrillSink struct {
 	mu     sync.Mutex
 	buffer []map[string]interface{}
 
+	// ctx scopes every send; it is cancelled when Close returns, aborting a
+	// flush still in flight from the background goroutine.
+	ctx    context.Context
+	cancel context.CancelFunc
 	stopCh chan struct{}
 	wg     sync.WaitGroup
 }
@@ -70,10 +100,13 @@ type KillKrillSink struct {
 func NewKillKrillSink(cfg KillKrillConfig) *KillKrillSink {
 	cfg.applyDefaults()
 
+	ctx, cancel := context.WithCancel(context.Background())
 	s := &KillKrillSink{
 		cfg:    cfg,
 		client: &http.Client{Timeout: cfg.Timeout},
 		buffer: make([]map[string]interface{}, 0, cfg.BatchSize),
+		ctx:    ctx,
+		cancel: cancel,
 		stopCh: make(chan struct{}),
 	}
 
@@ -107,14 +140,48 @@ func (s *KillKrillSink) Flush() error {
 	s.buffer = make([]map[string]interface{}, 0, s.cfg.BatchSize)
 	s.mu.Unlock()
 
-	return s.sendWithRetry(batch)
+	return s.sendWithRetry(s.ctx, batch)
 }
 
-// Close stops the background goroutine and flushes any remaining events.
+// Close stops the background goroutine and flushes any remaining events,
+// giving up after CloseTimeout. See CloseWithTimeout.
 func (s *KillKrillSink) Close() error {
+	return s.CloseWithTimeout(s.cfg.CloseTimeout)
+}
+
+// CloseWithTimeout stops the background goroutine and flushes any remaining
+// events, giving up after d so a hung endpoint cannot stall shutdown. On
+// timeout, in-flight requests are aborted, undelivered events are discarded,
+// and the returned error wraps context.DeadlineExceeded.
+func (s *KillKrillSink) CloseWithTimeout(d time.Duration) error {
+	ctx, cancel := context.WithTimeout(s.ctx, d)
+	defer cancel()
+	defer s.cancel()
+
 	close(s.stopCh)
-	s.wg.Wait()
-	return s.Flush()
+	done := make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-ctx.Done():
+	}
+
+	s.mu.Lock()
+	batch := s.buffer
+	s.buffer = nil
+	s.mu.Unlock()
+
+	var err error
+	if len(batch) > 0 && ctx.Err() == nil {
+		err = s.sendWithRetry(ctx, batch)
+	}
+	if ctx.Err() != nil {
+		return fmt.Errorf("killkrill: close timed out after %s, %d buffered events discarded: %w", d, len(batch), ctx.Err())
+	}
+	return err
 }
 
 func (s *KillKrillSink) flushLoop() {
@@ -133,38 +200,102 @@ func (s *KillKrillSink) flushLoop() {
 	}
 }
 
-func (s *KillKrillSink) sendWithRetry(batch []map[string]interface{}) error {
+func (s *KillKrillSink) sendWithRetry(ctx context.Context, batch []map[string]interface{}) error {
+	payload, contentType, err := s.encode(batch)
+	if err != nil {
+		return err
+	}
+	// Compress once; every retry resends the same buffer.
+	contentEncoding := ""
+	if s.cfg.Compress {
+		if payload, err = gzipBytes(payload); err != nil {
+			return fmt.Errorf("killkrill: compress batch: %w", err)
+		}
+		contentEncoding = "gzip"
+	}
+	if err := retryWithBackoff(ctx, s.cfg.MaxRetries, func() error { return s.send(ctx, payload, contentType, contentEncoding) }); err != nil {
+		return fmt.Errorf("killkrill: all %d attempts failed, last error: %w", s.cfg.MaxRetries+1, err)
+	}
+	return nil
+}
+
+// encode serializes batch in the configured Format and returns the body with
+// its Content-Type.
+func (s *KillKrillSink) encode(batch []map[string]interface{}) ([]byte, string, error) {
+	switch s.cfg.Format {
+	case KillKrillFormatArray:
+		payload, err := json.Marshal(batch)
+		if err != nil {
+			return nil, "", fmt.Errorf("killkrill: marshal batch: %w", err)
+		}
+		return payload, "application/json", nil
+	case KillKrillFormatNDJSON:
+		var buf bytes.Buffer
+		enc := json.NewEncoder(&buf)
+		for _, event := range batch {
+			// Encode terminates each event with a newline.
+			if err := enc.Encode(event); err != nil {
+				return nil, "", fmt.Errorf("killkrill: marshal event: %w", err)
+			}
+		}
+		return buf.Bytes(), "application/x-ndjson", nil
+	default:
+		return nil, "", fmt.Errorf("killkrill: unknown format %q", s.cfg.Format)
+	}
+}
+
+// gzipBytes returns data gzip-compressed.
+func gzipBytes(data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write(data); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// retryWithBackoff calls fn up to maxRetries+1 times, sleeping 100ms, 200ms,
+// 400ms, ... between attempts. It returns nil on the first success, otherwise
+// the error from the final attempt. It stops early once ctx is done.
+func retryWithBackoff(ctx context.Context, maxRetries int, fn func() error) error {
 	var lastErr error
 
-	for attempt := 0; attempt <= s.cfg.MaxRetries; attempt++ {
+	for attempt := 0; attempt <= maxRetries; attempt++ {
 		if attempt > 0 {
 			backoff := time.Duration(math.Pow(2, float64(attempt-1))) * 100 * time.Millisecond
-			time.Sleep(backoff)
+			timer := time.NewTimer(backoff)
+			select {
+			case <-timer.C:
+			case <-ctx.Done():
+				timer.Stop()
+				return lastErr
+			}
 		}
 
-		if err := s.send(batch); err != nil {
+		if err := fn(); err != nil {
 			lastErr = err
 			continue
 		}
 		return nil
 	}
 
-	return fmt.Errorf("killkrill: all %d attempts failed, last error: %w", s.cfg.MaxRetries+1, lastErr)
+	return lastErr
 }
 
-func (s *KillKrillSink) send(batch []map[string]interface{}) error {
-	payload, err := json.Marshal(batch)
-	if err != nil {
-		return fmt.Errorf("killkrill: marshal batch: %w", err)
-	}
-
+func (s *KillKrillSink) send(ctx context.Context, payload []byte, contentType, contentEncoding string) error {
 	url := s.cfg.Endpoint + eventsPath
-	req, err := http.NewRequestWithContext(context.Background(), http.MethodPost, url, bytes.NewReader(payload))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(payload))
 	if err != nil {
 		return fmt.Errorf("killkrill: build request: %w", err)
 	}
 
-	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Content-Type", contentType)
+	if contentEncoding != "" {
+		req.Header.Set("Content-Encoding", contentEncoding)
+	}
 	req.Header.Set("Authorization", "Bearer "+s.cfg.APIKey)
 
 	resp, err := s.client.Do(req)
@@ -21,13 +21,20 @@ type LoggerConfig struct {
 	// JSON controls whether the zap encoder uses JSON format (true) or console format (false).
 	// Sinks always receive JSON-encoded events regardless of this setting.
 	JSON bool
+	// SensitiveKeys lists keys redacted by this logger in addition to the
+	// package defaults. Other loggers are unaffected.
+	SensitiveKeys []string
 }
 
 // NewLogger builds a SanitizedLogger whose output is dispatched to all configured sinks.
 // When no sinks are provided, it falls back to NewSanitizedLogger for default stdout output.
 func NewLogger(cfg LoggerConfig) (*SanitizedLogger, error) {
 	if len(cfg.Sinks) == 0 {
-		return NewSanitizedLogger(cfg.Name)
+		logger, err := NewSanitizedLogger(cfg.Name)
+		if err != nil {
+			return nil, err
+		}
+		return logger.WithSensitiveKeys(cfg.SensitiveKeys...), nil
 	}
 
 	level, err := parseLevel(cfg.Level)
@@ -51,8 +58,9 @@ func NewLogger(cfg LoggerConfig) (*SanitizedLogger, error) {
 	zapLogger := zap.New(core).Named(cfg.Name)
 
 	return &SanitizedLogger{
-		logger: zapLogger,
-		name:   cfg.Name,
+		logger:    zapLogger,
+		name:      cfg.Name,
+		sanitizer: NewSanitizerConfig(cfg.SensitiveKeys...),
 	}, nil
 }
 
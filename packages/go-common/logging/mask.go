@@ -0,0 +1,114 @@
+package logging
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+// MaskStrategy selects how a sensitive value is rewritten before it is logged.
+type MaskStrategy string
+
+const (
+	// MaskFull replaces the value with "[REDACTED]". It is the default.
+	MaskFull MaskStrategy = "full"
+	// MaskPartial keeps the last few characters, as in "****1111", so values
+	// such as card numbers can still be told apart. See WithPartialMaskLength.
+	MaskPartial MaskStrategy = "partial"
+	// MaskHash replaces the value with a keyed HMAC-SHA256 pseudonym, as in
+	// "hmac:3f2a...", so equal values can be correlated across log lines without
+	// being revealed. It requires WithHashKey and falls back to MaskFull without
+	// one, since an unkeyed hash of a short secret can be brute-forced.
+	MaskHash MaskStrategy = "hash"
+)
+
+// DefaultPartialMaskLength is the number of trailing characters MaskPartial
+// keeps unless WithPartialMaskLength says otherwise.
+const DefaultPartialMaskLength = 4
+
+// hashPseudonymBytes is how much of the HMAC is kept in a MaskHash pseudonym;
+// 128 bits is ample to keep distinct values apart in logs.
+const hashPseudonymBytes = 16
+
+// WithMaskStrategies returns a copy of c that masks the listed keys with the
+// given strategies. Keys are matched like sensitive keys, case-insensitively
+// and by substring, with the longest matching key winning, and are added to
+// the sensitive keys. Sensitive keys without a strategy use MaskFull.
+func (c *SanitizerConfig) WithMaskStrategies(strategies map[string]MaskStrategy) *SanitizerConfig {
+	next := c.clone()
+	next.strategies = make(map[string]MaskStrategy, len(c.strategies)+len(strategies))
+	for k, v := range c.strategies {
+		next.strategies[k] = v
+	}
+	for k, v := range strategies {
+		k = strings.ToLower(k)
+		next.strategies[k] = v
+		next.sensitiveKeys[k] = true
+	}
+	return next
+}
+
+// WithPartialMaskLength returns a copy of c whose MaskPartial keeps the last n
+// characters. Values of n characters or fewer are fully redacted.
+func (c *SanitizerConfig) WithPartialMaskLength(n int) *SanitizerConfig {
+	next := c.clone()
+	next.partialKeep = n
+	return next
+}
+
+// WithHashKey returns a copy of c whose MaskHash pseudonyms are keyed with key.
+// Use a secret of at least 32 random bytes and keep it stable for as long as
+// pseudonyms need to correlate.
+func (c *SanitizerConfig) WithHashKey(key []byte) *SanitizerConfig {
+	next := c.clone()
+	next.hashKey = append([]byte(nil), key...)
+	return next
+}
+
+// strategyFor returns the strategy configured for key, preferring an exact
+// match and then the longest configured key contained in it.
+func (c *SanitizerConfig) strategyFor(key string) MaskStrategy {
+	if len(c.strategies) == 0 {
+		return MaskFull
+	}
+	keyLower := strings.ToLower(key)
+	if s, ok := c.strategies[keyLower]; ok {
+		return s
+	}
+	strategy, matched := MaskFull, ""
+	for k, s := range c.strategies {
+		if len(k) > len(matched) && strings.Contains(keyLower, k) {
+			strategy, matched = s, k
+		}
+	}
+	return strategy
+}
+
+// mask rewrites the value of a sensitive key according to its strategy.
+func (c *SanitizerConfig) mask(key string, value interface{}) string {
+	switch c.strategyFor(key) {
+	case MaskPartial:
+		return maskPartial(fmt.Sprint(value), c.partialKeep)
+	case MaskHash:
+		if len(c.hashKey) == 0 {
+			return "[REDACTED]"
+		}
+		mac := hmac.New(sha256.New, c.hashKey)
+		mac.Write([]byte(fmt.Sprint(value)))
+		return "hmac:" + hex.EncodeToString(mac.Sum(nil)[:hashPseudonymBytes])
+	default:
+		return "[REDACTED]"
+	}
+}
+
+// maskPartial keeps the last keep runes of s behind a fixed "****" prefix, so
+// the output does not reveal the value's length.
+func maskPartial(s string, keep int) string {
+	runes := []rune(s)
+	if keep <= 0 || len(runes) <= keep {
+		return "[REDACTED]"
+	}
+	return "****" + string(runes[len(runes)-keep:])
+}
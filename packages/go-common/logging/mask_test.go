@@ -0,0 +1,123 @@
+package logging
+
+import (
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+// TestSanitizerConfig_MaskStrategies tests each strategy applied to the same value via different keys
+func TestSanitizerConfig_MaskStrategies(t *testing.T) {
+	cfg := NewSanitizerConfig().
+		WithHashKey([]byte("0123456789abcdef0123456789abcdef")).
+		WithMaskStrategies(map[string]MaskStrategy{
+			"card_number": MaskPartial,
+			"user_ssn":    MaskHash,
+			"pin":         MaskFull,
+		})
+	const value = "4111111111111111"
+
+	if got := cfg.SanitizeValue("card_number", value); got != "****1111" {
+		t.Errorf("partial = %v, want ****1111", got)
+	}
+	if got := cfg.SanitizeValue("pin", value); got != "[REDACTED]" {
+		t.Errorf("full = %v, want [REDACTED]", got)
+	}
+	hashed, _ := cfg.SanitizeValue("user_ssn", value).(string)
+	if !strings.HasPrefix(hashed, "hmac:") || len(hashed) != len("hmac:")+2*hashPseudonymBytes {
+		t.Errorf("hash = %q, want hmac: followed by %d hex characters", hashed, 2*hashPseudonymBytes)
+	}
+	if strings.Contains(hashed, "1111") {
+		t.Errorf("hash %q leaks part of the value", hashed)
+	}
+	if got := cfg.SanitizeValue("user_ssn", value); got != hashed {
+		t.Errorf("hash is not stable: %v then %v", hashed, got)
+	}
+	if got := cfg.SanitizeValue("user_ssn", "4111111111111112"); got == hashed {
+		t.Error("different values produced the same pseudonym")
+	}
+	if got := cfg.SanitizeValue("password", value); got != "[REDACTED]" {
+		t.Errorf("default key without strategy = %v, want [REDACTED]", got)
+	}
+}
+
+// TestSanitizerConfig_MaskHashKeyed tests that pseudonyms depend on the hash key
+func TestSanitizerConfig_MaskHashKeyed(t *testing.T) {
+	strategies := map[string]MaskStrategy{"user_ssn": MaskHash}
+	a := NewSanitizerConfig().WithMaskStrategies(strategies).WithHashKey([]byte("key-a"))
+	b := NewSanitizerConfig().WithMaskStrategies(strategies).WithHashKey([]byte("key-b"))
+
+	if a.SanitizeValue("user_ssn", "123-45-6789") == b.SanitizeValue("user_ssn", "123-45-6789") {
+		t.Error("expected different keys to produce different pseudonyms")
+	}
+	unkeyed := NewSanitizerConfig().WithMaskStrategies(strategies)
+	if got := unkeyed.SanitizeValue("user_ssn", "123-45-6789"); got != "[REDACTED]" {
+		t.Errorf("hash without key = %v, want [REDACTED]", got)
+	}
+}
+
+// TestSanitizerConfig_MaskPartialLength tests the configurable trailing length and short values
+func TestSanitizerConfig_MaskPartialLength(t *testing.T) {
+	cfg := NewSanitizerConfig().WithMaskStrategies(map[string]MaskStrategy{"account": MaskPartial})
+
+	if got := cfg.WithPartialMaskLength(2).SanitizeValue("account", "987654"); got != "****54" {
+		t.Errorf("keep 2 = %v, want ****54", got)
+	}
+	if got := cfg.SanitizeValue("account", "1234"); got != "[REDACTED]" {
+		t.Errorf("value no longer than kept suffix = %v, want [REDACTED]", got)
+	}
+	if got := cfg.SanitizeValue("account", 12345678); got != "****5678" {
+		t.Errorf("non-string value = %v, want ****5678", got)
+	}
+}
+
+// TestSanitizerConfig_MaskStrategyMatching tests case-insensitive, substring and longest-key matching
+func TestSanitizerConfig_MaskStrategyMatching(t *testing.T) {
+	cfg := NewSanitizerConfig().WithMaskStrategies(map[string]MaskStrategy{
+		"card":        MaskFull,
+		"card_number": MaskPartial,
+	})
+
+	if got := cfg.SanitizeValue("Billing_Card_Number", "4111111111111111"); got != "****1111" {
+		t.Errorf("longest match = %v, want ****1111", got)
+	}
+	if got := cfg.SanitizeValue("card_holder", "A. Person"); got != "[REDACTED]" {
+		t.Errorf("shorter match = %v, want [REDACTED]", got)
+	}
+	if !cfg.IsSensitiveKey("card_number") {
+		t.Error("expected keys with a strategy to be sensitive")
+	}
+}
+
+// TestSanitizerConfig_WithMaskStrategiesDoesNotMutateParent tests copy-on-extend semantics
+func TestSanitizerConfig_WithMaskStrategiesDoesNotMutateParent(t *testing.T) {
+	base := NewSanitizerConfig("card_number")
+	partial := base.WithMaskStrategies(map[string]MaskStrategy{"card_number": MaskPartial})
+
+	if got := base.SanitizeValue("card_number", "4111111111111111"); got != "[REDACTED]" {
+		t.Errorf("base = %v, want [REDACTED]", got)
+	}
+	if got := partial.SanitizeValue("card_number", "4111111111111111"); got != "****1111" {
+		t.Errorf("extended = %v, want ****1111", got)
+	}
+}
+
+// TestSanitizedLogger_WithSanitizer tests that a logger applies the mask strategies of its config
+func TestSanitizedLogger_WithSanitizer(t *testing.T) {
+	core, logs := observer.New(zap.InfoLevel)
+	base := &SanitizedLogger{logger: zap.New(core), sanitizer: NewSanitizerConfig()}
+	logger := base.WithSanitizer(NewSanitizerConfig().WithMaskStrategies(map[string]MaskStrategy{"card_number": MaskPartial}))
+
+	logger.Info("charge", zap.String("card_number", "4111111111111111"))
+	base.Info("charge", zap.String("card_number", "4111111111111111"))
+
+	entries := logs.All()
+	if got := entries[0].ContextMap()["card_number"]; got != "****1111" {
+		t.Errorf("card_number = %v, want ****1111", got)
+	}
+	if got := entries[1].ContextMap()["card_number"]; got != "4111111111111111" {
+		t.Errorf("receiver was affected: card_number = %v", got)
+	}
+}
@@ -0,0 +1,109 @@
+package logging
+
+import (
+	"fmt"
+	"math"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// RateLimitConfig controls the token bucket used by RateLimitedSink.
+type RateLimitConfig struct {
+	// EventsPerSecond is the sustained rate at which events are forwarded. Required.
+	EventsPerSecond float64
+	// Burst is the bucket capacity: how many events may pass back-to-back
+	// before the rate applies. Defaults to EventsPerSecond rounded up, minimum 1.
+	Burst int
+	// Summarize emits a single "N events suppressed" event ahead of the next
+	// allowed event whenever events were dropped since the last one passed.
+	Summarize bool
+}
+
+// RateLimitedSink wraps another Sink and forwards at most a configured number
+// of events per second, dropping the excess. Flush and Close pass through.
+type RateLimitedSink struct {
+	next Sink
+	cfg  RateLimitConfig
+	now  func() time.Time
+
+	mu         sync.Mutex
+	tokens     float64
+	last       time.Time
+	suppressed uint64
+
+	dropped atomic.Uint64
+}
+
+// NewRateLimitedSink returns a RateLimitedSink that forwards to next.
+func NewRateLimitedSink(next Sink, cfg RateLimitConfig) (*RateLimitedSink, error) {
+	if cfg.EventsPerSecond <= 0 {
+		return nil, fmt.Errorf("rate limit: events per second must be positive, got %v", cfg.EventsPerSecond)
+	}
+	if cfg.Burst <= 0 {
+		cfg.Burst = int(math.Max(1, math.Ceil(cfg.EventsPerSecond)))
+	}
+
+	s := &RateLimitedSink{
+		next:   next,
+		cfg:    cfg,
+		now:    time.Now,
+		tokens: float64(cfg.Burst),
+	}
+	s.last = s.now()
+	return s, nil
+}
+
+// Write forwards the event if a token is available and drops it otherwise.
+// Dropped events are counted, not reported as errors.
+func (s *RateLimitedSink) Write(event map[string]interface{}) error {
+	s.mu.Lock()
+	if !s.take() {
+		s.suppressed++
+		s.mu.Unlock()
+		s.dropped.Add(1)
+		return nil
+	}
+	suppressed := s.suppressed
+	s.suppressed = 0
+	s.mu.Unlock()
+
+	if s.cfg.Summarize && suppressed > 0 {
+		summary := map[string]interface{}{
+			"level":      "warn",
+			"msg":        fmt.Sprintf("%d log events suppressed by rate limit", suppressed),
+			"suppressed": suppressed,
+		}
+		if err := s.next.Write(summary); err != nil {
+			return err
+		}
+	}
+	return s.next.Write(event)
+}
+
+// Dropped returns the total number of events dropped since the sink was created.
+func (s *RateLimitedSink) Dropped() uint64 {
+	return s.dropped.Load()
+}
+
+// Flush flushes the wrapped sink.
+func (s *RateLimitedSink) Flush() error { return s.next.Flush() }
+
+// Close closes the wrapped sink.
+func (s *RateLimitedSink) Close() error { return s.next.Close() }
+
+// take refills the bucket for the time elapsed since the last call and
+// consumes one token if available. Callers must hold s.mu.
+func (s *RateLimitedSink) take() bool {
+	now := s.now()
+	elapsed := now.Sub(s.last).Seconds()
+	s.last = now
+	if elapsed > 0 {
+		s.tokens = math.Min(float64(s.cfg.Burst), s.tokens+elapsed*s.cfg.EventsPerSecond)
+	}
+	if s.tokens < 1 {
+		return false
+	}
+	s.tokens--
+	return true
+}
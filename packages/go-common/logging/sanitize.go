@@ -12,7 +12,9 @@ import (
 	"go.uber.org/zap/zapcore"
 )
 
-// SensitiveKeys contains keys that should always be redacted in logs.
+// SensitiveKeys contains the default keys redacted in logs. The package-level
+// Sanitize functions read it directly; each SanitizedLogger takes a snapshot
+// when it is created, so per-logger keys belong in SanitizerConfig instead.
 var SensitiveKeys = map[string]bool{
 	"password":      true,
 	"passwd":        true,
@@ -38,24 +40,191 @@ var SensitiveKeys = map[string]bool{
 
 var emailRegex = regexp.MustCompile(`[a-zA-Z0-9._%+-]+@[a-zA-Z0-9.-]+\.[a-zA-Z]{2,}`)
 
-// SanitizeValue redacts sensitive values based on the key name.
+// bearerRegex matches an HTTP Bearer credential; the scheme is kept and the
+// token replaced.
+var bearerRegex = regexp.MustCompile(`(?i)\b(bearer)\s+[A-Za-z0-9\-._~+/]+=*`)
+
+// jwtRegex matches compact JWS tokens: a base64url JSON header (always
+// starting "eyJ") and payload, plus a signature. The minimum segment lengths
+// keep ordinary dotted identifiers like "com.example.service" from matching.
+var jwtRegex = regexp.MustCompile(`\beyJ[A-Za-z0-9_-]{7,}\.[A-Za-z0-9_-]{10,}\.[A-Za-z0-9_-]{16,}`)
+
+// redactTokens replaces bearer credentials and JWTs embedded in free text,
+// leaving the surrounding text intact.
+func redactTokens(s string) string {
+	if !strings.Contains(s, "eyJ") && !strings.Contains(strings.ToLower(s), "bearer") {
+		return s
+	}
+	s = bearerRegex.ReplaceAllString(s, "${1} [REDACTED]")
+	return jwtRegex.ReplaceAllString(s, "[REDACTED]")
+}
+
+// urlCredentialRegex matches the password in URL userinfo, as in a DSN like
+// postgres://app:secret@db/prod; the scheme and user are kept.
+var urlCredentialRegex = regexp.MustCompile(`([A-Za-z][A-Za-z0-9+.-]*://[^:/@\s]*):[^@\s]+@`)
+
+// keyValueRegex matches key=value and key: value pairs in free text, with the
+// value optionally quoted.
+var keyValueRegex = regexp.MustCompile(`\b([A-Za-z][A-Za-z0-9_.-]*)"?(\s*[=:]\s*)("[^"]*"|'[^']*'|[^\s&;,"']+)`)
+
+// redactCredentials replaces URL userinfo passwords and the values of
+// key=value pairs whose key is sensitive, leaving the surrounding text intact.
+func redactCredentials(keys map[string]bool, s string) string {
+	if !strings.ContainsAny(s, "=:") {
+		return s
+	}
+	s = urlCredentialRegex.ReplaceAllString(s, "${1}:[REDACTED]@")
+
+	// Matches are found one at a time rather than with FindAll: when a pair's
+	// key is not sensitive, scanning resumes at its value, which may itself be
+	// the key of the next pair (as in "config: client_secret: x").
+	var b strings.Builder
+	last, pos := 0, 0
+	for pos < len(s) {
+		m := keyValueRegex.FindStringSubmatchIndex(s[pos:])
+		if m == nil {
+			break
+		}
+		keyStart, keyEnd := pos+m[2], pos+m[3]
+		valStart, valEnd := pos+m[6], pos+m[7]
+		if !isSensitiveKey(keys, s[keyStart:keyEnd]) {
+			pos = valStart
+			continue
+		}
+		b.WriteString(s[last:valStart])
+		b.WriteString("[REDACTED]")
+		last, pos = valEnd, valEnd
+	}
+	if last == 0 {
+		return s
+	}
+	b.WriteString(s[last:])
+	return b.String()
+}
+
+// SanitizerConfig is an immutable set of redaction rules. Each SanitizedLogger
+// owns one, so keys added for one subsystem do not affect other loggers.
+type SanitizerConfig struct {
+	sensitiveKeys map[string]bool
+	strategies    map[string]MaskStrategy
+	partialKeep   int
+	hashKey       []byte
+}
+
+// NewSanitizerConfig returns a config seeded from a snapshot of SensitiveKeys
+// plus any extra keys. Later changes to SensitiveKeys do not affect it.
+func NewSanitizerConfig(extraKeys ...string) *SanitizerConfig {
+	keys := make(map[string]bool, len(SensitiveKeys)+len(extraKeys))
+	for k, v := range SensitiveKeys {
+		keys[k] = v
+	}
+	for _, k := range extraKeys {
+		keys[strings.ToLower(k)] = true
+	}
+	return &SanitizerConfig{sensitiveKeys: keys, partialKeep: DefaultPartialMaskLength}
+}
+
+// WithSensitiveKeys returns a copy of c that also redacts keys.
+func (c *SanitizerConfig) WithSensitiveKeys(keys ...string) *SanitizerConfig {
+	next := c.clone()
+	for _, k := range keys {
+		next.sensitiveKeys[strings.ToLower(k)] = true
+	}
+	return next
+}
+
+// clone returns a copy of c with its own sensitive key set. The strategies map
+// and hash key are never mutated after construction and are shared.
+func (c *SanitizerConfig) clone() *SanitizerConfig {
+	next := *c
+	next.sensitiveKeys = make(map[string]bool, len(c.sensitiveKeys))
+	for k, v := range c.sensitiveKeys {
+		next.sensitiveKeys[k] = v
+	}
+	return &next
+}
+
+// IsSensitiveKey reports whether values logged under key are redacted.
+func (c *SanitizerConfig) IsSensitiveKey(key string) bool {
+	return isSensitiveKey(c.sensitiveKeys, key)
+}
+
+// SanitizeValue is the config-aware form of the package-level SanitizeValue.
+// Values of sensitive keys are masked with the key's MaskStrategy.
+func (c *SanitizerConfig) SanitizeValue(key string, value interface{}) interface{} {
+	if isSensitiveKey(c.sensitiveKeys, key) {
+		return c.mask(key, value)
+	}
+	return sanitizeValue(c.sensitiveKeys, key, value)
+}
+
+// SanitizeField is the config-aware form of the package-level SanitizeField.
+func (c *SanitizerConfig) SanitizeField(field zap.Field) zap.Field {
+	return sanitizeField(field, c.SanitizeValue)
+}
+
+// SanitizeFields is the config-aware form of the package-level SanitizeFields.
+func (c *SanitizerConfig) SanitizeFields(fields []zap.Field) []zap.Field {
+	return sanitizeFields(fields, c.SanitizeValue)
+}
+
+// SanitizedError is the config-aware form of the package-level SanitizedError.
+func (c *SanitizerConfig) SanitizedError(err error) zap.Field {
+	return sanitizedError(err, c.SanitizeValue)
+}
+
+// SanitizeValue redacts sensitive values based on the key name, and masks
+// tokens and email addresses found in string values. It uses the package-level
+// SensitiveKeys.
 func SanitizeValue(key string, value interface{}) interface{} {
+	return sanitizeValue(SensitiveKeys, key, value)
+}
+
+// SanitizeFields sanitizes a slice of zap fields for safe logging.
+func SanitizeFields(fields []zap.Field) []zap.Field {
+	return sanitizeFields(fields, SanitizeValue)
+}
+
+// SanitizeField sanitizes a single zap field.
+func SanitizeField(field zap.Field) zap.Field {
+	return sanitizeField(field, SanitizeValue)
+}
+
+// SanitizedError is zap.Error with the error's message sanitized like a
+// string value, so credentials embedded in it (for example a DSN password in
+// a connection error) are redacted. A nil err yields a no-op field.
+func SanitizedError(err error) zap.Field {
+	return sanitizedError(err, SanitizeValue)
+}
+
+func isSensitiveKey(keys map[string]bool, key string) bool {
 	keyLower := strings.ToLower(key)
 
 	// Check if key is sensitive
-	if SensitiveKeys[keyLower] {
-		return "[REDACTED]"
+	if keys[keyLower] {
+		return true
 	}
 
 	// Check if key contains sensitive substring
-	for sensitiveKey := range SensitiveKeys {
+	for sensitiveKey := range keys {
 		if strings.Contains(keyLower, sensitiveKey) {
-			return "[REDACTED]"
+			return true
 		}
 	}
+	return false
+}
+
+func sanitizeValue(keys map[string]bool, key string, value interface{}) interface{} {
+	if isSensitiveKey(keys, key) {
+		return "[REDACTED]"
+	}
 
-	// Check for email addresses
 	if strVal, ok := value.(string); ok {
+		// Check for tokens and credentials embedded in the value
+		strVal = redactTokens(strVal)
+		strVal = redactCredentials(keys, strVal)
+
+		// Check for email addresses
 		if strings.Contains(strVal, "@") && emailRegex.MatchString(strVal) {
 			parts := strings.Split(strVal, "@")
 			if len(parts) == 2 {
@@ -63,38 +232,54 @@ func SanitizeValue(key string, value interface{}) interface{} {
 			}
 			return "[REDACTED_EMAIL]"
 		}
+		return strVal
 	}
 
 	return value
 }
 
-// SanitizeFields sanitizes a slice of zap fields for safe logging.
-func SanitizeFields(fields []zap.Field) []zap.Field {
+func sanitizeFields(fields []zap.Field, valueFn func(string, interface{}) interface{}) []zap.Field {
 	sanitized := make([]zap.Field, len(fields))
 	for i, field := range fields {
-		sanitized[i] = SanitizeField(field)
+		sanitized[i] = sanitizeField(field, valueFn)
 	}
 	return sanitized
 }
 
-// SanitizeField sanitizes a single zap field.
-func SanitizeField(field zap.Field) zap.Field {
+func sanitizeField(field zap.Field, valueFn func(string, interface{}) interface{}) zap.Field {
 	switch field.Type {
 	case zapcore.StringType:
-		sanitizedValue := SanitizeValue(field.Key, field.String)
+		sanitizedValue := valueFn(field.Key, field.String)
 		if sanitizedValue != field.String {
 			return zap.String(field.Key, sanitizedValue.(string))
 		}
+	case zapcore.ErrorType:
+		// zap.Error and zap.NamedError: scan the rendered message.
+		if err, ok := field.Interface.(error); ok {
+			msg := err.Error()
+			if sanitized := valueFn(field.Key, msg); sanitized != msg {
+				return zap.String(field.Key, sanitized.(string))
+			}
+		}
 	default:
-		// Non-string field types are passed through unsanitized
+		// Other field types are passed through unsanitized
 	}
 	return field
 }
 
-// SanitizedLogger wraps a zap logger with automatic sanitization.
+func sanitizedError(err error, valueFn func(string, interface{}) interface{}) zap.Field {
+	if err == nil {
+		return zap.Skip()
+	}
+	return zap.String("error", valueFn("error", err.Error()).(string))
+}
+
+// SanitizedLogger wraps a zap logger with automatic sanitization. Field values
+// are sanitized by key and content; messages have embedded tokens redacted.
 type SanitizedLogger struct {
-	logger *zap.Logger
-	name   string
+	logger    *zap.Logger
+	name      string
+	sanitizer *SanitizerConfig
 }
 
 // NewSanitizedLogger creates a new sanitized logger.
@@ -109,29 +294,51 @@ func NewSanitizedLogger(name string) (*SanitizedLogger, error) {
 	}
 
 	return &SanitizedLogger{
-		logger: logger.Named(name),
-		name:   name,
+		logger:    logger.Named(name),
+		name:      name,
+		sanitizer: NewSanitizerConfig(),
 	}, nil
 }
 
 // Debug logs a debug message with sanitized fields.
 func (l *SanitizedLogger) Debug(msg string, fields ...zap.Field) {
-	l.logger.Debug(msg, SanitizeFields(fields)...)
+	l.logger.Debug(redactTokens(msg), l.sanitizer.SanitizeFields(fields)...)
 }
 
 // Info logs an info message with sanitized fields.
 func (l *SanitizedLogger) Info(msg string, fields ...zap.Field) {
-	l.logger.Info(msg, SanitizeFields(fields)...)
+	l.logger.Info(redactTokens(msg), l.sanitizer.SanitizeFields(fields)...)
 }
 
 // Warn logs a warning message with sanitized fields.
 func (l *SanitizedLogger) Warn(msg string, fields ...zap.Field) {
-	l.logger.Warn(msg, SanitizeFields(fields)...)
+	l.logger.Warn(redactTokens(msg), l.sanitizer.SanitizeFields(fields)...)
 }
 
 // Error logs an error message with sanitized fields.
 func (l *SanitizedLogger) Error(msg string, fields ...zap.Field) {
-	l.logger.Error(msg, SanitizeFields(fields)...)
+	l.logger.Error(redactTokens(msg), l.sanitizer.SanitizeFields(fields)...)
+}
+
+// WithSensitiveKeys returns a logger that additionally redacts keys. The
+// receiver and any other loggers are unaffected.
+func (l *SanitizedLogger) WithSensitiveKeys(keys ...string) *SanitizedLogger {
+	return &SanitizedLogger{
+		logger:    l.logger,
+		name:      l.name,
+		sanitizer: l.sanitizer.WithSensitiveKeys(keys...),
+	}
+}
+
+// WithSanitizer returns a logger that sanitizes fields with c instead of its
+// current config, for example one built with WithMaskStrategies. The receiver
+// is unaffected.
+func (l *SanitizedLogger) WithSanitizer(c *SanitizerConfig) *SanitizedLogger {
+	return &SanitizedLogger{
+		logger:    l.logger,
+		name:      l.name,
+		sanitizer: c,
+	}
 }
 
 // Sync flushes any buffered log entries.
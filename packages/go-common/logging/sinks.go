@@ -3,6 +3,7 @@ package logging
 import (
 	"encoding/json"
 	"fmt"
+	"io"
 	"net"
 	"os"
 	"sync"
@@ -41,6 +42,50 @@ func (s *StdoutSink) Flush() error { return nil }
 // Close is a no-op for StdoutSink; the process owns stdout.
 func (s *StdoutSink) Close() error { return nil }
 
+// WriterSink writes newline-delimited JSON log events to an arbitrary io.Writer.
+// It is the general form of StdoutSink, useful for capturing logs in tests or
+// routing them into an embedder's own stream.
+type WriterSink struct {
+	mu      sync.Mutex
+	w       io.Writer
+	encoder *json.Encoder
+}
+
+// NewWriterSink creates a WriterSink that writes to w.
+func NewWriterSink(w io.Writer) *WriterSink {
+	return &WriterSink{
+		w:       w,
+		encoder: json.NewEncoder(w),
+	}
+}
+
+// Write encodes the event as a single JSON line and writes it to the writer.
+func (s *WriterSink) Write(event map[string]interface{}) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.encoder.Encode(event)
+}
+
+// Flush flushes buffered writers (such as *bufio.Writer) and syncs writers
+// backed by files. It is a no-op for writers that support neither.
+func (s *WriterSink) Flush() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if f, ok := s.w.(interface{ Flush() error }); ok {
+		if err := f.Flush(); err != nil {
+			return err
+		}
+	}
+	if f, ok := s.w.(interface{ Sync() error }); ok {
+		return f.Sync()
+	}
+	return nil
+}
+
+// Close flushes the writer. The caller retains ownership of the writer and is
+// responsible for closing it.
+func (s *WriterSink) Close() error { return s.Flush() }
+
 // FileSink writes JSON-encoded log events to a file with simple size-based rotation.
 // When the file exceeds maxSizeMB, it is renamed with a ".1" suffix and a fresh file is opened.
 type FileSink struct {
@@ -1,13 +1,19 @@
 package logging
 
 import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
 	"encoding/json"
+	"errors"
 	"io"
 	"net"
 	"net/http"
 	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"strings"
 	"sync"
 	"testing"
 	"time"
@@ -52,6 +58,80 @@ func TestStdoutSink_ConcurrentWriteIsSafe(t *testing.T) {
 	wg.Wait()
 }
 
+// --- WriterSink ---
+
+func TestWriterSink_WritesNewlineDelimitedJSON(t *testing.T) {
+	var buf bytes.Buffer
+	sink := NewWriterSink(&buf)
+
+	for i := 0; i < 3; i++ {
+		if err := sink.Write(map[string]interface{}{"n": i, "msg": "buffered"}); err != nil {
+			t.Fatalf("Write %d: %v", i, err)
+		}
+	}
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("expected 3 lines, got %d: %q", len(lines), buf.String())
+	}
+	for i, line := range lines {
+		var decoded map[string]interface{}
+		if err := json.Unmarshal([]byte(line), &decoded); err != nil {
+			t.Fatalf("line %d is not valid JSON: %v", i, err)
+		}
+		if decoded["n"] != float64(i) {
+			t.Errorf("line %d n: got %v, want %d", i, decoded["n"], i)
+		}
+	}
+}
+
+func TestWriterSink_FlushFlushesBufferedWriter(t *testing.T) {
+	var buf bytes.Buffer
+	bw := bufio.NewWriter(&buf)
+	sink := NewWriterSink(bw)
+
+	if err := sink.Write(map[string]interface{}{"msg": "pending"}); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	if buf.Len() != 0 {
+		t.Fatal("expected event to remain buffered before Flush")
+	}
+	if err := sink.Flush(); err != nil {
+		t.Fatalf("Flush: %v", err)
+	}
+	if !strings.Contains(buf.String(), "pending") {
+		t.Errorf("expected flushed output to contain event, got %q", buf.String())
+	}
+}
+
+func TestWriterSink_ConcurrentWritesProduceWholeLines(t *testing.T) {
+	var buf bytes.Buffer
+	sink := NewWriterSink(&buf)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func(n int) {
+			defer wg.Done()
+			_ = sink.Write(map[string]interface{}{"n": n})
+		}(i)
+	}
+	wg.Wait()
+
+	scanner := bufio.NewScanner(&buf)
+	count := 0
+	for scanner.Scan() {
+		var decoded map[string]interface{}
+		if err := json.Unmarshal(scanner.Bytes(), &decoded); err != nil {
+			t.Fatalf("interleaved output, line %d: %v", count, err)
+		}
+		count++
+	}
+	if count != 50 {
+		t.Errorf("expected 50 lines, got %d", count)
+	}
+}
+
 // --- FileSink ---
 
 func TestFileSink_WritesJSONToFile(t *testing.T) {
@@ -422,8 +502,645 @@ func TestKillKrillSink_DefaultsApplied(t *testing.T) {
 	if sink.cfg.MaxRetries != defaultMaxRetries {
 		t.Errorf("MaxRetries default: got %d, want %d", sink.cfg.MaxRetries, defaultMaxRetries)
 	}
+	if sink.cfg.Format != KillKrillFormatArray {
+		t.Errorf("Format default: got %q, want %q", sink.cfg.Format, KillKrillFormatArray)
+	}
+	if sink.cfg.CloseTimeout != defaultCloseTimeout {
+		t.Errorf("CloseTimeout default: got %v, want %v", sink.cfg.CloseTimeout, defaultCloseTimeout)
+	}
+
+	if err := sink.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+}
+
+func TestKillKrillSink_NDJSONFormat(t *testing.T) {
+	var mu sync.Mutex
+	var received []map[string]interface{}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if ct := r.Header.Get("Content-Type"); ct != "application/x-ndjson" {
+			t.Errorf("unexpected Content-Type: %s", ct)
+		}
+		scanner := bufio.NewScanner(r.Body)
+		for scanner.Scan() {
+			var event map[string]interface{}
+			if err := json.Unmarshal(scanner.Bytes(), &event); err != nil {
+				t.Errorf("unmarshal line %q: %v", scanner.Text(), err)
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			mu.Lock()
+			received = append(received, event)
+			mu.Unlock()
+		}
+		if err := scanner.Err(); err != nil {
+			t.Errorf("scan body: %v", err)
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	sink := NewKillKrillSink(KillKrillConfig{
+		Endpoint:      server.URL,
+		APIKey:        "key",
+		BatchSize:     10,
+		FlushInterval: time.Hour,
+		Format:        KillKrillFormatNDJSON,
+	})
+	for i := 0; i < 3; i++ {
+		if err := sink.Write(map[string]interface{}{"n": i, "msg": "line\nbreak"}); err != nil {
+			t.Fatalf("Write %d: %v", i, err)
+		}
+	}
+	if err := sink.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(received) != 3 {
+		t.Fatalf("expected 3 events, got %d", len(received))
+	}
+	for i, event := range received {
+		if event["n"] != float64(i) || event["msg"] != "line\nbreak" {
+			t.Errorf("event %d: unexpected %v", i, event)
+		}
+	}
+}
+
+func TestKillKrillSink_Compress(t *testing.T) {
+	var mu sync.Mutex
+	var bodies [][]byte
+	attempts := 0
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if ce := r.Header.Get("Content-Encoding"); ce != "gzip" {
+			t.Errorf("unexpected Content-Encoding: %q", ce)
+		}
+		zr, err := gzip.NewReader(r.Body)
+		if err != nil {
+			t.Errorf("gzip reader: %v", err)
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		body, err := io.ReadAll(zr)
+		if err != nil {
+			t.Errorf("decompress body: %v", err)
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+		bodies = append(bodies, body)
+		attempts++
+		if attempts == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	sink := NewKillKrillSink(KillKrillConfig{
+		Endpoint:      server.URL,
+		APIKey:        "key",
+		BatchSize:     10,
+		FlushInterval: time.Hour,
+		MaxRetries:    2,
+		Compress:      true,
+	})
+	for i := 0; i < 4; i++ {
+		if err := sink.Write(map[string]interface{}{"n": i, "msg": "compressible event"}); err != nil {
+			t.Fatalf("Write %d: %v", i, err)
+		}
+	}
+	if err := sink.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(bodies) != 2 {
+		t.Fatalf("expected a failed attempt and a retry, got %d requests", len(bodies))
+	}
+	if !bytes.Equal(bodies[0], bodies[1]) {
+		t.Error("expected the retry to resend the same payload")
+	}
+	var batch []map[string]interface{}
+	if err := json.Unmarshal(bodies[1], &batch); err != nil {
+		t.Fatalf("unmarshal batch: %v", err)
+	}
+	if len(batch) != 4 {
+		t.Fatalf("expected 4 events, got %d", len(batch))
+	}
+	for i, event := range batch {
+		if event["n"] != float64(i) || event["msg"] != "compressible event" {
+			t.Errorf("event %d: unexpected %v", i, event)
+		}
+	}
+}
+
+func TestKillKrillSink_UncompressedByDefault(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if ce := r.Header.Get("Content-Encoding"); ce != "" {
+			t.Errorf("expected no Content-Encoding, got %q", ce)
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	sink := NewKillKrillSink(KillKrillConfig{Endpoint: server.URL, FlushInterval: time.Hour})
+	if err := sink.Write(map[string]interface{}{"n": 1}); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	if err := sink.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+}
+
+// hangingServer returns a server whose handler blocks until the test ends.
+func hangingServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	t.Cleanup(server.Close)
+	t.Cleanup(func() { close(release) })
+	return server
+}
+
+func TestKillKrillSink_CloseWithTimeoutHangingEndpoint(t *testing.T) {
+	server := hangingServer(t)
+	sink := NewKillKrillSink(KillKrillConfig{Endpoint: server.URL, FlushInterval: time.Hour, Timeout: time.Minute})
+	if err := sink.Write(map[string]interface{}{"n": 1}); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+
+	start := time.Now()
+	err := sink.CloseWithTimeout(200 * time.Millisecond)
+	elapsed := time.Since(start)
+
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected DeadlineExceeded, got %v", err)
+	}
+	if elapsed > 2*time.Second {
+		t.Errorf("Close took %v, want it bounded by the 200ms deadline", elapsed)
+	}
+}
+
+func TestKillKrillSink_CloseTimeoutBoundsInFlightFlush(t *testing.T) {
+	server := hangingServer(t)
+	sink := NewKillKrillSink(KillKrillConfig{
+		Endpoint:      server.URL,
+		FlushInterval: 10 * time.Millisecond,
+		Timeout:       time.Minute,
+		CloseTimeout:  200 * time.Millisecond,
+	})
+	if err := sink.Write(map[string]interface{}{"n": 1}); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	// Let the background goroutine pick the event up and hang on the request.
+	time.Sleep(50 * time.Millisecond)
+
+	start := time.Now()
+	err := sink.Close()
+	elapsed := time.Since(start)
+
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected DeadlineExceeded, got %v", err)
+	}
+	if elapsed > 2*time.Second {
+		t.Errorf("Close took %v, want it bounded by CloseTimeout", elapsed)
+	}
+}
+
+func TestKillKrillSink_UnknownFormat(t *testing.T) {
+	sink := NewKillKrillSink(KillKrillConfig{
+		Endpoint:      "http://127.0.0.1:0",
+		FlushInterval: time.Hour,
+		Format:        "xml",
+	})
+	if err := sink.Write(map[string]interface{}{"n": 1}); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	if err := sink.Close(); err == nil || !strings.Contains(err.Error(), "unknown format") {
+		t.Errorf("expected unknown format error, got %v", err)
+	}
+}
+
+// --- KafkaSink ---
+
+// mockKafkaProducer records every produced message and can fail a fixed
+// number of initial Produce calls.
+type mockKafkaProducer struct {
+	mu       sync.Mutex
+	messages []KafkaMessage
+	calls    int
+	failN    int
+	closed   bool
+}
+
+func (p *mockKafkaProducer) Produce(_ context.Context, msgs []KafkaMessage) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.calls++
+	if p.calls <= p.failN {
+		return errors.New("broker unavailable")
+	}
+	p.messages = append(p.messages, msgs...)
+	return nil
+}
+
+func (p *mockKafkaProducer) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.closed = true
+	return nil
+}
+
+func (p *mockKafkaProducer) snapshot() ([]KafkaMessage, int, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]KafkaMessage(nil), p.messages...), p.calls, p.closed
+}
+
+func TestKafkaSink_ProducesEventsWithKeyRouting(t *testing.T) {
+	producer := &mockKafkaProducer{}
+	sink, err := NewKafkaSink(KafkaConfig{
+		Producer:  producer,
+		Topic:     "logs",
+		KeyField:  "tenant",
+		BatchSize: 2,
+		Linger:    10 * time.Second,
+	})
+	if err != nil {
+		t.Fatalf("NewKafkaSink: %v", err)
+	}
+
+	events := []map[string]interface{}{
+		{"msg": "a", "tenant": "acme"},
+		{"msg": "b", "tenant": "globex"},
+		{"msg": "c"},
+	}
+	for i, event := range events {
+		if err := sink.Write(event); err != nil {
+			t.Fatalf("Write %d: %v", i, err)
+		}
+	}
+
+	// The first two events filled a batch; the third is still buffered.
+	if msgs, _, _ := producer.snapshot(); len(msgs) != 2 {
+		t.Fatalf("expected 2 messages after batch-full flush, got %d", len(msgs))
+	}
+
+	if err := sink.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	msgs, _, closed := producer.snapshot()
+	if len(msgs) != 3 {
+		t.Fatalf("expected 3 messages after Close, got %d", len(msgs))
+	}
+	if !closed {
+		t.Error("expected Close to close the producer")
+	}
+
+	wantKeys := []string{"acme", "globex", ""}
+	for i, msg := range msgs {
+		if msg.Topic != "logs" {
+			t.Errorf("message %d topic: got %q, want logs", i, msg.Topic)
+		}
+		if string(msg.Key) != wantKeys[i] {
+			t.Errorf("message %d key: got %q, want %q", i, msg.Key, wantKeys[i])
+		}
+		var decoded map[string]interface{}
+		if err := json.Unmarshal(msg.Value, &decoded); err != nil {
+			t.Fatalf("message %d is not valid JSON: %v", i, err)
+		}
+		if decoded["msg"] != events[i]["msg"] {
+			t.Errorf("message %d msg: got %v, want %v", i, decoded["msg"], events[i]["msg"])
+		}
+	}
+	if msgs[2].Key != nil {
+		t.Errorf("expected nil key for event without tenant, got %q", msgs[2].Key)
+	}
+}
+
+func TestKafkaSink_LingerFlushesInBackground(t *testing.T) {
+	producer := &mockKafkaProducer{}
+	sink, err := NewKafkaSink(KafkaConfig{
+		Producer: producer,
+		Topic:    "logs",
+		Linger:   50 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("NewKafkaSink: %v", err)
+	}
+	defer sink.Close()
+
+	if err := sink.Write(map[string]interface{}{"msg": "linger"}); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		if msgs, _, _ := producer.snapshot(); len(msgs) == 1 {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatal("expected linger flush to produce the buffered event")
+}
+
+func TestKafkaSink_RetriesOnProduceError(t *testing.T) {
+	producer := &mockKafkaProducer{failN: 2}
+	sink, err := NewKafkaSink(KafkaConfig{
+		Producer:   producer,
+		Topic:      "logs",
+		Linger:     10 * time.Second,
+		MaxRetries: 3,
+	})
+	if err != nil {
+		t.Fatalf("NewKafkaSink: %v", err)
+	}
+
+	if err := sink.Write(map[string]interface{}{"msg": "retry"}); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	if err := sink.Flush(); err != nil {
+		t.Fatalf("Flush: %v", err)
+	}
+	if err := sink.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	msgs, calls, _ := producer.snapshot()
+	if calls != 3 {
+		t.Errorf("expected 3 Produce calls (2 failures + 1 success), got %d", calls)
+	}
+	if len(msgs) != 1 {
+		t.Errorf("expected 1 delivered message, got %d", len(msgs))
+	}
+}
+
+func TestKafkaSink_ReturnsErrorAfterExhaustingRetries(t *testing.T) {
+	producer := &mockKafkaProducer{failN: 100}
+	sink, err := NewKafkaSink(KafkaConfig{
+		Producer:   producer,
+		Topic:      "logs",
+		Linger:     10 * time.Second,
+		MaxRetries: 1,
+	})
+	if err != nil {
+		t.Fatalf("NewKafkaSink: %v", err)
+	}
+
+	_ = sink.Write(map[string]interface{}{"msg": "lost"})
+	if err := sink.Close(); err == nil {
+		t.Error("expected Close to report the failed final flush")
+	}
+	if _, calls, closed := producer.snapshot(); calls != 2 || !closed {
+		t.Errorf("expected 2 attempts and a closed producer, got calls=%d closed=%v", calls, closed)
+	}
+}
+
+func TestNewKafkaSink_RequiresProducerAndTopic(t *testing.T) {
+	if _, err := NewKafkaSink(KafkaConfig{Topic: "logs"}); err == nil {
+		t.Error("expected error for missing producer")
+	}
+	if _, err := NewKafkaSink(KafkaConfig{Producer: &mockKafkaProducer{}}); err == nil {
+		t.Error("expected error for missing topic")
+	}
+}
+
+// --- RateLimitedSink ---
+
+// fakeClock is a manually advanced time source for rate-limit tests.
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+func newTestRateLimitedSink(t *testing.T, next Sink, cfg RateLimitConfig) (*RateLimitedSink, *fakeClock) {
+	t.Helper()
+	clock := &fakeClock{now: time.Unix(1700000000, 0)}
+	sink, err := NewRateLimitedSink(next, cfg)
+	if err != nil {
+		t.Fatalf("NewRateLimitedSink: %v", err)
+	}
+	sink.now = clock.Now
+	sink.last = clock.Now()
+	return sink, clock
+}
+
+func TestRateLimitedSink_DropsEventsAboveLimit(t *testing.T) {
+	capture := &captureSink{}
+	sink, clock := newTestRateLimitedSink(t, capture, RateLimitConfig{EventsPerSecond: 5})
+
+	for i := 0; i < 20; i++ {
+		if err := sink.Write(map[string]interface{}{"n": i}); err != nil {
+			t.Fatalf("Write %d: %v", i, err)
+		}
+	}
+	if capture.count() != 5 {
+		t.Errorf("expected burst of 5 events to pass, got %d", capture.count())
+	}
+	if sink.Dropped() != 15 {
+		t.Errorf("expected 15 dropped events, got %d", sink.Dropped())
+	}
+
+	// One second refills the bucket for another five events.
+	clock.Advance(time.Second)
+	for i := 0; i < 10; i++ {
+		_ = sink.Write(map[string]interface{}{"n": i})
+	}
+	if capture.count() != 10 {
+		t.Errorf("expected 10 events after refill, got %d", capture.count())
+	}
+	if sink.Dropped() != 20 {
+		t.Errorf("expected 20 dropped events, got %d", sink.Dropped())
+	}
+}
+
+func TestRateLimitedSink_SummarizesSuppressedEvents(t *testing.T) {
+	capture := &captureSink{}
+	sink, clock := newTestRateLimitedSink(t, capture, RateLimitConfig{EventsPerSecond: 1, Summarize: true})
+
+	for i := 0; i < 4; i++ {
+		_ = sink.Write(map[string]interface{}{"msg": "noisy"})
+	}
+	clock.Advance(time.Second)
+	_ = sink.Write(map[string]interface{}{"msg": "after"})
 
+	if capture.count() != 3 {
+		t.Fatalf("expected first event, summary, and next event; got %d events", capture.count())
+	}
+	summary := capture.get(1)
+	if summary["suppressed"] != uint64(3) {
+		t.Errorf("expected summary of 3 suppressed events, got %v", summary["suppressed"])
+	}
+	if msg, _ := summary["msg"].(string); !strings.Contains(msg, "3 log events suppressed") {
+		t.Errorf("unexpected summary message %q", msg)
+	}
+	if capture.get(2)["msg"] != "after" {
+		t.Errorf("expected allowed event after summary, got %v", capture.get(2)["msg"])
+	}
+}
+
+func TestRateLimitedSink_NoSummaryWhenDisabled(t *testing.T) {
+	capture := &captureSink{}
+	sink, clock := newTestRateLimitedSink(t, capture, RateLimitConfig{EventsPerSecond: 1})
+
+	_ = sink.Write(map[string]interface{}{"msg": "first"})
+	_ = sink.Write(map[string]interface{}{"msg": "dropped"})
+	clock.Advance(time.Second)
+	_ = sink.Write(map[string]interface{}{"msg": "second"})
+
+	if capture.count() != 2 {
+		t.Fatalf("expected 2 events without a summary, got %d", capture.count())
+	}
+}
+
+func TestRateLimitedSink_FlushAndClosePassThrough(t *testing.T) {
+	inner := &flushTrackingSink{}
+	sink, _ := newTestRateLimitedSink(t, inner, RateLimitConfig{EventsPerSecond: 10})
+
+	if err := sink.Flush(); err != nil {
+		t.Fatalf("Flush: %v", err)
+	}
+	if !inner.wasFlushed() {
+		t.Error("expected Flush to reach the wrapped sink")
+	}
 	if err := sink.Close(); err != nil {
 		t.Fatalf("Close: %v", err)
 	}
 }
+
+func TestNewRateLimitedSink_RejectsNonPositiveRate(t *testing.T) {
+	if _, err := NewRateLimitedSink(&captureSink{}, RateLimitConfig{}); err == nil {
+		t.Error("expected error for zero rate")
+	}
+}
+
+// --- FanOutSink ---
+
+// slowSink blocks every Write until release is closed.
+type slowSink struct {
+	captureSink
+	release chan struct{}
+}
+
+func (s *slowSink) Write(event map[string]interface{}) error {
+	<-s.release
+	return s.captureSink.Write(event)
+}
+
+// failingSink rejects every operation.
+type failingSink struct{}
+
+func (failingSink) Write(map[string]interface{}) error { return errors.New("write refused") }
+func (failingSink) Flush() error                       { return errors.New("flush refused") }
+func (failingSink) Close() error                       { return nil }
+
+func TestFanOutSink_SlowAndFailingSinksDoNotStallFastSinks(t *testing.T) {
+	slow := &slowSink{release: make(chan struct{})}
+	defer close(slow.release)
+	fast := &captureSink{}
+
+	sink := NewFanOutSink(100*time.Millisecond, slow, failingSink{}, fast)
+
+	start := time.Now()
+	err := sink.Write(map[string]interface{}{"msg": "fan out"})
+	elapsed := time.Since(start)
+
+	if fast.count() != 1 {
+		t.Fatalf("expected fast sink to receive the event, got %d events", fast.count())
+	}
+	if elapsed > time.Second {
+		t.Errorf("Write took %v; expected it to return near the 100ms timeout", elapsed)
+	}
+	if err == nil {
+		t.Fatal("expected aggregate error for the slow and failing sinks")
+	}
+	if !errors.Is(err, ErrSinkTimeout) {
+		t.Errorf("expected ErrSinkTimeout in aggregate error, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "write refused") {
+		t.Errorf("expected failing sink error in aggregate error, got %v", err)
+	}
+	if strings.Contains(err.Error(), "sink 2") {
+		t.Errorf("fast sink should not be reported, got %v", err)
+	}
+}
+
+func TestFanOutSink_WritesConcurrently(t *testing.T) {
+	a := &slowSink{release: make(chan struct{})}
+	b := &slowSink{release: make(chan struct{})}
+	sink := NewFanOutSink(time.Second, a, b)
+
+	done := make(chan error, 1)
+	go func() { done <- sink.Write(map[string]interface{}{"msg": "parallel"}) }()
+
+	// Releasing b first would deadlock a sequential implementation.
+	close(b.release)
+	close(a.release)
+
+	if err := <-done; err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	if a.count() != 1 || b.count() != 1 {
+		t.Errorf("expected both sinks to receive the event, got %d and %d", a.count(), b.count())
+	}
+}
+
+func TestFanOutSink_ChildrenReceiveIndependentCopies(t *testing.T) {
+	var first map[string]interface{}
+	mutator := NewCallbackSink(func(event map[string]interface{}) { event["mutated"] = true })
+	recorder := NewCallbackSink(func(event map[string]interface{}) { first = event })
+
+	sink := NewFanOutSink(time.Second, mutator, recorder)
+	original := map[string]interface{}{"msg": "copy"}
+	if err := sink.Write(original); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	if _, ok := original["mutated"]; ok {
+		t.Error("original event was mutated")
+	}
+	if _, ok := first["mutated"]; ok {
+		t.Error("recorder observed another sink's mutation")
+	}
+}
+
+func TestFanOutSink_FlushAggregatesErrors(t *testing.T) {
+	tracker := &flushTrackingSink{}
+	sink := NewFanOutSink(time.Second, tracker, failingSink{})
+
+	err := sink.Flush()
+	if err == nil || !strings.Contains(err.Error(), "flush refused") {
+		t.Errorf("expected flush error from failing sink, got %v", err)
+	}
+	if !tracker.wasFlushed() {
+		t.Error("expected healthy sink to be flushed despite the failure")
+	}
+	if err := sink.Close(); err != nil {
+		t.Errorf("Close: %v", err)
+	}
+}
@@ -0,0 +1,123 @@
+package client
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/quic-go/quic-go"
+	"go.uber.org/zap"
+)
+
+// defaultAltSvcMaxAge is the freshness lifetime of an Alt-Svc entry without an
+// "ma" parameter (RFC 7838, section 3.1).
+const defaultAltSvcMaxAge = 24 * time.Hour
+
+// altSvcEntry is an HTTP/3 alternative endpoint advertised by an origin.
+type altSvcEntry struct {
+	addr    string
+	expires time.Time
+}
+
+// parseAltSvcH3 returns the first h3 alternative in an Alt-Svc header value,
+// resolved against origin (host:port), and its max age. clear reports whether
+// the header is "clear", which withdraws earlier advertisements.
+func parseAltSvcH3(value, origin string) (addr string, maxAge time.Duration, clear, ok bool) {
+	value = strings.TrimSpace(value)
+	if value == "clear" {
+		return "", 0, true, false
+	}
+	originHost, _, err := net.SplitHostPort(origin)
+	if err != nil {
+		originHost = origin
+	}
+	for _, alt := range strings.Split(value, ",") {
+		params := strings.Split(alt, ";")
+		protocol, authority, found := strings.Cut(strings.TrimSpace(params[0]), "=")
+		if !found || protocol != "h3" {
+			continue
+		}
+		host, port, err := net.SplitHostPort(strings.Trim(authority, `"`))
+		if err != nil || port == "" {
+			continue
+		}
+		if host == "" {
+			host = originHost
+		}
+		maxAge = defaultAltSvcMaxAge
+		for _, p := range params[1:] {
+			k, v, _ := strings.Cut(strings.TrimSpace(p), "=")
+			if k != "ma" {
+				continue
+			}
+			if secs, err := strconv.Atoi(strings.Trim(v, `"`)); err == nil {
+				maxAge = time.Duration(secs) * time.Second
+			}
+		}
+		return net.JoinHostPort(host, port), maxAge, false, true
+	}
+	return "", 0, false, false
+}
+
+// recordAltSvc stores the HTTP/3 endpoint advertised by an HTTP/2 response and,
+// when HTTP/3 is enabled but not in use, upgrades the client unless the circuit
+// breaker is open.
+func (c *Client) recordAltSvc(req *http.Request, resp *http.Response) {
+	header := resp.Header.Get("Alt-Svc")
+	if header == "" {
+		return
+	}
+	origin := authorityAddr(req)
+	addr, maxAge, clear, ok := parseAltSvcH3(header, origin)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if clear {
+		delete(c.altSvc, origin)
+		return
+	}
+	if !ok {
+		return
+	}
+	c.altSvc[origin] = altSvcEntry{addr: addr, expires: time.Now().Add(maxAge)}
+	if c.cfg.H3Enabled && !c.useH3 && c.breaker == BreakerClosed {
+		c.logger.Info("upgrading to HTTP/3 via Alt-Svc", zap.String("origin", origin), zap.String("h3_addr", addr))
+		c.useH3 = true
+		c.stats.upgrades.Add(1)
+	}
+}
+
+// dialH3 dials the HTTP/3 endpoint for addr, using the connection won by a
+// protocol race if there is one and otherwise substituting an unexpired Alt-Svc
+// alternative when the origin advertised one.
+func (c *Client) dialH3(ctx context.Context, addr string, tlsCfg *tls.Config, quicCfg *quic.Config) (*quic.Conn, error) {
+	c.mu.Lock()
+	if conn, ok := c.predialedH3[addr]; ok {
+		delete(c.predialedH3, addr)
+		c.mu.Unlock()
+		return conn, nil
+	}
+	if alt, ok := c.altSvc[addr]; ok && time.Now().Before(alt.expires) {
+		addr = alt.addr
+	}
+	c.mu.Unlock()
+	return quic.DialAddrEarly(ctx, addr, tlsCfg, quicCfg)
+}
+
+// authorityAddr returns the host:port a request is sent to, applying the
+// scheme's default port.
+func authorityAddr(req *http.Request) string {
+	host := req.URL.Host
+	if _, _, err := net.SplitHostPort(host); err == nil {
+		return host
+	}
+	port := "443"
+	if req.URL.Scheme == "http" {
+		port = "80"
+	}
+	return net.JoinHostPort(strings.Trim(host, "[]"), port)
+}
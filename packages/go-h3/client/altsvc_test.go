@@ -0,0 +1,119 @@
+package client
+
+import (
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+func TestParseAltSvcH3(t *testing.T) {
+	tests := []struct {
+		name       string
+		value      string
+		wantAddr   string
+		wantMaxAge time.Duration
+		wantClear  bool
+		wantOK     bool
+	}{
+		{"port only", `h3=":8443"`, "example.test:8443", 24 * time.Hour, false, true},
+		{"with max age", `h3=":8443"; ma=3600`, "example.test:8443", time.Hour, false, true},
+		{"explicit host", `h3="alt.example.test:443"`, "alt.example.test:443", 24 * time.Hour, false, true},
+		{"skips other protocols", `h3-29=":9443", h3=":8443"; ma=60`, "example.test:8443", time.Minute, false, true},
+		{"no h3", `h2=":443"`, "", 0, false, false},
+		{"clear", "clear", "", 0, true, false},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			addr, maxAge, clear, ok := parseAltSvcH3(tc.value, "example.test:443")
+			if addr != tc.wantAddr || maxAge != tc.wantMaxAge || clear != tc.wantClear || ok != tc.wantOK {
+				t.Errorf("expected (%q, %v, %v, %v), got (%q, %v, %v, %v)",
+					tc.wantAddr, tc.wantMaxAge, tc.wantClear, tc.wantOK, addr, maxAge, clear, ok)
+			}
+		})
+	}
+}
+
+// newAltSvcServer starts an HTTP/2 server that advertises a separate HTTP/3
+// listener on another port via Alt-Svc.
+func newAltSvcServer(t *testing.T) string {
+	t.Helper()
+	var h3Port string
+	srv := newH2OnlyServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Alt-Svc", `h3=":`+h3Port+`"; ma=60`)
+		_, _ = w.Write([]byte(r.Proto))
+	}))
+	h3Addr := serveH3(t, "127.0.0.1:0", srv.TLS.Certificates, protoHandler())
+	_, h3Port, _ = net.SplitHostPort(h3Addr)
+	return srv.URL
+}
+
+func TestAltSvc_UpgradesToH3(t *testing.T) {
+	url := newAltSvcServer(t)
+	cfg := testClientConfig()
+	cfg.H3Discovery = true
+	c := New(cfg, zap.NewNop())
+	defer c.Close()
+
+	if c.Protocol() != "h2" {
+		t.Fatalf("expected discovery to start on h2, got %s", c.Protocol())
+	}
+
+	resp, err := c.HTTPClient().Get(url)
+	if err != nil {
+		t.Fatalf("first request failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.ProtoMajor != 2 {
+		t.Errorf("expected first request over HTTP/2, got %s", resp.Proto)
+	}
+	if c.Protocol() != "h3" {
+		t.Fatalf("expected upgrade to h3 after Alt-Svc, got %s", c.Protocol())
+	}
+
+	resp, err = c.HTTPClient().Get(url)
+	if err != nil {
+		t.Fatalf("second request failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.ProtoMajor != 3 {
+		t.Errorf("expected second request over HTTP/3 on the advertised port, got %s", resp.Proto)
+	}
+}
+
+func TestAltSvc_NoUpgradeWhileBreakerOpen(t *testing.T) {
+	url := newAltSvcServer(t)
+	c := New(testClientConfig(), zap.NewNop())
+	defer c.Close()
+	c.MarkH3Failed()
+
+	resp, err := c.HTTPClient().Get(url)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	resp.Body.Close()
+
+	if c.Protocol() != "h2" {
+		t.Errorf("expected open breaker to block the Alt-Svc upgrade, got %s", c.Protocol())
+	}
+}
+
+func TestAltSvc_IgnoredWhenH3Disabled(t *testing.T) {
+	url := newAltSvcServer(t)
+	cfg := testClientConfig()
+	cfg.H3Enabled = false
+	c := New(cfg, zap.NewNop())
+	defer c.Close()
+
+	resp, err := c.HTTPClient().Get(url)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	resp.Body.Close()
+
+	if c.Protocol() != "h2" {
+		t.Errorf("expected h2 when H3 is disabled, got %s", c.Protocol())
+	}
+}
@@ -0,0 +1,124 @@
+package client
+
+import (
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// BreakerState is the state of the client's HTTP/3 circuit breaker.
+type BreakerState int
+
+const (
+	// BreakerClosed means HTTP/3 is in use and failures are being counted.
+	BreakerClosed BreakerState = iota
+	// BreakerOpen means HTTP/3 is disabled and requests use HTTP/2 until the
+	// retry interval elapses.
+	BreakerOpen
+	// BreakerHalfOpen means HTTP/3 is being re-attempted: the next success closes
+	// the breaker and the next failure re-opens it with a longer interval.
+	BreakerHalfOpen
+)
+
+// String returns the lowercase name of the state.
+func (s BreakerState) String() string {
+	switch s {
+	case BreakerClosed:
+		return "closed"
+	case BreakerOpen:
+		return "open"
+	case BreakerHalfOpen:
+		return "half-open"
+	}
+	return "unknown"
+}
+
+// H3BreakerState returns the current state of the HTTP/3 circuit breaker.
+func (c *Client) H3BreakerState() BreakerState {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.breaker
+}
+
+// MarkH3Failed records that an HTTP/3 request failed. After H3FailureThreshold
+// consecutive failures, or any failure while re-attempting HTTP/3, the breaker
+// opens and the client falls back to HTTP/2. Re-upgrade is attempted after the
+// current retry interval, which doubles each time the breaker re-opens.
+func (c *Client) MarkH3Failed() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.useH3 {
+		return
+	}
+	c.stats.h3Failures.Add(1)
+	c.h3Failures++
+	if c.breaker == BreakerClosed && c.h3Failures < c.failureThreshold() {
+		return
+	}
+	c.h3Trips++
+	c.breaker = BreakerOpen
+	c.useH3 = false
+	c.lastH3Try = time.Now()
+	c.logger.Warn("HTTP/3 failed, falling back to HTTP/2",
+		zap.Int("failures", c.h3Failures),
+		zap.Duration("retry_in", c.retryInterval()),
+	)
+}
+
+// MarkH3Succeeded records that an HTTP/3 request succeeded. It resets the failure
+// count and, while re-attempting HTTP/3, closes the breaker and resets the retry
+// interval.
+func (c *Client) MarkH3Succeeded() {
+	c.mu.RLock()
+	clean := c.breaker == BreakerClosed && c.h3Failures == 0
+	c.mu.RUnlock()
+	if clean {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.h3Failures = 0
+	if c.breaker == BreakerHalfOpen {
+		c.logger.Info("HTTP/3 recovered")
+		c.breaker = BreakerClosed
+		c.h3Trips = 0
+	}
+}
+
+// MaybeRetryH3 checks if enough time has passed to re-attempt HTTP/3 and, if so,
+// moves the breaker to half-open. Requests sent through HTTPClient call it
+// automatically.
+func (c *Client) MaybeRetryH3() {
+	if !c.cfg.H3Enabled {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.breaker == BreakerOpen && time.Since(c.lastH3Try) >= c.retryInterval() {
+		c.logger.Info("re-attempting HTTP/3")
+		c.breaker = BreakerHalfOpen
+		c.useH3 = true
+		c.stats.upgrades.Add(1)
+	}
+}
+
+func (c *Client) failureThreshold() int {
+	if c.cfg.H3FailureThreshold < 1 {
+		return 1
+	}
+	return c.cfg.H3FailureThreshold
+}
+
+// retryInterval returns H3RetryInterval doubled for each re-open after the first,
+// capped at H3MaxRetryInterval. Callers must hold c.mu.
+func (c *Client) retryInterval() time.Duration {
+	interval := c.cfg.H3RetryInterval
+	for i := 1; i < c.h3Trips; i++ {
+		interval *= 2
+		if c.cfg.H3MaxRetryInterval > 0 && interval >= c.cfg.H3MaxRetryInterval {
+			return c.cfg.H3MaxRetryInterval
+		}
+	}
+	return interval
+}
@@ -0,0 +1,130 @@
+package client
+
+import (
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+func newBreakerTestClient(threshold int, interval time.Duration) *Client {
+	cfg := DefaultClientConfig()
+	cfg.H3FailureThreshold = threshold
+	cfg.H3RetryInterval = interval
+	cfg.H3MaxRetryInterval = 4 * interval
+	return New(cfg, zap.NewNop())
+}
+
+// expireRetryInterval backdates the breaker so the current retry interval has
+// elapsed.
+func expireRetryInterval(c *Client) {
+	c.mu.Lock()
+	c.lastH3Try = time.Now().Add(-c.retryInterval())
+	c.mu.Unlock()
+}
+
+func TestBreaker_IntermittentFailuresKeepH3(t *testing.T) {
+	c := newBreakerTestClient(3, time.Minute)
+
+	for i := 0; i < 5; i++ {
+		c.MarkH3Failed()
+		c.MarkH3Failed()
+		c.MarkH3Succeeded()
+	}
+
+	if c.H3BreakerState() != BreakerClosed {
+		t.Errorf("expected breaker closed after intermittent failures, got %s", c.H3BreakerState())
+	}
+	if c.Protocol() != "h3" {
+		t.Errorf("expected protocol h3, got %s", c.Protocol())
+	}
+}
+
+func TestBreaker_SustainedFailuresOpen(t *testing.T) {
+	c := newBreakerTestClient(3, time.Minute)
+
+	c.MarkH3Failed()
+	c.MarkH3Failed()
+	if c.H3BreakerState() != BreakerClosed {
+		t.Fatalf("expected breaker closed below threshold, got %s", c.H3BreakerState())
+	}
+	c.MarkH3Failed()
+
+	if c.H3BreakerState() != BreakerOpen {
+		t.Errorf("expected breaker open at threshold, got %s", c.H3BreakerState())
+	}
+	if c.Protocol() != "h2" {
+		t.Errorf("expected protocol h2 while open, got %s", c.Protocol())
+	}
+
+	c.MaybeRetryH3()
+	if c.H3BreakerState() != BreakerOpen {
+		t.Errorf("expected breaker to stay open before the retry interval, got %s", c.H3BreakerState())
+	}
+}
+
+func TestBreaker_HalfOpenTransitions(t *testing.T) {
+	c := newBreakerTestClient(2, time.Minute)
+	c.MarkH3Failed()
+	c.MarkH3Failed()
+
+	expireRetryInterval(c)
+	c.MaybeRetryH3()
+	if c.H3BreakerState() != BreakerHalfOpen {
+		t.Fatalf("expected breaker half-open after retry interval, got %s", c.H3BreakerState())
+	}
+	if c.Protocol() != "h3" {
+		t.Errorf("expected protocol h3 while half-open, got %s", c.Protocol())
+	}
+
+	// A single failure while half-open re-opens the breaker.
+	c.MarkH3Failed()
+	if c.H3BreakerState() != BreakerOpen {
+		t.Fatalf("expected breaker to re-open on half-open failure, got %s", c.H3BreakerState())
+	}
+
+	expireRetryInterval(c)
+	c.MaybeRetryH3()
+	c.MarkH3Succeeded()
+	if c.H3BreakerState() != BreakerClosed {
+		t.Errorf("expected breaker closed after half-open success, got %s", c.H3BreakerState())
+	}
+}
+
+func TestBreaker_RetryIntervalWidens(t *testing.T) {
+	c := newBreakerTestClient(1, time.Minute)
+	want := []time.Duration{time.Minute, 2 * time.Minute, 4 * time.Minute, 4 * time.Minute}
+
+	for i, w := range want {
+		c.MarkH3Failed()
+		c.mu.RLock()
+		got := c.retryInterval()
+		c.mu.RUnlock()
+		if got != w {
+			t.Errorf("trip %d: expected retry interval %v, got %v", i+1, w, got)
+		}
+		expireRetryInterval(c)
+		c.MaybeRetryH3()
+	}
+
+	c.MarkH3Succeeded()
+	c.MarkH3Failed()
+	c.mu.RLock()
+	got := c.retryInterval()
+	c.mu.RUnlock()
+	if got != time.Minute {
+		t.Errorf("expected retry interval to reset after recovery, got %v", got)
+	}
+}
+
+func TestBreakerState_String(t *testing.T) {
+	for state, want := range map[BreakerState]string{
+		BreakerClosed:   "closed",
+		BreakerOpen:     "open",
+		BreakerHalfOpen: "half-open",
+	} {
+		if got := state.String(); got != want {
+			t.Errorf("expected %q, got %q", want, got)
+		}
+	}
+}
@@ -1,24 +1,37 @@
 package client
 
 import (
+	"context"
 	"crypto/tls"
+	"net"
 	"net/http"
 	"sync"
 	"time"
 
+	"github.com/quic-go/quic-go"
 	"github.com/quic-go/quic-go/http3"
 	"go.uber.org/zap"
 )
 
 // Client is an HTTP client that prefers HTTP/3 and falls back to HTTP/2.
 type Client struct {
-	cfg       Config
-	logger    *zap.Logger
-	h2Client  *http.Client
-	h3Client  *http.Client
-	mu        sync.RWMutex
-	useH3     bool
-	lastH3Try time.Time
+	cfg         Config
+	logger      *zap.Logger
+	h2Transport *http.Transport
+	h3Transport *http3.Transport
+	httpClient  *http.Client
+	mu          sync.RWMutex
+	useH3       bool
+	lastH3Try   time.Time
+	breaker     BreakerState
+	h3Failures  int
+	h3Trips     int
+	altSvc      map[string]altSvcEntry
+	raced       map[string]bool
+	predialedH2 map[string]net.Conn
+	predialedH3 map[string]*quic.Conn
+	stats       clientStats
+	drain       *drainTracker
 }
 
 // New creates a Client with the given config and logger.
@@ -41,33 +54,45 @@ func New(cfg Config, logger *zap.Logger) *Client {
 
 	h3Transport := &http3.Transport{
 		TLSClientConfig: tlsCfg.Clone(),
+		QUICConfig:      &quic.Config{HandshakeIdleTimeout: cfg.H3Timeout},
 	}
 
 	c := &Client{
-		cfg:    cfg,
-		logger: logger,
-		h2Client: &http.Client{
-			Transport: h2Transport,
-			Timeout:   cfg.RequestTimeout,
-		},
-		h3Client: &http.Client{
-			Transport: h3Transport,
-			Timeout:   cfg.RequestTimeout,
-		},
-		useH3: cfg.H3Enabled,
+		cfg:         cfg,
+		logger:      logger,
+		h2Transport: h2Transport,
+		h3Transport: h3Transport,
+		useH3:       cfg.H3Enabled && !cfg.H3Discovery,
+		altSvc:      make(map[string]altSvcEntry),
+		raced:       make(map[string]bool),
+		predialedH2: make(map[string]net.Conn),
+		predialedH3: make(map[string]*quic.Conn),
+		drain:       newDrainTracker(),
+	}
+	h3Transport.Dial = c.dialH3
+	if cfg.H3Race {
+		h2Transport.DialTLSContext = c.dialH2TLS
+	}
+	var transport http.RoundTripper = &protocolTransport{c: c}
+	if cfg.Retry != nil {
+		transport = newRetryTransport(transport, *cfg.Retry, logger)
+	}
+	c.httpClient = &http.Client{
+		Transport: &drainTransport{next: transport, tracker: c.drain},
+		Timeout:   cfg.RequestTimeout,
 	}
 	return c
 }
 
-// HTTPClient returns the current preferred *http.Client.
+// HTTPClient returns an *http.Client that sends each request over the currently
+// preferred protocol. If an HTTP/3 request fails at the connection level,
+// idempotent requests are transparently re-issued over HTTP/2 within the same
+// call; other requests return the error. Failures feed the HTTP/3 circuit breaker
+// (see MarkH3Failed), which decides when the client prefers HTTP/2 and when
+// HTTP/3 is re-attempted.
 // This can be passed to ConnectRPC client constructors.
 func (c *Client) HTTPClient() *http.Client {
-	c.mu.RLock()
-	defer c.mu.RUnlock()
-	if c.useH3 {
-		return c.h3Client
-	}
-	return c.h2Client
+	return c.httpClient
 }
 
 // Protocol returns the currently active protocol ("h3" or "h2").
@@ -80,37 +105,11 @@ func (c *Client) Protocol() string {
 	return "h2"
 }
 
-// MarkH3Failed records that an HTTP/3 request failed, triggering fallback to HTTP/2.
-// Periodic re-upgrade is attempted after H3RetryInterval.
-func (c *Client) MarkH3Failed() {
-	c.mu.Lock()
-	defer c.mu.Unlock()
-	if c.useH3 {
-		c.logger.Warn("HTTP/3 failed, falling back to HTTP/2")
-		c.useH3 = false
-		c.lastH3Try = time.Now()
-	}
-}
-
-// MaybeRetryH3 checks if enough time has passed to re-attempt HTTP/3.
-// Call this periodically (e.g., before each request) to enable re-upgrade.
-func (c *Client) MaybeRetryH3() {
-	if !c.cfg.H3Enabled {
-		return
-	}
-	c.mu.Lock()
-	defer c.mu.Unlock()
-	if !c.useH3 && time.Since(c.lastH3Try) >= c.cfg.H3RetryInterval {
-		c.logger.Info("re-attempting HTTP/3")
-		c.useH3 = true
-	}
-}
-
-// Close releases resources held by the client's transports.
+// Close stops accepting requests, waits up to DrainTimeout for in-flight requests
+// to finish, and releases resources held by the client's transports. See
+// CloseContext.
 func (c *Client) Close() error {
-	c.h2Client.CloseIdleConnections()
-	if t, ok := c.h3Client.Transport.(*http3.Transport); ok {
-		return t.Close()
-	}
-	return nil
+	ctx, cancel := context.WithTimeout(context.Background(), c.cfg.DrainTimeout)
+	defer cancel()
+	return c.CloseContext(ctx)
 }
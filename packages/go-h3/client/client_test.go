@@ -43,6 +43,7 @@ func TestClient_MarkH3Failed(t *testing.T) {
 	logger := zap.NewNop()
 	cfg := DefaultClientConfig()
 	cfg.H3Enabled = true
+	cfg.H3FailureThreshold = 1
 
 	client := New(cfg, logger)
 
@@ -62,6 +63,7 @@ func TestClient_MaybeRetryH3_TooSoon(t *testing.T) {
 	cfg := DefaultClientConfig()
 	cfg.H3Enabled = true
 	cfg.H3RetryInterval = 1 * time.Minute
+	cfg.H3FailureThreshold = 1
 
 	client := New(cfg, logger)
 
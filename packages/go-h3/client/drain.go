@@ -0,0 +1,124 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+)
+
+// ErrClientClosed is returned for requests sent after Close has been called.
+var ErrClientClosed = errors.New("client: closed")
+
+// drainTracker counts in-flight requests so Close can wait for them.
+type drainTracker struct {
+	mu       sync.Mutex
+	closed   bool
+	inflight int
+	idle     chan struct{} // closed when closed && inflight == 0
+}
+
+func newDrainTracker() *drainTracker {
+	return &drainTracker{idle: make(chan struct{})}
+}
+
+// acquire registers a new request, failing once the client is closed.
+func (d *drainTracker) acquire() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.closed {
+		return ErrClientClosed
+	}
+	d.inflight++
+	return nil
+}
+
+func (d *drainTracker) release() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.inflight--
+	if d.closed && d.inflight == 0 {
+		close(d.idle)
+	}
+}
+
+// close rejects new requests and returns a channel that is closed once every
+// in-flight request has finished, and the number still in flight.
+func (d *drainTracker) close() (<-chan struct{}, int) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if !d.closed {
+		d.closed = true
+		if d.inflight == 0 {
+			close(d.idle)
+		}
+	}
+	return d.idle, d.inflight
+}
+
+// drainTransport tracks each request from RoundTrip until its response body is
+// closed or fully read.
+type drainTransport struct {
+	next    http.RoundTripper
+	tracker *drainTracker
+}
+
+func (t *drainTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if err := t.tracker.acquire(); err != nil {
+		if req.Body != nil {
+			_ = req.Body.Close()
+		}
+		return nil, err
+	}
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		t.tracker.release()
+		return nil, err
+	}
+	resp.Body = &trackedBody{ReadCloser: resp.Body, release: t.tracker.release}
+	return resp, nil
+}
+
+// trackedBody releases its request's in-flight slot once, on EOF or Close.
+type trackedBody struct {
+	io.ReadCloser
+	once    sync.Once
+	release func()
+}
+
+func (b *trackedBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if err == io.EOF {
+		b.once.Do(b.release)
+	}
+	return n, err
+}
+
+func (b *trackedBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.once.Do(b.release)
+	return err
+}
+
+// CloseContext stops the client from accepting new requests, waits for in-flight
+// requests to finish (including reading their response bodies) until ctx is done,
+// then releases the transports. It returns an error if requests were still in
+// flight when ctx ended; their connections are closed regardless.
+func (c *Client) CloseContext(ctx context.Context) error {
+	idle, inflight := c.drain.close()
+	var drainErr error
+	if inflight > 0 {
+		c.logger.Info("draining in-flight requests")
+		select {
+		case <-idle:
+		case <-ctx.Done():
+			drainErr = fmt.Errorf("client close: requests still in flight: %w", ctx.Err())
+		}
+	}
+
+	c.closePredialed()
+	c.h2Transport.CloseIdleConnections()
+	return errors.Join(drainErr, c.h3Transport.Close())
+}
@@ -0,0 +1,112 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// newSlowServer returns an H2 server whose handler signals started and then
+// waits for release before responding.
+func newSlowServer(t *testing.T) (url string, started <-chan struct{}, release chan<- struct{}) {
+	t.Helper()
+	startedCh := make(chan struct{}, 1)
+	releaseCh := make(chan struct{})
+	srv := newH2OnlyServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		startedCh <- struct{}{}
+		select {
+		case <-releaseCh:
+		case <-r.Context().Done():
+			return
+		}
+		_, _ = w.Write([]byte("done"))
+	}))
+	return srv.URL, startedCh, releaseCh
+}
+
+func newDrainTestClient() *Client {
+	cfg := testClientConfig()
+	cfg.H3Enabled = false
+	return New(cfg, zap.NewNop())
+}
+
+func TestCloseContext_WaitsForInflightRequest(t *testing.T) {
+	url, started, release := newSlowServer(t)
+	c := newDrainTestClient()
+
+	type result struct {
+		body string
+		err  error
+	}
+	done := make(chan result, 1)
+	go func() {
+		resp, err := c.HTTPClient().Get(url)
+		if err != nil {
+			done <- result{err: err}
+			return
+		}
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		done <- result{body: string(body), err: err}
+	}()
+	<-started
+
+	closed := make(chan error, 1)
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		closed <- c.CloseContext(ctx)
+	}()
+
+	select {
+	case err := <-closed:
+		t.Fatalf("expected Close to wait for the in-flight request, returned %v", err)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	if _, err := c.HTTPClient().Get(url); !errors.Is(err, ErrClientClosed) {
+		t.Errorf("expected new requests to fail with ErrClientClosed, got %v", err)
+	}
+
+	close(release)
+	res := <-done
+	if res.err != nil || res.body != "done" {
+		t.Errorf("expected in-flight request to complete, got body %q err %v", res.body, res.err)
+	}
+	if err := <-closed; err != nil {
+		t.Errorf("expected clean close, got %v", err)
+	}
+}
+
+func TestCloseContext_DeadlineExceeded(t *testing.T) {
+	url, started, release := newSlowServer(t)
+	defer close(release)
+	c := newDrainTestClient()
+
+	go func() {
+		if resp, err := c.HTTPClient().Get(url); err == nil {
+			resp.Body.Close()
+		}
+	}()
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	err := c.CloseContext(ctx)
+
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected deadline error with a request still in flight, got %v", err)
+	}
+}
+
+func TestClose_NoInflightRequests(t *testing.T) {
+	c := newDrainTestClient()
+	if err := c.Close(); err != nil {
+		t.Errorf("expected clean close, got %v", err)
+	}
+}
@@ -14,20 +14,51 @@ type Config struct {
 	TLSConfig *tls.Config
 	// H3Enabled controls whether HTTP/3 is attempted. Default true.
 	H3Enabled bool
+	// H3Discovery starts the client on HTTP/2 and upgrades to HTTP/3 once a
+	// response advertises it via Alt-Svc. Requires H3Enabled. Regardless of this
+	// setting, advertised HTTP/3 endpoints are used when dialing and an Alt-Svc
+	// advertisement re-enables HTTP/3 unless the circuit breaker is open.
+	H3Discovery bool
+	// H3Race races HTTP/3 and HTTP/2 connections on the first request to each
+	// origin and prefers whichever connects first, instead of assuming HTTP/3
+	// works. Requires H3Enabled.
+	H3Race bool
+	// H3RaceHeadStart is how long the HTTP/3 attempt runs before the HTTP/2
+	// attempt starts, so HTTP/3 wins when both work. Default 250ms.
+	H3RaceHeadStart time.Duration
 	// H3Timeout is how long to wait for an HTTP/3 connection before falling back. Default 5s.
 	H3Timeout time.Duration
-	// H3RetryInterval controls how often to retry HTTP/3 after a fallback. Default 5m.
+	// H3RetryInterval controls how long HTTP/3 stays disabled after the circuit
+	// breaker opens. Each consecutive re-open doubles it, up to H3MaxRetryInterval.
+	// Default 5m.
 	H3RetryInterval time.Duration
+	// H3MaxRetryInterval caps the widening retry interval. Default 1h.
+	H3MaxRetryInterval time.Duration
+	// H3FailureThreshold is the number of consecutive HTTP/3 failures that open the
+	// circuit breaker and fall back to HTTP/2. Values below 1 are treated as 1.
+	// Default 3.
+	H3FailureThreshold int
 	// RequestTimeout is the default request timeout. Default 30s.
 	RequestTimeout time.Duration
+	// DrainTimeout is how long Close waits for in-flight requests before closing
+	// connections. Zero closes immediately. Default 10s.
+	DrainTimeout time.Duration
+	// Retry, if set, makes HTTPClient retry idempotent requests that fail with a
+	// transport error or a 502, 503 or 504 response, with exponential backoff.
+	// Backoff never extends past the request's context deadline. Default nil
+	// (no retries).
+	Retry *RetryConfig
 }
 
 // DefaultClientConfig returns a Config with sensible defaults.
 func DefaultClientConfig() Config {
 	return Config{
-		H3Enabled:       true,
-		H3Timeout:       5 * time.Second,
-		H3RetryInterval: 5 * time.Minute,
-		RequestTimeout:  30 * time.Second,
+		H3Enabled:          true,
+		H3Timeout:          5 * time.Second,
+		H3RetryInterval:    5 * time.Minute,
+		H3MaxRetryInterval: time.Hour,
+		H3FailureThreshold: 3,
+		RequestTimeout:     30 * time.Second,
+		DrainTimeout:       10 * time.Second,
 	}
 }
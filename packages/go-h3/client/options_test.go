@@ -17,6 +17,12 @@ func TestDefaultClientConfig(t *testing.T) {
 	if cfg.H3RetryInterval != 5*time.Minute {
 		t.Errorf("expected H3RetryInterval 5m, got %v", cfg.H3RetryInterval)
 	}
+	if cfg.H3FailureThreshold != 3 {
+		t.Errorf("expected H3FailureThreshold 3, got %d", cfg.H3FailureThreshold)
+	}
+	if cfg.DrainTimeout != 10*time.Second {
+		t.Errorf("expected DrainTimeout 10s, got %v", cfg.DrainTimeout)
+	}
 	if cfg.RequestTimeout != 30*time.Second {
 		t.Errorf("expected RequestTimeout 30s, got %v", cfg.RequestTimeout)
 	}
@@ -0,0 +1,176 @@
+package client
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"net"
+	"time"
+
+	"github.com/quic-go/quic-go"
+	"go.uber.org/zap"
+)
+
+// defaultH3RaceHeadStart is how long HTTP/3 may try to connect before the HTTP/2
+// attempt starts, following the connection attempt delay of RFC 8305.
+const defaultH3RaceHeadStart = 250 * time.Millisecond
+
+// raceResult is the outcome of one connection attempt in a protocol race. Exactly
+// one of h3 and h2 is set when err is nil.
+type raceResult struct {
+	protocol string
+	h3       *quic.Conn
+	h2       net.Conn
+	err      error
+}
+
+func (r raceResult) close() {
+	if r.h3 != nil {
+		_ = r.h3.CloseWithError(0, "")
+	}
+	if r.h2 != nil {
+		_ = r.h2.Close()
+	}
+}
+
+// maybeRace races HTTP/3 and HTTP/2 connections to origin on the first request to
+// it when H3Race is enabled. The winner becomes the preferred protocol and its
+// connection is handed to the matching transport for the request that triggered
+// the race; the loser is cancelled and closed.
+func (c *Client) maybeRace(ctx context.Context, origin string) {
+	if !c.cfg.H3Race || !c.cfg.H3Enabled {
+		return
+	}
+	c.mu.Lock()
+	if c.raced[origin] {
+		c.mu.Unlock()
+		return
+	}
+	c.raced[origin] = true
+	c.mu.Unlock()
+
+	winner, err := c.race(ctx, origin)
+	if err != nil {
+		c.logger.Warn("protocol race failed", zap.String("origin", origin), zap.Error(err))
+		return
+	}
+	c.logger.Info("protocol race won", zap.String("origin", origin), zap.String("protocol", winner.protocol))
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.useH3 = winner.protocol == "h3"
+	if winner.h3 != nil {
+		c.predialedH3[origin] = winner.h3
+	} else {
+		c.predialedH2[origin] = winner.h2
+	}
+}
+
+// race dials origin over QUIC immediately and over TCP+TLS once the HTTP/3 head
+// start elapses or the QUIC attempt fails, and returns the first connection
+// established.
+func (c *Client) race(ctx context.Context, origin string) (raceResult, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	results := make(chan raceResult, 2)
+
+	go func() {
+		conn, err := c.dialH3(ctx, origin, c.h3TLSConfig(origin), c.h3Transport.QUICConfig)
+		results <- raceResult{protocol: "h3", h3: conn, err: err}
+	}()
+
+	headStart := c.cfg.H3RaceHeadStart
+	if headStart <= 0 {
+		headStart = defaultH3RaceHeadStart
+	}
+	timer := time.NewTimer(headStart)
+	defer timer.Stop()
+
+	startH2 := func() {
+		go func() {
+			conn, err := c.dialH2TLS(ctx, "tcp", origin)
+			results <- raceResult{protocol: "h2", h2: conn, err: err}
+		}()
+	}
+
+	pending, h2Started := 1, false
+	var errs []error
+	for pending > 0 || !h2Started {
+		select {
+		case <-timer.C:
+			if !h2Started {
+				h2Started = true
+				pending++
+				startH2()
+			}
+		case res := <-results:
+			pending--
+			if res.err == nil {
+				cancel()
+				go closeRaceLosers(results, pending)
+				return res, nil
+			}
+			errs = append(errs, res.err)
+			if !h2Started {
+				h2Started = true
+				pending++
+				startH2()
+			}
+		}
+	}
+	cancel()
+	return raceResult{}, errors.Join(errs...)
+}
+
+// closeRaceLosers waits for the remaining attempts of a finished race and closes
+// any connection that was established after the winner.
+func closeRaceLosers(results <-chan raceResult, pending int) {
+	for ; pending > 0; pending-- {
+		if res := <-results; res.err == nil {
+			res.close()
+		}
+	}
+}
+
+// h3TLSConfig returns the TLS config the HTTP/3 transport would use for origin.
+func (c *Client) h3TLSConfig(origin string) *tls.Config {
+	cfg := c.h3Transport.TLSClientConfig.Clone()
+	if cfg.ServerName == "" {
+		cfg.ServerName, _, _ = net.SplitHostPort(origin)
+	}
+	cfg.NextProtos = []string{"h3"}
+	return cfg
+}
+
+// dialH2TLS dials a TLS connection for the HTTP/2 transport, using the connection
+// won by a protocol race for addr if there is one.
+func (c *Client) dialH2TLS(ctx context.Context, network, addr string) (net.Conn, error) {
+	c.mu.Lock()
+	conn, ok := c.predialedH2[addr]
+	delete(c.predialedH2, addr)
+	c.mu.Unlock()
+	if ok {
+		return conn, nil
+	}
+
+	cfg := c.h2Transport.TLSClientConfig.Clone()
+	if cfg.ServerName == "" {
+		cfg.ServerName, _, _ = net.SplitHostPort(addr)
+	}
+	cfg.NextProtos = []string{"h2", "http/1.1"}
+	d := &tls.Dialer{Config: cfg}
+	return d.DialContext(ctx, network, addr)
+}
+
+// closePredialed closes race winners that were never used.
+func (c *Client) closePredialed() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for addr, conn := range c.predialedH2 {
+		_ = conn.Close()
+		delete(c.predialedH2, addr)
+	}
+	for addr, conn := range c.predialedH3 {
+		_ = conn.CloseWithError(0, "")
+		delete(c.predialedH3, addr)
+	}
+}
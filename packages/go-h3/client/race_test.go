@@ -0,0 +1,88 @@
+package client
+
+import (
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+func newRaceTestClient() *Client {
+	cfg := testClientConfig()
+	cfg.H3Race = true
+	cfg.H3RaceHeadStart = 20 * time.Millisecond
+	// Long enough that the QUIC attempt against an H2-only server is still
+	// pending when HTTP/2 wins, so the race has a loser to cancel.
+	cfg.H3Timeout = 5 * time.Second
+	return New(cfg, zap.NewNop())
+}
+
+func TestRace_H2OnlyServerPicksH2(t *testing.T) {
+	srv := newH2OnlyServer(t, protoHandler())
+	c := newRaceTestClient()
+	defer c.Close()
+
+	start := time.Now()
+	resp, err := c.HTTPClient().Get(srv.URL)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	resp.Body.Close()
+
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("expected HTTP/2 to win without waiting for the QUIC timeout, took %v", elapsed)
+	}
+	if resp.ProtoMajor != 2 {
+		t.Errorf("expected HTTP/2 response, got %s", resp.Proto)
+	}
+	if c.Protocol() != "h2" {
+		t.Errorf("expected h2 to be preferred, got %s", c.Protocol())
+	}
+	if got := c.Stats(); got.H3Requests != 0 || got.H3Failures != 0 {
+		t.Errorf("expected no HTTP/3 request or failure after losing the race, got %+v", got)
+	}
+}
+
+func TestRace_DualServerPrefersH3(t *testing.T) {
+	srv := newDualServer(t, protoHandler())
+	c := newRaceTestClient()
+	defer c.Close()
+
+	resp, err := c.HTTPClient().Get(srv.URL)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	resp.Body.Close()
+
+	if resp.ProtoMajor != 3 {
+		t.Errorf("expected HTTP/3 response, got %s", resp.Proto)
+	}
+	if c.Protocol() != "h3" {
+		t.Errorf("expected h3 to be preferred, got %s", c.Protocol())
+	}
+
+	c.mu.RLock()
+	leftover := len(c.predialedH2) + len(c.predialedH3)
+	c.mu.RUnlock()
+	if leftover != 0 {
+		t.Errorf("expected the race winner to be used by the request, %d connections left over", leftover)
+	}
+}
+
+func TestRace_OncePerOrigin(t *testing.T) {
+	srv := newH2OnlyServer(t, protoHandler())
+	c := newRaceTestClient()
+	defer c.Close()
+
+	for i := 0; i < 2; i++ {
+		resp, err := c.HTTPClient().Get(srv.URL)
+		if err != nil {
+			t.Fatalf("request %d failed: %v", i+1, err)
+		}
+		resp.Body.Close()
+	}
+
+	if got := c.Stats(); got.H2Requests != 2 || got.H3Requests != 0 {
+		t.Errorf("expected both requests over HTTP/2 after a single race, got %+v", got)
+	}
+}
@@ -30,8 +30,9 @@ func DefaultRetryConfig() RetryConfig {
 }
 
 // DoWithRetry executes fn with exponential backoff retries.
-// It calls the client's MarkH3Failed on the first failure and
-// falls back to HTTP/2 for subsequent attempts.
+// Each failure while the client prefers HTTP/3 is reported to its circuit
+// breaker via MarkH3Failed, so attempts fall back to HTTP/2 once the breaker
+// opens.
 func DoWithRetry[T any](ctx context.Context, c *Client, rcfg RetryConfig, logger *zap.Logger, fn func() (T, error)) (T, error) {
 	var lastErr error
 	var zero T
@@ -45,8 +46,7 @@ func DoWithRetry[T any](ctx context.Context, c *Client, rcfg RetryConfig, logger
 		}
 		lastErr = err
 
-		// On first failure with H3, mark it as failed to trigger fallback.
-		if attempt == 0 && c.Protocol() == "h3" {
+		if c.Protocol() == "h3" {
 			c.MarkH3Failed()
 		}
 
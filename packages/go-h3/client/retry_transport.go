@@ -0,0 +1,98 @@
+package client
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// retryTransport retries idempotent requests that fail with a transport error or a
+// 502, 503 or 504 response, waiting calcBackoff between attempts. It never sleeps
+// past the request context's deadline.
+type retryTransport struct {
+	next   http.RoundTripper
+	cfg    RetryConfig
+	logger *zap.Logger
+	// sleep waits for d or until ctx is done; replaced in tests.
+	sleep func(ctx context.Context, d time.Duration) error
+}
+
+func newRetryTransport(next http.RoundTripper, cfg RetryConfig, logger *zap.Logger) *retryTransport {
+	return &retryTransport{next: next, cfg: cfg, logger: logger, sleep: sleepContext}
+}
+
+func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if !isReplayable(req) {
+		return t.next.RoundTrip(req)
+	}
+	ctx := req.Context()
+
+	for attempt := 0; ; attempt++ {
+		resp, err := t.next.RoundTrip(req)
+		if !shouldRetry(resp, err) || attempt >= t.cfg.MaxRetries || ctx.Err() != nil {
+			return resp, err
+		}
+
+		backoff := calcBackoff(t.cfg, attempt)
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < backoff {
+			return resp, err
+		}
+		next, rerr := rewindRequest(req)
+		if rerr != nil {
+			return resp, err
+		}
+
+		fields := []zap.Field{
+			zap.String("method", req.Method),
+			zap.String("url", req.URL.Redacted()),
+			zap.Int("attempt", attempt+1),
+			zap.Int("max_retries", t.cfg.MaxRetries),
+			zap.Duration("backoff", backoff),
+		}
+		if err != nil {
+			fields = append(fields, zap.Error(err))
+		} else {
+			fields = append(fields, zap.Int("status", resp.StatusCode))
+			drainBody(resp)
+		}
+		t.logger.Warn("request failed, retrying", fields...)
+
+		if err := t.sleep(ctx, backoff); err != nil {
+			return nil, err
+		}
+		req = next
+	}
+}
+
+// shouldRetry reports whether an attempt failed in a way worth retrying.
+func shouldRetry(resp *http.Response, err error) bool {
+	if err != nil {
+		return true
+	}
+	switch resp.StatusCode {
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// drainBody discards and closes a response that is being replaced by a retry so
+// its connection can be reused.
+func drainBody(resp *http.Response) {
+	_, _ = io.CopyN(io.Discard, resp.Body, 4<<10)
+	_ = resp.Body.Close()
+}
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
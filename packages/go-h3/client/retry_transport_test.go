@@ -0,0 +1,142 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// failingServer responds 503 to its first `failures` requests and 200 afterward.
+func failingServer(t *testing.T, failures int32, calls *atomic.Int32) string {
+	t.Helper()
+	srv := newH2OnlyServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) <= failures {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = w.Write([]byte("ok"))
+	}))
+	return srv.URL
+}
+
+// newRetryTestClient returns an H2-only client with retries enabled and the
+// backoff sleeps recorded instead of performed.
+func newRetryTestClient(t *testing.T, rcfg RetryConfig) (*Client, *[]time.Duration) {
+	t.Helper()
+	cfg := testClientConfig()
+	cfg.H3Enabled = false
+	cfg.Retry = &rcfg
+	c := New(cfg, zap.NewNop())
+	t.Cleanup(func() { _ = c.Close() })
+
+	var sleeps []time.Duration
+	c.HTTPClient().Transport.(*drainTransport).next.(*retryTransport).sleep = func(_ context.Context, d time.Duration) error {
+		sleeps = append(sleeps, d)
+		return nil
+	}
+	return c, &sleeps
+}
+
+func testRetryConfig() RetryConfig {
+	return RetryConfig{
+		MaxRetries:     3,
+		InitialBackoff: 10 * time.Millisecond,
+		MaxBackoff:     time.Second,
+		Multiplier:     2.0,
+	}
+}
+
+func TestRetryTransport_RetriesUntilSuccess(t *testing.T) {
+	var calls atomic.Int32
+	url := failingServer(t, 2, &calls)
+	c, sleeps := newRetryTestClient(t, testRetryConfig())
+
+	resp, err := c.HTTPClient().Get(url)
+	if err != nil {
+		t.Fatalf("expected success, got %v", err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("expected 200, got %d", resp.StatusCode)
+	}
+	if calls.Load() != 3 {
+		t.Errorf("expected 3 attempts, got %d", calls.Load())
+	}
+	want := []time.Duration{10 * time.Millisecond, 20 * time.Millisecond}
+	if len(*sleeps) != len(want) {
+		t.Fatalf("expected backoff schedule %v, got %v", want, *sleeps)
+	}
+	for i := range want {
+		if (*sleeps)[i] != want[i] {
+			t.Errorf("backoff %d: expected %v, got %v", i, want[i], (*sleeps)[i])
+		}
+	}
+}
+
+func TestRetryTransport_ReturnsLastResponseWhenExhausted(t *testing.T) {
+	var calls atomic.Int32
+	url := failingServer(t, 10, &calls)
+	rcfg := testRetryConfig()
+	rcfg.MaxRetries = 1
+	c, _ := newRetryTestClient(t, rcfg)
+
+	resp, err := c.HTTPClient().Get(url)
+	if err != nil {
+		t.Fatalf("expected final response, got %v", err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("expected 503, got %d", resp.StatusCode)
+	}
+	if calls.Load() != 2 {
+		t.Errorf("expected 2 attempts, got %d", calls.Load())
+	}
+}
+
+func TestRetryTransport_StopsAtContextDeadline(t *testing.T) {
+	var calls atomic.Int32
+	url := failingServer(t, 10, &calls)
+	rcfg := testRetryConfig()
+	rcfg.InitialBackoff = time.Minute
+	rcfg.MaxBackoff = time.Minute
+	c, sleeps := newRetryTestClient(t, rcfg)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	resp, err := c.HTTPClient().Do(req)
+	if err != nil {
+		t.Fatalf("expected first response, got %v", err)
+	}
+	resp.Body.Close()
+
+	if calls.Load() != 1 {
+		t.Errorf("expected no retry when backoff exceeds the deadline, got %d attempts", calls.Load())
+	}
+	if len(*sleeps) != 0 {
+		t.Errorf("expected no backoff sleeps, got %v", *sleeps)
+	}
+}
+
+func TestRetryTransport_DoesNotRetryNonIdempotent(t *testing.T) {
+	var calls atomic.Int32
+	url := failingServer(t, 10, &calls)
+	c, _ := newRetryTestClient(t, testRetryConfig())
+
+	resp, err := c.HTTPClient().Post(url, "text/plain", strings.NewReader("x"))
+	if err != nil {
+		t.Fatalf("expected response, got %v", err)
+	}
+	resp.Body.Close()
+
+	if calls.Load() != 1 {
+		t.Errorf("expected POST to be sent once, got %d attempts", calls.Load())
+	}
+}
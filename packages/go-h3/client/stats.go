@@ -0,0 +1,35 @@
+package client
+
+import "sync/atomic"
+
+// Stats is a snapshot of the client's per-protocol request counters.
+type Stats struct {
+	// H3Requests counts requests sent over HTTP/3, including failed attempts.
+	H3Requests uint64
+	// H2Requests counts requests sent over HTTP/2, including fallback retries.
+	H2Requests uint64
+	// H3Failures counts HTTP/3 failures reported to the circuit breaker.
+	H3Failures uint64
+	// Upgrades counts switches from HTTP/2 to HTTP/3, whether from the breaker
+	// re-attempting HTTP/3 or an Alt-Svc advertisement.
+	Upgrades uint64
+}
+
+// clientStats holds the live counters behind Stats.
+type clientStats struct {
+	h3Requests atomic.Uint64
+	h2Requests atomic.Uint64
+	h3Failures atomic.Uint64
+	upgrades   atomic.Uint64
+}
+
+// Stats returns a snapshot of the client's request counters, e.g. for exporting
+// as Prometheus metrics.
+func (c *Client) Stats() Stats {
+	return Stats{
+		H3Requests: c.stats.h3Requests.Load(),
+		H2Requests: c.stats.h2Requests.Load(),
+		H3Failures: c.stats.h3Failures.Load(),
+		Upgrades:   c.stats.upgrades.Load(),
+	}
+}
@@ -0,0 +1,72 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+// doGet sends a GET through c and closes the response.
+func doGet(t *testing.T, c *Client, ctx context.Context, url string) {
+	t.Helper()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	resp, err := c.HTTPClient().Do(req)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	resp.Body.Close()
+}
+
+func TestStats_CountsRequestsPerProtocol(t *testing.T) {
+	srv := newDualServer(t, protoHandler())
+	c := New(testClientConfig(), zap.NewNop())
+	defer c.Close()
+
+	doGet(t, c, context.Background(), srv.URL)
+	doGet(t, c, context.Background(), srv.URL)
+	doGet(t, c, WithProtocol(context.Background(), "h2"), srv.URL)
+
+	want := Stats{H3Requests: 2, H2Requests: 1}
+	if got := c.Stats(); got != want {
+		t.Errorf("expected %+v, got %+v", want, got)
+	}
+}
+
+func TestStats_CountsFallback(t *testing.T) {
+	srv := newH2OnlyServer(t, protoHandler())
+	c := New(testClientConfig(), zap.NewNop())
+	defer c.Close()
+
+	doGet(t, c, context.Background(), srv.URL)
+	doGet(t, c, context.Background(), srv.URL)
+
+	want := Stats{H3Requests: 1, H2Requests: 2, H3Failures: 1}
+	if got := c.Stats(); got != want {
+		t.Errorf("expected %+v, got %+v", want, got)
+	}
+}
+
+func TestStats_CountsUpgrades(t *testing.T) {
+	url := newAltSvcServer(t)
+	cfg := testClientConfig()
+	cfg.H3Discovery = true
+	c := New(cfg, zap.NewNop())
+	defer c.Close()
+
+	doGet(t, c, context.Background(), url)
+	doGet(t, c, context.Background(), url)
+
+	want := Stats{H3Requests: 1, H2Requests: 1, Upgrades: 1}
+	if got := c.Stats(); got != want {
+		t.Errorf("expected %+v, got %+v", want, got)
+	}
+
+	c.MarkH3Failed()
+	expireRetryInterval(c)
+	c.MaybeRetryH3()
+	if got := c.Stats(); got.H3Failures != 1 || got.Upgrades != 2 {
+		t.Errorf("expected 1 failure and 2 upgrades after breaker re-attempt, got %+v", got)
+	}
+}
@@ -0,0 +1,122 @@
+package client
+
+import (
+	"context"
+	"net/http"
+
+	"go.uber.org/zap"
+)
+
+// protocolKey is the context key for per-request protocol overrides.
+type protocolKey struct{}
+
+// WithProtocol returns a context that forces requests sent through
+// Client.HTTPClient to use protocol ("h2" or "h3") regardless of the client's
+// current preference. Forced requests do not fall back or affect the shared
+// preference: a failed forced H3 request returns its error. Other values are
+// ignored.
+func WithProtocol(ctx context.Context, protocol string) context.Context {
+	return context.WithValue(ctx, protocolKey{}, protocol)
+}
+
+// protocolFromContext returns the protocol forced by WithProtocol, if any.
+func protocolFromContext(ctx context.Context) string {
+	switch p, _ := ctx.Value(protocolKey{}).(string); p {
+	case "h2", "h3":
+		return p
+	}
+	return ""
+}
+
+// protocolTransport sends each request over the client's preferred protocol and
+// feeds HTTP/3 outcomes to the circuit breaker. When an HTTP/3 round trip fails at
+// the connection level it marks H3 failed and, for idempotent requests,
+// transparently re-issues the request over HTTP/2.
+type protocolTransport struct {
+	c *Client
+}
+
+func (t *protocolTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	c := t.c
+	switch protocolFromContext(req.Context()) {
+	case "h2":
+		return c.roundTripH2(req)
+	case "h3":
+		return c.roundTripH3(req)
+	}
+
+	c.maybeRace(req.Context(), authorityAddr(req))
+	c.MaybeRetryH3()
+	if c.Protocol() != "h3" {
+		return c.roundTripH2(req)
+	}
+
+	resp, err := c.roundTripH3(req)
+	if err == nil {
+		c.MarkH3Succeeded()
+		return resp, nil
+	}
+	if req.Context().Err() != nil {
+		return nil, err
+	}
+	c.MarkH3Failed()
+	if !isReplayable(req) {
+		return nil, err
+	}
+
+	retry, rerr := rewindRequest(req)
+	if rerr != nil {
+		return nil, err
+	}
+	c.logger.Warn("HTTP/3 request failed, retrying over HTTP/2",
+		zap.String("method", req.Method),
+		zap.String("url", req.URL.Redacted()),
+		zap.Error(err),
+	)
+	return c.roundTripH2(retry)
+}
+
+// roundTripH3 sends req over HTTP/3.
+func (c *Client) roundTripH3(req *http.Request) (*http.Response, error) {
+	c.stats.h3Requests.Add(1)
+	return c.h3Transport.RoundTrip(req)
+}
+
+// roundTripH2 sends req over HTTP/2 and records any HTTP/3 endpoint the response
+// advertises.
+func (c *Client) roundTripH2(req *http.Request) (*http.Response, error) {
+	c.stats.h2Requests.Add(1)
+	resp, err := c.h2Transport.RoundTrip(req)
+	if err == nil {
+		c.recordAltSvc(req, resp)
+	}
+	return resp, err
+}
+
+// isReplayable reports whether req may be sent again after a failed attempt: the
+// method must be idempotent (or the request carry an idempotency key, as net/http
+// treats it) and the body must be absent or rewindable.
+func isReplayable(req *http.Request) bool {
+	switch req.Method {
+	case "", http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace, http.MethodPut, http.MethodDelete:
+	default:
+		if req.Header.Get("Idempotency-Key") == "" && req.Header.Get("X-Idempotency-Key") == "" {
+			return false
+		}
+	}
+	return req.Body == nil || req.Body == http.NoBody || req.GetBody != nil
+}
+
+// rewindRequest returns a copy of req with a fresh body for another attempt.
+func rewindRequest(req *http.Request) (*http.Request, error) {
+	retry := req.Clone(req.Context())
+	if req.Body == nil || req.Body == http.NoBody {
+		return retry, nil
+	}
+	body, err := req.GetBody()
+	if err != nil {
+		return nil, err
+	}
+	retry.Body = body
+	return retry, nil
+}
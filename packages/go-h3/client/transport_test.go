@@ -0,0 +1,245 @@
+package client
+
+import (
+	"context"
+	"crypto/tls"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/quic-go/quic-go/http3"
+	"go.uber.org/zap"
+)
+
+// newH2OnlyServer starts a TLS server that speaks HTTP/2 but has no HTTP/3
+// listener, so QUIC handshakes to its address fail.
+func newH2OnlyServer(t *testing.T, handler http.Handler) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewUnstartedServer(handler)
+	srv.EnableHTTP2 = true
+	srv.StartTLS()
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+// newDualServer starts a TLS server that speaks HTTP/2 over TCP and HTTP/3 over
+// UDP on the same port.
+func newDualServer(t *testing.T, handler http.Handler) *httptest.Server {
+	t.Helper()
+	srv := newH2OnlyServer(t, handler)
+	serveH3(t, srv.Listener.Addr().String(), srv.TLS.Certificates, handler)
+	return srv
+}
+
+// serveH3 starts an HTTP/3 server on the UDP address addr and returns the bound
+// address.
+func serveH3(t *testing.T, addr string, certs []tls.Certificate, handler http.Handler) string {
+	t.Helper()
+	conn, err := net.ListenPacket("udp", addr)
+	if err != nil {
+		t.Skipf("cannot bind UDP %s: %v", addr, err)
+	}
+	h3 := &http3.Server{
+		Handler: handler,
+		TLSConfig: &tls.Config{
+			Certificates: certs,
+			MinVersion:   tls.VersionTLS13,
+			NextProtos:   []string{"h3"},
+		},
+	}
+	go func() { _ = h3.Serve(conn) }()
+	t.Cleanup(func() {
+		_ = h3.Close()
+		_ = conn.Close()
+	})
+	return conn.LocalAddr().String()
+}
+
+// testClientConfig returns a Config that trusts any server certificate, gives up
+// on QUIC handshakes quickly and falls back to HTTP/2 on the first H3 failure.
+func testClientConfig() Config {
+	cfg := DefaultClientConfig()
+	cfg.TLSConfig = &tls.Config{InsecureSkipVerify: true, MinVersion: tls.VersionTLS13} //nolint:gosec // test-only self-signed cert
+	cfg.H3Timeout = 200 * time.Millisecond
+	cfg.H3FailureThreshold = 1
+	cfg.RequestTimeout = 5 * time.Second
+	return cfg
+}
+
+func protoHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.Copy(io.Discard, r.Body)
+		_, _ = w.Write([]byte(r.Proto))
+	})
+}
+
+func TestProtocolTransport_FallsBackToH2ForIdempotentRequest(t *testing.T) {
+	srv := newH2OnlyServer(t, protoHandler())
+	c := New(testClientConfig(), zap.NewNop())
+	defer c.Close()
+
+	if c.Protocol() != "h3" {
+		t.Fatalf("expected initial protocol h3, got %s", c.Protocol())
+	}
+
+	resp, err := c.HTTPClient().Get(srv.URL)
+	if err != nil {
+		t.Fatalf("expected GET to succeed via H2 fallback, got %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.ProtoMajor != 2 {
+		t.Errorf("expected HTTP/2 response, got %s", resp.Proto)
+	}
+	if c.Protocol() != "h2" {
+		t.Errorf("expected protocol h2 after fallback, got %s", c.Protocol())
+	}
+}
+
+func TestProtocolTransport_ReplaysRewindableBody(t *testing.T) {
+	var got atomic.Value
+	srv := newH2OnlyServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		got.Store(string(body))
+	}))
+	c := New(testClientConfig(), zap.NewNop())
+	defer c.Close()
+
+	req, _ := http.NewRequest(http.MethodPut, srv.URL, strings.NewReader("payload"))
+	resp, err := c.HTTPClient().Do(req)
+	if err != nil {
+		t.Fatalf("expected PUT to succeed via H2 fallback, got %v", err)
+	}
+	resp.Body.Close()
+
+	if got.Load() != "payload" {
+		t.Errorf("expected replayed body %q, got %v", "payload", got.Load())
+	}
+}
+
+func TestProtocolTransport_DoesNotReplayNonIdempotentRequest(t *testing.T) {
+	var calls atomic.Int32
+	srv := newH2OnlyServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+	}))
+	c := New(testClientConfig(), zap.NewNop())
+	defer c.Close()
+
+	resp, err := c.HTTPClient().Post(srv.URL, "text/plain", strings.NewReader("payload"))
+	if err == nil {
+		resp.Body.Close()
+		t.Fatal("expected POST over failed H3 to return an error")
+	}
+	if calls.Load() != 0 {
+		t.Errorf("expected POST not to be re-sent over H2, server saw %d calls", calls.Load())
+	}
+	if c.Protocol() != "h2" {
+		t.Errorf("expected protocol h2 after failure, got %s", c.Protocol())
+	}
+
+	resp, err = c.HTTPClient().Post(srv.URL, "text/plain", strings.NewReader("payload"))
+	if err != nil {
+		t.Fatalf("expected next POST to use H2, got %v", err)
+	}
+	resp.Body.Close()
+	if calls.Load() != 1 {
+		t.Errorf("expected 1 call over H2, got %d", calls.Load())
+	}
+}
+
+func TestIsReplayable(t *testing.T) {
+	tests := []struct {
+		name   string
+		method string
+		body   io.Reader
+		header string
+		want   bool
+	}{
+		{"GET", http.MethodGet, nil, "", true},
+		{"PUT with body", http.MethodPut, strings.NewReader("x"), "", true},
+		{"POST", http.MethodPost, strings.NewReader("x"), "", false},
+		{"POST with idempotency key", http.MethodPost, strings.NewReader("x"), "key-1", true},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			req, _ := http.NewRequest(tc.method, "https://example.test", tc.body)
+			if tc.header != "" {
+				req.Header.Set("Idempotency-Key", tc.header)
+			}
+			if got := isReplayable(req); got != tc.want {
+				t.Errorf("expected %v, got %v", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestWithProtocol_ForcesH3WithoutChangingPreference(t *testing.T) {
+	srv := newDualServer(t, protoHandler())
+	cfg := testClientConfig()
+	cfg.H3Enabled = false
+	c := New(cfg, zap.NewNop())
+	defer c.Close()
+
+	req, _ := http.NewRequestWithContext(WithProtocol(context.Background(), "h3"), http.MethodGet, srv.URL, nil)
+	resp, err := c.HTTPClient().Do(req)
+	if err != nil {
+		t.Fatalf("forced H3 request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.ProtoMajor != 3 {
+		t.Errorf("expected HTTP/3 response, got %s", resp.Proto)
+	}
+	if c.Protocol() != "h2" {
+		t.Errorf("expected global preference to stay h2, got %s", c.Protocol())
+	}
+}
+
+func TestWithProtocol_ForcesH2WithoutChangingPreference(t *testing.T) {
+	srv := newDualServer(t, protoHandler())
+	c := New(testClientConfig(), zap.NewNop())
+	defer c.Close()
+
+	req, _ := http.NewRequestWithContext(WithProtocol(context.Background(), "h2"), http.MethodGet, srv.URL, nil)
+	resp, err := c.HTTPClient().Do(req)
+	if err != nil {
+		t.Fatalf("forced H2 request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.ProtoMajor != 2 {
+		t.Errorf("expected HTTP/2 response, got %s", resp.Proto)
+	}
+	if c.Protocol() != "h3" {
+		t.Errorf("expected global preference to stay h3, got %s", c.Protocol())
+	}
+
+	resp, err = c.HTTPClient().Get(srv.URL)
+	if err != nil {
+		t.Fatalf("unforced request failed: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.ProtoMajor != 3 {
+		t.Errorf("expected unforced request to use HTTP/3, got %s", resp.Proto)
+	}
+}
+
+func TestWithProtocol_ForcedH3FailureDoesNotFallBack(t *testing.T) {
+	srv := newH2OnlyServer(t, protoHandler())
+	c := New(testClientConfig(), zap.NewNop())
+	defer c.Close()
+
+	req, _ := http.NewRequestWithContext(WithProtocol(context.Background(), "h3"), http.MethodGet, srv.URL, nil)
+	if resp, err := c.HTTPClient().Do(req); err == nil {
+		resp.Body.Close()
+		t.Fatal("expected forced H3 request to an H2-only server to fail")
+	}
+	if c.Protocol() != "h3" {
+		t.Errorf("expected global preference to stay h3, got %s", c.Protocol())
+	}
+}
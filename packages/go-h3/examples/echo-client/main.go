@@ -37,17 +37,11 @@ func main() {
 	url := fmt.Sprintf("%s/echo?msg=%s", cfg.BaseURL, *msg)
 	logger.Info("sending request", zap.String("url", url), zap.String("protocol", c.Protocol()))
 
+	// HTTPClient falls back to H2 and re-issues the GET if H3 fails.
 	resp, err := c.HTTPClient().Get(url)
 	if err != nil {
-		// If H3 failed, try H2 fallback.
-		c.MarkH3Failed()
-		logger.Warn("H3 request failed, retrying with H2", zap.Error(err))
-
-		resp, err = c.HTTPClient().Get(url)
-		if err != nil {
-			logger.Fatal("request failed", zap.Error(err))
-			os.Exit(1)
-		}
+		logger.Fatal("request failed", zap.Error(err))
+		os.Exit(1)
 	}
 	defer resp.Body.Close()
 
@@ -3,12 +3,8 @@
 package main
 
 import (
-	"context"
 	"fmt"
 	"net/http"
-	"os"
-	"os/signal"
-	"syscall"
 
 	"connectrpc.com/connect"
 	"go.uber.org/zap"
@@ -31,33 +27,22 @@ func main() {
 		server.NewLoggingInterceptor(logger),
 	}
 
-	srv, err := server.New(cfg, logger)
-	if err != nil {
-		logger.Fatal("failed to create server", zap.Error(err))
-	}
-
-	// Register a simple echo handler at /echo.
-	srv.Mux().HandleFunc("/echo", func(w http.ResponseWriter, r *http.Request) {
-		msg := r.URL.Query().Get("msg")
-		if msg == "" {
-			msg = "hello"
-		}
-		w.Header().Set("Content-Type", "text/plain")
-		fmt.Fprintf(w, "echo: %s (protocol: %s)\n", msg, r.Proto)
-	})
-
-	// Health check.
-	srv.Mux().HandleFunc("/healthz", func(w http.ResponseWriter, _ *http.Request) {
-		w.WriteHeader(http.StatusOK)
-		fmt.Fprint(w, "ok")
-	})
-
-	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
-	defer cancel()
-
 	logger.Info("starting echo server")
-	if err := srv.Start(ctx); err != nil {
+	err = server.Run(cfg, logger, func(mux *http.ServeMux) {
+		// Register a simple echo handler at /echo.
+		mux.HandleFunc("/echo", func(w http.ResponseWriter, r *http.Request) {
+			msg := r.URL.Query().Get("msg")
+			if msg == "" {
+				msg = "hello"
+			}
+			w.Header().Set("Content-Type", "text/plain")
+			fmt.Fprintf(w, "echo: %s (protocol: %s)\n", msg, r.Proto)
+		})
+
+		// Liveness and readiness probes at /livez and /readyz.
+		server.NewHealthRegistry(0).RegisterHandlers(mux)
+	})
+	if err != nil {
 		logger.Fatal("server error", zap.Error(err))
-		os.Exit(1)
 	}
 }
@@ -0,0 +1,50 @@
+package server
+
+import (
+	"errors"
+	"net/http"
+
+	"golang.org/x/crypto/acme"
+	"golang.org/x/crypto/acme/autocert"
+)
+
+// ACMEConfig enables automatic certificate provisioning and renewal from an ACME
+// CA such as Let's Encrypt. Certificates are obtained on the first TLS handshake
+// for each domain and validated with the HTTP-01 challenge.
+type ACMEConfig struct {
+	// Domains are the host names certificates may be requested for. Required.
+	Domains []string
+	// CacheDir stores account keys and certificates across restarts. Empty keeps
+	// them in memory only, which risks hitting CA rate limits.
+	CacheDir string
+	// Email is the contact address registered with the CA account. Optional.
+	Email string
+	// DirectoryURL is the ACME directory endpoint. Defaults to Let's Encrypt
+	// production.
+	DirectoryURL string
+	// HTTPAddr is the listen address for HTTP-01 challenges. Defaults to ":80".
+	// Other plain HTTP requests on it are redirected to HTTPS.
+	HTTPAddr string
+}
+
+// newACMEManager builds the autocert manager for cfg and returns it with the
+// HTTP-01 challenge handler. Creating the handler opts the manager in to HTTP-01.
+func newACMEManager(cfg ACMEConfig) (*autocert.Manager, http.Handler, error) {
+	if len(cfg.Domains) == 0 {
+		return nil, nil, errors.New("ACME requires at least one domain")
+	}
+	directory := cfg.DirectoryURL
+	if directory == "" {
+		directory = autocert.DefaultACMEDirectory
+	}
+	m := &autocert.Manager{
+		Prompt:     autocert.AcceptTOS,
+		HostPolicy: autocert.HostWhitelist(cfg.Domains...),
+		Email:      cfg.Email,
+		Client:     &acme.Client{DirectoryURL: directory},
+	}
+	if cfg.CacheDir != "" {
+		m.Cache = autocert.DirCache(cfg.CacheDir)
+	}
+	return m, m.HTTPHandler(nil), nil
+}
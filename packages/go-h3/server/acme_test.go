@@ -0,0 +1,333 @@
+package server
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/quic-go/webtransport-go"
+	"go.uber.org/zap"
+)
+
+// mockACMECA is a minimal RFC 8555 CA that issues a certificate for a single
+// domain after validating an HTTP-01 challenge against challengeAddr. Request
+// signatures are not verified.
+type mockACMECA struct {
+	t      *testing.T
+	srv    *httptest.Server
+	domain string
+	caKey  *ecdsa.PrivateKey
+	caCert *x509.Certificate
+
+	mu            sync.Mutex
+	challengeAddr string
+	authzStatus   string
+	orderStatus   string
+	certPEM       []byte
+	validated     bool
+}
+
+const mockACMEToken = "mock-token"
+
+func newMockACMECA(t *testing.T, domain string) *mockACMECA {
+	t.Helper()
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("generate CA key: %v", err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "mock ACME CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(24 * time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &caKey.PublicKey, caKey)
+	if err != nil {
+		t.Fatalf("create CA certificate: %v", err)
+	}
+	caCert, _ := x509.ParseCertificate(der)
+
+	ca := &mockACMECA{
+		t:           t,
+		domain:      domain,
+		caKey:       caKey,
+		caCert:      caCert,
+		authzStatus: "pending",
+		orderStatus: "pending",
+	}
+	ca.srv = httptest.NewServer(http.HandlerFunc(ca.serveHTTP))
+	t.Cleanup(ca.srv.Close)
+	return ca
+}
+
+func (ca *mockACMECA) directoryURL() string { return ca.srv.URL + "/directory" }
+
+func (ca *mockACMECA) setChallengeAddr(addr string) {
+	ca.mu.Lock()
+	defer ca.mu.Unlock()
+	ca.challengeAddr = addr
+}
+
+func (ca *mockACMECA) challengeValidated() bool {
+	ca.mu.Lock()
+	defer ca.mu.Unlock()
+	return ca.validated
+}
+
+func (ca *mockACMECA) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Replay-Nonce", fmt.Sprintf("nonce-%d", time.Now().UnixNano()))
+	if r.URL.Path == "/directory" {
+		writeJSON(w, http.StatusOK, map[string]string{
+			"newNonce":   ca.srv.URL + "/nonce",
+			"newAccount": ca.srv.URL + "/account",
+			"newOrder":   ca.srv.URL + "/order",
+			"revokeCert": ca.srv.URL + "/revoke",
+			"keyChange":  ca.srv.URL + "/key-change",
+		})
+		return
+	}
+	if r.URL.Path == "/nonce" {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	payload := jwsPayload(ca.t, r)
+	ca.mu.Lock()
+	defer ca.mu.Unlock()
+	switch r.URL.Path {
+	case "/account":
+		w.Header().Set("Location", ca.srv.URL+"/account/1")
+		writeJSON(w, http.StatusCreated, map[string]string{"status": "valid"})
+	case "/order":
+		w.Header().Set("Location", ca.srv.URL+"/order/1")
+		writeJSON(w, http.StatusCreated, ca.order())
+	case "/order/1":
+		writeJSON(w, http.StatusOK, ca.order())
+	case "/authz/1":
+		if strings.Contains(string(payload), "deactivated") {
+			ca.authzStatus = "deactivated"
+		}
+		writeJSON(w, http.StatusOK, ca.authz())
+	case "/challenge/1":
+		ca.validateHTTP01()
+		writeJSON(w, http.StatusOK, ca.authz()["challenges"].([]map[string]string)[0])
+	case "/finalize/1":
+		var req struct {
+			CSR string `json:"csr"`
+		}
+		_ = json.Unmarshal(payload, &req)
+		ca.issue(req.CSR)
+		writeJSON(w, http.StatusOK, ca.order())
+	case "/cert/1":
+		w.Header().Set("Content-Type", "application/pem-certificate-chain")
+		_, _ = w.Write(ca.certPEM)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (ca *mockACMECA) order() map[string]interface{} {
+	o := map[string]interface{}{
+		"status":         ca.orderStatus,
+		"identifiers":    []map[string]string{{"type": "dns", "value": ca.domain}},
+		"authorizations": []string{ca.srv.URL + "/authz/1"},
+		"finalize":       ca.srv.URL + "/finalize/1",
+	}
+	if ca.orderStatus == "valid" {
+		o["certificate"] = ca.srv.URL + "/cert/1"
+	}
+	return o
+}
+
+func (ca *mockACMECA) authz() map[string]interface{} {
+	return map[string]interface{}{
+		"status":     ca.authzStatus,
+		"identifier": map[string]string{"type": "dns", "value": ca.domain},
+		"challenges": []map[string]string{{
+			"type":   "http-01",
+			"url":    ca.srv.URL + "/challenge/1",
+			"token":  mockACMEToken,
+			"status": ca.authzStatus,
+		}},
+	}
+}
+
+// validateHTTP01 fetches the key authorization from the challenge listener, as a
+// real CA would, and marks the authorization valid if it matches the token.
+func (ca *mockACMECA) validateHTTP01() {
+	req, _ := http.NewRequest(http.MethodGet, "http://"+ca.challengeAddr+"/.well-known/acme-challenge/"+mockACMEToken, nil)
+	req.Host = ca.domain
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		ca.authzStatus = "invalid"
+		return
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK || !strings.HasPrefix(string(body), mockACMEToken+".") {
+		ca.authzStatus = "invalid"
+		return
+	}
+	ca.validated = true
+	ca.authzStatus = "valid"
+	ca.orderStatus = "ready"
+}
+
+func (ca *mockACMECA) issue(csrB64 string) {
+	der, err := base64.RawURLEncoding.DecodeString(csrB64)
+	if err != nil {
+		ca.t.Errorf("decode CSR: %v", err)
+		return
+	}
+	csr, err := x509.ParseCertificateRequest(der)
+	if err != nil {
+		ca.t.Errorf("parse CSR: %v", err)
+		return
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: ca.domain},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(24 * time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		DNSNames:     csr.DNSNames,
+	}
+	leaf, err := x509.CreateCertificate(rand.Reader, tmpl, ca.caCert, csr.PublicKey, ca.caKey)
+	if err != nil {
+		ca.t.Errorf("issue certificate: %v", err)
+		return
+	}
+	ca.certPEM = append(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: leaf}),
+		pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ca.caCert.Raw})...)
+	ca.orderStatus = "valid"
+}
+
+func jwsPayload(t *testing.T, r *http.Request) []byte {
+	var jws struct {
+		Payload string `json:"payload"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&jws); err != nil {
+		t.Errorf("decode JWS: %v", err)
+		return nil
+	}
+	payload, _ := base64.RawURLEncoding.DecodeString(jws.Payload)
+	return payload
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func TestNew_ACMERequiresDomains(t *testing.T) {
+	cfg := testConfig()
+	cfg.ACME = &ACMEConfig{}
+	if _, err := New(cfg, zap.NewNop()); err == nil {
+		t.Error("expected error for ACME config without domains")
+	}
+}
+
+func TestNew_ACMEAndCertReloaderExclusive(t *testing.T) {
+	cfg := testConfig()
+	cfg.ACME = &ACMEConfig{Domains: []string{"example.test"}}
+	cfg.CertReloader = &CertReloader{}
+	if _, err := New(cfg, zap.NewNop()); err == nil {
+		t.Error("expected error when both ACME and CertReloader are set")
+	}
+}
+
+func TestServer_ACMEInvalidConfigStartsNoChallengeListener(t *testing.T) {
+	cfg := testConfig()
+	cfg.H3Enabled = false
+	cfg.ACME = &ACMEConfig{Domains: []string{"example.test"}, CacheDir: t.TempDir(), HTTPAddr: "127.0.0.1:0"}
+	srv, err := New(cfg, zap.NewNop())
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	srv.HandleWebTransport("/echo", func(*webtransport.Session, *http.Request) {})
+	if err := srv.Start(context.Background()); err == nil {
+		t.Fatal("expected Start to fail without HTTP/3")
+	}
+	if addr := srv.ListenAddr("acme"); addr != "" {
+		t.Errorf("expected no ACME challenge listener, got %s", addr)
+	}
+}
+
+func TestServer_ACMEObtainsCertificate(t *testing.T) {
+	const domain = "example.test"
+	ca := newMockACMECA(t, domain)
+
+	cfg := testConfig()
+	cfg.H3Enabled = false
+	cfg.ACME = &ACMEConfig{
+		Domains:      []string{domain},
+		CacheDir:     t.TempDir(),
+		Email:        "ops@example.test",
+		DirectoryURL: ca.directoryURL(),
+		HTTPAddr:     "127.0.0.1:0",
+	}
+	srv, err := New(cfg, zap.NewNop())
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	startTestServer(t, srv)
+
+	challengeAddr := srv.ListenAddr("acme")
+	if challengeAddr == "" {
+		t.Fatal("expected ACME challenge listener to be running")
+	}
+	ca.setChallengeAddr(challengeAddr)
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.caCert)
+	conn, err := tls.Dial("tcp", srv.ListenAddr("h2"), &tls.Config{
+		ServerName: domain,
+		RootCAs:    roots,
+		NextProtos: []string{"h2"},
+	})
+	if err != nil {
+		t.Fatalf("tls handshake: %v", err)
+	}
+	defer conn.Close()
+
+	if !ca.challengeValidated() {
+		t.Error("expected the CA to validate the HTTP-01 challenge")
+	}
+	leaf := conn.ConnectionState().PeerCertificates[0]
+	if leaf.Issuer.CommonName != "mock ACME CA" {
+		t.Errorf("expected certificate issued by mock ACME CA, got %q", leaf.Issuer.CommonName)
+	}
+
+	noRedirect := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}}
+	resp, err := noRedirect.Get("http://" + challengeAddr + "/")
+	if err != nil {
+		t.Fatalf("challenge listener request: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusFound || !strings.HasPrefix(resp.Header.Get("Location"), "https://") {
+		t.Errorf("expected non-challenge requests to redirect to HTTPS, got %d %q", resp.StatusCode, resp.Header.Get("Location"))
+	}
+}
@@ -0,0 +1,35 @@
+package server
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+)
+
+// altSvcHandler wraps next so that every response advertises the HTTP/3 listener
+// at h3Addr via the Alt-Svc header, letting clients discover and upgrade to H3.
+func altSvcHandler(next http.Handler, h3Addr string, maxAge time.Duration) http.Handler {
+	value := altSvcValue(h3Addr, maxAge)
+	if value == "" {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Alt-Svc", value)
+		next.ServeHTTP(w, r)
+	})
+}
+
+// altSvcValue formats the Alt-Svc header value for the H3 listener at h3Addr. It
+// returns an empty string when h3Addr has no port.
+func altSvcValue(h3Addr string, maxAge time.Duration) string {
+	_, port, err := net.SplitHostPort(h3Addr)
+	if err != nil || port == "" {
+		return ""
+	}
+	value := fmt.Sprintf(`h3=":%s"`, port)
+	if maxAge > 0 {
+		value += fmt.Sprintf("; ma=%d", int64(maxAge/time.Second))
+	}
+	return value
+}
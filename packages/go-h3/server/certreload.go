@@ -0,0 +1,147 @@
+package server
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"os"
+	"os/signal"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// CertReloader serves a TLS certificate loaded from cert/key files and swaps it
+// atomically when the files change or the process receives SIGHUP, so renewed
+// certificates take effect without restarting listeners. Existing connections
+// keep the certificate they were established with.
+type CertReloader struct {
+	certPath     string
+	keyPath      string
+	pollInterval time.Duration
+	logger       *zap.Logger
+
+	cert atomic.Pointer[tls.Certificate]
+
+	mu      sync.Mutex
+	certMod time.Time
+	keyMod  time.Time
+}
+
+// NewCertReloader loads the keypair at certPath/keyPath. When pollInterval is
+// positive, Watch also reloads whenever either file's modification time changes;
+// otherwise it reloads only on SIGHUP. If logger is nil, a no-op logger is used.
+func NewCertReloader(certPath, keyPath string, pollInterval time.Duration, logger *zap.Logger) (*CertReloader, error) {
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	r := &CertReloader{
+		certPath:     certPath,
+		keyPath:      keyPath,
+		pollInterval: pollInterval,
+		logger:       logger,
+	}
+	if err := r.Reload(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Reload reads the keypair from disk and makes it the certificate served to new
+// handshakes. On error the previous certificate stays in use.
+func (r *CertReloader) Reload() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	certMod, keyMod, err := r.modTimes()
+	if err != nil {
+		return err
+	}
+	cert, err := tls.LoadX509KeyPair(r.certPath, r.keyPath)
+	if err != nil {
+		return fmt.Errorf("loading TLS keypair: %w", err)
+	}
+	r.cert.Store(&cert)
+	r.certMod, r.keyMod = certMod, keyMod
+	return nil
+}
+
+// GetCertificate returns the current certificate. It matches the signature of
+// tls.Config.GetCertificate.
+func (r *CertReloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	return r.cert.Load(), nil
+}
+
+// TLSConfig returns a TLS 1.3 configuration that serves the reloader's current
+// certificate.
+func (r *CertReloader) TLSConfig() *tls.Config {
+	return &tls.Config{
+		GetCertificate: r.GetCertificate,
+		MinVersion:     tls.VersionTLS13,
+		NextProtos:     []string{"h3", "h2", "http/1.1"},
+	}
+}
+
+// Watch reloads the keypair on SIGHUP and, if a poll interval was configured,
+// when the files change on disk. It blocks until ctx is cancelled. Failed
+// reloads are logged and the previous certificate is kept.
+func (r *CertReloader) Watch(ctx context.Context) {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+	var tick <-chan time.Time
+	if r.pollInterval > 0 {
+		ticker := time.NewTicker(r.pollInterval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hup:
+			r.reloadAndLog("SIGHUP")
+		case <-tick:
+			if r.changed() {
+				r.reloadAndLog("file change")
+			}
+		}
+	}
+}
+
+func (r *CertReloader) reloadAndLog(trigger string) {
+	if err := r.Reload(); err != nil {
+		r.logger.Error("TLS certificate reload failed", zap.String("trigger", trigger), zap.Error(err))
+		return
+	}
+	r.logger.Info("TLS certificate reloaded", zap.String("trigger", trigger))
+}
+
+// changed reports whether either file's modification time differs from the
+// last successful load.
+func (r *CertReloader) changed() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	certMod, keyMod, err := r.modTimes()
+	if err != nil {
+		return false
+	}
+	return !certMod.Equal(r.certMod) || !keyMod.Equal(r.keyMod)
+}
+
+func (r *CertReloader) modTimes() (certMod, keyMod time.Time, err error) {
+	certInfo, err := os.Stat(r.certPath)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("stat TLS cert: %w", err)
+	}
+	keyInfo, err := os.Stat(r.keyPath)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("stat TLS key: %w", err)
+	}
+	return certInfo.ModTime(), keyInfo.ModTime(), nil
+}
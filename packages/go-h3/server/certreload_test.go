@@ -0,0 +1,186 @@
+package server
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/quic-go/quic-go"
+	"go.uber.org/zap"
+)
+
+// writeCertFiles writes a self-signed certificate with the given serial number
+// and its key to certPath and keyPath, bumping their modification times so
+// pollers notice the change.
+func writeCertFiles(t *testing.T, certPath, keyPath string, serial int64) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: "localhost"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("create certificate: %v", err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatalf("marshal key: %v", err)
+	}
+	if err := os.WriteFile(certPath, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600); err != nil {
+		t.Fatalf("write cert: %v", err)
+	}
+	if err := os.WriteFile(keyPath, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600); err != nil {
+		t.Fatalf("write key: %v", err)
+	}
+	mod := time.Now().Add(time.Duration(serial) * time.Second)
+	_ = os.Chtimes(certPath, mod, mod)
+	_ = os.Chtimes(keyPath, mod, mod)
+}
+
+func tempCertPaths(t *testing.T) (string, string) {
+	dir := t.TempDir()
+	return filepath.Join(dir, "tls.crt"), filepath.Join(dir, "tls.key")
+}
+
+// h2PeerSerial performs a TLS handshake against addr and returns the serial
+// number of the presented leaf certificate.
+func h2PeerSerial(t *testing.T, addr string) int64 {
+	t.Helper()
+	conn, err := tls.Dial("tcp", addr, &tls.Config{InsecureSkipVerify: true, NextProtos: []string{"h2"}}) //nolint:gosec // test-only self-signed cert
+	if err != nil {
+		t.Fatalf("tls dial: %v", err)
+	}
+	defer conn.Close()
+	return conn.ConnectionState().PeerCertificates[0].SerialNumber.Int64()
+}
+
+// h3PeerSerial performs a QUIC handshake against addr and returns the serial
+// number of the presented leaf certificate.
+func h3PeerSerial(t *testing.T, addr string) int64 {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	conn, err := quic.DialAddr(ctx, addr, &tls.Config{InsecureSkipVerify: true, NextProtos: []string{"h3"}}, nil) //nolint:gosec // test-only self-signed cert
+	if err != nil {
+		t.Fatalf("quic dial: %v", err)
+	}
+	defer conn.CloseWithError(0, "")
+	return conn.ConnectionState().TLS.PeerCertificates[0].SerialNumber.Int64()
+}
+
+func currentSerial(t *testing.T, r *CertReloader) int64 {
+	t.Helper()
+	cert, _ := r.GetCertificate(nil)
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+	if err != nil {
+		t.Fatalf("parse certificate: %v", err)
+	}
+	return leaf.SerialNumber.Int64()
+}
+
+func TestNewCertReloader_InvalidPaths(t *testing.T) {
+	if _, err := NewCertReloader("/nonexistent/cert.pem", "/nonexistent/key.pem", 0, nil); err == nil {
+		t.Error("expected error for invalid paths, got nil")
+	}
+}
+
+func TestCertReloader_ReloadFailureKeepsCertificate(t *testing.T) {
+	certPath, keyPath := tempCertPaths(t)
+	writeCertFiles(t, certPath, keyPath, 1)
+	r, err := NewCertReloader(certPath, keyPath, 0, nil)
+	if err != nil {
+		t.Fatalf("NewCertReloader: %v", err)
+	}
+
+	if err := os.WriteFile(certPath, []byte("not a certificate"), 0o600); err != nil {
+		t.Fatalf("write cert: %v", err)
+	}
+	if err := r.Reload(); err == nil {
+		t.Error("expected reload of invalid cert to fail")
+	}
+	if got := currentSerial(t, r); got != 1 {
+		t.Errorf("expected previous certificate to stay in use, got serial %d", got)
+	}
+}
+
+func TestCertReloader_PollsForChanges(t *testing.T) {
+	certPath, keyPath := tempCertPaths(t)
+	writeCertFiles(t, certPath, keyPath, 1)
+	r, err := NewCertReloader(certPath, keyPath, 10*time.Millisecond, nil)
+	if err != nil {
+		t.Fatalf("NewCertReloader: %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go r.Watch(ctx)
+
+	writeCertFiles(t, certPath, keyPath, 2)
+
+	deadline := time.Now().Add(5 * time.Second)
+	for currentSerial(t, r) != 2 {
+		if time.Now().After(deadline) {
+			t.Fatal("certificate was not reloaded after files changed")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestServer_CertReloaderSwapsCertificateWithoutRestart(t *testing.T) {
+	certPath, keyPath := tempCertPaths(t)
+	writeCertFiles(t, certPath, keyPath, 1)
+	reloader, err := NewCertReloader(certPath, keyPath, 0, nil)
+	if err != nil {
+		t.Fatalf("NewCertReloader: %v", err)
+	}
+
+	cfg := testConfig()
+	cfg.CertReloader = reloader
+	srv, err := New(cfg, zap.NewNop())
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	startTestServer(t, srv)
+	h2Addr, h3Addr := srv.ListenAddr("h2"), srv.ListenAddr("h3")
+
+	if got := h2PeerSerial(t, h2Addr); got != 1 {
+		t.Fatalf("expected initial H2 certificate serial 1, got %d", got)
+	}
+	if got := h3PeerSerial(t, h3Addr); got != 1 {
+		t.Fatalf("expected initial H3 certificate serial 1, got %d", got)
+	}
+
+	writeCertFiles(t, certPath, keyPath, 2)
+	if err := reloader.Reload(); err != nil {
+		t.Fatalf("Reload: %v", err)
+	}
+
+	if got := h2PeerSerial(t, h2Addr); got != 2 {
+		t.Errorf("expected reloaded H2 certificate serial 2, got %d", got)
+	}
+	if got := h3PeerSerial(t, h3Addr); got != 2 {
+		t.Errorf("expected reloaded H3 certificate serial 2, got %d", got)
+	}
+	if srv.ListenAddr("h2") != h2Addr || srv.ListenAddr("h3") != h3Addr {
+		t.Error("expected listeners to keep their addresses across reload")
+	}
+}
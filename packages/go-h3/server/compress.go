@@ -0,0 +1,237 @@
+package server
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/andybalholm/brotli"
+)
+
+// Content codings supported by NewCompressionMiddleware.
+const (
+	encodingBrotli = "br"
+	encodingGzip   = "gzip"
+)
+
+const defaultCompressionMinSize = 1024
+
+// CompressionConfig controls NewCompressionMiddleware.
+type CompressionConfig struct {
+	// MinSize is the smallest response body, in bytes, worth compressing.
+	// Smaller responses are sent as is. Defaults to 1024.
+	MinSize int
+	// ContentTypes lists the media types eligible for compression. An entry
+	// ending in "/" matches a whole type, e.g. "text/". Defaults to JSON and text.
+	ContentTypes []string
+}
+
+// DefaultCompressionConfig returns a CompressionConfig for ConnectRPC JSON and
+// plain-text responses larger than 1 KiB.
+func DefaultCompressionConfig() CompressionConfig {
+	return CompressionConfig{
+		MinSize:      defaultCompressionMinSize,
+		ContentTypes: []string{"application/json", "application/connect+json", "text/"},
+	}
+}
+
+// NewCompressionMiddleware returns an http.Handler wrapper that compresses
+// responses with brotli or gzip, as negotiated by the request's Accept-Encoding
+// (brotli wins ties). Responses are left alone when they are smaller than
+// MinSize, have a content type outside ContentTypes, or already carry a
+// Content-Encoding, e.g. because a Connect handler compressed them itself.
+// Install it on the server via Config.HTTPMiddleware:
+//
+//	cfg.HTTPMiddleware = append(cfg.HTTPMiddleware,
+//		server.NewCompressionMiddleware(server.DefaultCompressionConfig()))
+func NewCompressionMiddleware(cfg CompressionConfig) func(http.Handler) http.Handler {
+	if cfg.MinSize <= 0 {
+		cfg.MinSize = defaultCompressionMinSize
+	}
+	if len(cfg.ContentTypes) == 0 {
+		cfg.ContentTypes = DefaultCompressionConfig().ContentTypes
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			encoding := negotiateEncoding(r.Header.Get("Accept-Encoding"))
+			if encoding == "" || r.Method == http.MethodHead {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			cw := &compressWriter{ResponseWriter: w, cfg: &cfg, encoding: encoding, status: http.StatusOK}
+			defer cw.close()
+			next.ServeHTTP(cw, r)
+		})
+	}
+}
+
+// negotiateEncoding picks the preferred supported coding from an
+// Accept-Encoding header, or "" if neither brotli nor gzip is acceptable.
+func negotiateEncoding(header string) string {
+	best, bestQ := "", 0.0
+	for _, part := range strings.Split(header, ",") {
+		coding, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		coding = strings.ToLower(strings.TrimSpace(coding))
+		if coding != encodingBrotli && coding != encodingGzip {
+			continue
+		}
+		q := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				continue
+			}
+			q = parsed
+		}
+		if q <= 0 {
+			continue
+		}
+		if q > bestQ || (q == bestQ && coding == encodingBrotli) {
+			best, bestQ = coding, q
+		}
+	}
+	return best
+}
+
+// compressWriter buffers the start of a response until it knows whether the
+// body reaches MinSize, then either compresses it or passes it through.
+type compressWriter struct {
+	http.ResponseWriter
+	cfg      *CompressionConfig
+	encoding string
+
+	status      int
+	wroteHeader bool
+	decided     bool
+	buf         []byte
+	enc         io.WriteCloser
+}
+
+// WriteHeader records the status; it is sent once the coding is decided.
+// Informational (1xx) responses are passed through immediately.
+func (cw *compressWriter) WriteHeader(status int) {
+	if status < http.StatusOK {
+		cw.ResponseWriter.WriteHeader(status)
+		return
+	}
+	if cw.wroteHeader {
+		return
+	}
+	cw.wroteHeader = true
+	cw.status = status
+}
+
+// Write buffers until MinSize bytes are available, then streams.
+func (cw *compressWriter) Write(p []byte) (int, error) {
+	cw.wroteHeader = true
+	if cw.decided {
+		return cw.write(p)
+	}
+	cw.buf = append(cw.buf, p...)
+	if len(cw.buf) >= cw.cfg.MinSize {
+		if err := cw.decide(true); err != nil {
+			return 0, err
+		}
+	}
+	return len(p), nil
+}
+
+// Flush sends buffered data. A flushed response is treated as a stream and
+// compressed regardless of MinSize, since its final size is unknown.
+func (cw *compressWriter) Flush() {
+	if !cw.decided {
+		if err := cw.decide(true); err != nil {
+			return
+		}
+	}
+	if f, ok := cw.enc.(interface{ Flush() error }); ok {
+		_ = f.Flush()
+	}
+	if f, ok := cw.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Unwrap exposes the underlying writer to http.ResponseController.
+func (cw *compressWriter) Unwrap() http.ResponseWriter {
+	return cw.ResponseWriter
+}
+
+// close finishes the response once the handler returns.
+func (cw *compressWriter) close() {
+	if !cw.decided {
+		_ = cw.decide(false)
+	}
+	if cw.enc != nil {
+		_ = cw.enc.Close()
+	}
+}
+
+// decide sends the headers, compressing if large is set and the response is
+// eligible, then writes any buffered body.
+func (cw *compressWriter) decide(large bool) error {
+	cw.decided = true
+	h := cw.Header()
+	if cw.eligible() {
+		h.Add("Vary", "Accept-Encoding")
+		if large {
+			h.Set("Content-Encoding", cw.encoding)
+			h.Del("Content-Length")
+			if cw.encoding == encodingBrotli {
+				cw.enc = brotli.NewWriter(cw.ResponseWriter)
+			} else {
+				cw.enc = gzip.NewWriter(cw.ResponseWriter)
+			}
+		}
+	}
+	cw.ResponseWriter.WriteHeader(cw.status)
+
+	buf := cw.buf
+	cw.buf = nil
+	if len(buf) == 0 {
+		return nil
+	}
+	_, err := cw.write(buf)
+	return err
+}
+
+func (cw *compressWriter) write(p []byte) (int, error) {
+	if cw.enc != nil {
+		return cw.enc.Write(p)
+	}
+	return cw.ResponseWriter.Write(p)
+}
+
+// eligible reports whether the response may be compressed at all.
+func (cw *compressWriter) eligible() bool {
+	if cw.status < http.StatusOK || cw.status == http.StatusNoContent || cw.status == http.StatusNotModified ||
+		cw.status == http.StatusPartialContent {
+		return false
+	}
+	h := cw.Header()
+	if h.Get("Content-Encoding") != "" {
+		return false
+	}
+	if n, err := strconv.Atoi(h.Get("Content-Length")); err == nil && n < cw.cfg.MinSize {
+		return false
+	}
+	ct := h.Get("Content-Type")
+	if ct == "" {
+		ct = http.DetectContentType(cw.buf)
+	}
+	mediaType, _, _ := strings.Cut(ct, ";")
+	mediaType = strings.ToLower(strings.TrimSpace(mediaType))
+	for _, allowed := range cw.cfg.ContentTypes {
+		if strings.HasSuffix(allowed, "/") && strings.HasPrefix(mediaType, allowed) {
+			return true
+		}
+		if mediaType == allowed {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,169 @@
+package server
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/andybalholm/brotli"
+)
+
+func compressTestHandler(contentType string, body []byte) http.Handler {
+	next := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		if contentType != "" {
+			w.Header().Set("Content-Type", contentType)
+		}
+		_, _ = w.Write(body)
+	})
+	return NewCompressionMiddleware(DefaultCompressionConfig())(next)
+}
+
+func serveCompressed(h http.Handler, acceptEncoding string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/svc.Foo/Bar", nil)
+	if acceptEncoding != "" {
+		req.Header.Set("Accept-Encoding", acceptEncoding)
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+func largeJSON() []byte {
+	return []byte(`{"items":[` + strings.Repeat(`"penguin",`, 300) + `"end"]}`)
+}
+
+func TestCompressionMiddleware_Gzip(t *testing.T) {
+	body := largeJSON()
+	rec := serveCompressed(compressTestHandler("application/json", body), "gzip")
+
+	if got := rec.Header().Get("Content-Encoding"); got != "gzip" {
+		t.Fatalf("expected gzip encoding, got %q", got)
+	}
+	if got := rec.Header().Get("Vary"); got != "Accept-Encoding" {
+		t.Errorf("expected Vary: Accept-Encoding, got %q", got)
+	}
+	zr, err := gzip.NewReader(rec.Body)
+	if err != nil {
+		t.Fatalf("gzip.NewReader: %v", err)
+	}
+	got, err := io.ReadAll(zr)
+	if err != nil {
+		t.Fatalf("read gzip body: %v", err)
+	}
+	if !bytes.Equal(got, body) {
+		t.Error("decompressed body does not match")
+	}
+}
+
+func TestCompressionMiddleware_BrotliPreferred(t *testing.T) {
+	body := largeJSON()
+	rec := serveCompressed(compressTestHandler("application/connect+json", body), "gzip, deflate, br")
+
+	if got := rec.Header().Get("Content-Encoding"); got != "br" {
+		t.Fatalf("expected br encoding, got %q", got)
+	}
+	got, err := io.ReadAll(brotli.NewReader(rec.Body))
+	if err != nil {
+		t.Fatalf("read brotli body: %v", err)
+	}
+	if !bytes.Equal(got, body) {
+		t.Error("decompressed body does not match")
+	}
+}
+
+func TestCompressionMiddleware_SkipsSmallResponses(t *testing.T) {
+	body := []byte(`{"ok":true}`)
+	rec := serveCompressed(compressTestHandler("application/json", body), "br, gzip")
+
+	if got := rec.Header().Get("Content-Encoding"); got != "" {
+		t.Errorf("expected no encoding, got %q", got)
+	}
+	if rec.Body.String() != string(body) {
+		t.Errorf("expected body passed through, got %q", rec.Body.String())
+	}
+}
+
+func TestCompressionMiddleware_SkipsUnlistedContentTypes(t *testing.T) {
+	body := bytes.Repeat([]byte{0x89, 'P', 'N', 'G'}, 1024)
+	rec := serveCompressed(compressTestHandler("image/png", body), "gzip")
+
+	if got := rec.Header().Get("Content-Encoding"); got != "" {
+		t.Errorf("expected no encoding, got %q", got)
+	}
+	if rec.Body.Len() != len(body) {
+		t.Errorf("expected %d bytes, got %d", len(body), rec.Body.Len())
+	}
+}
+
+func TestCompressionMiddleware_SkipsEncodedResponses(t *testing.T) {
+	body := largeJSON()
+	next := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Content-Encoding", "gzip")
+		_, _ = w.Write(body)
+	})
+	rec := serveCompressed(NewCompressionMiddleware(DefaultCompressionConfig())(next), "br")
+
+	if got := rec.Header().Get("Content-Encoding"); got != "gzip" {
+		t.Errorf("expected handler's encoding kept, got %q", got)
+	}
+	if !bytes.Equal(rec.Body.Bytes(), body) {
+		t.Error("expected body passed through unchanged")
+	}
+}
+
+func TestCompressionMiddleware_NoAcceptEncoding(t *testing.T) {
+	body := largeJSON()
+	rec := serveCompressed(compressTestHandler("application/json", body), "")
+
+	if got := rec.Header().Get("Content-Encoding"); got != "" {
+		t.Errorf("expected no encoding, got %q", got)
+	}
+	if !bytes.Equal(rec.Body.Bytes(), body) {
+		t.Error("expected body passed through unchanged")
+	}
+}
+
+func TestCompressionMiddleware_PreservesStatus(t *testing.T) {
+	body := largeJSON()
+	next := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write(body)
+	})
+	rec := serveCompressed(NewCompressionMiddleware(DefaultCompressionConfig())(next), "gzip")
+
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("expected 404, got %d", rec.Code)
+	}
+	if got := rec.Header().Get("Content-Encoding"); got != "gzip" {
+		t.Errorf("expected gzip encoding, got %q", got)
+	}
+}
+
+func TestNegotiateEncoding(t *testing.T) {
+	tests := []struct {
+		header string
+		want   string
+	}{
+		{"", ""},
+		{"identity", ""},
+		{"gzip", "gzip"},
+		{"br", "br"},
+		{"gzip, br", "br"},
+		{"br;q=0.5, gzip", "gzip"},
+		{"gzip;q=0.8, br;q=0.9", "br"},
+		{"br;q=0, gzip;q=0", ""},
+		{"GZIP", "gzip"},
+		{"br;q=bogus, gzip", "gzip"},
+	}
+	for _, tt := range tests {
+		if got := negotiateEncoding(tt.header); got != tt.want {
+			t.Errorf("negotiateEncoding(%q) = %q, want %q", tt.header, got, tt.want)
+		}
+	}
+}
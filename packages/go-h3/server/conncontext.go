@@ -0,0 +1,45 @@
+package server
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"net"
+	"time"
+
+	"github.com/quic-go/quic-go"
+)
+
+// errQUICConnIO is returned by the byte-stream methods of quicNetConn, which only
+// exists to expose connection metadata to Config.ConnContext.
+var errQUICConnIO = errors.New("quic connection does not support net.Conn I/O")
+
+// quicNetConn adapts an HTTP/3 QUIC connection to net.Conn so a single
+// Config.ConnContext function serves both listeners. It exposes the addresses and,
+// like *tls.Conn, a ConnectionState method returning the TLS state; reads, writes
+// and deadlines are not supported.
+type quicNetConn struct {
+	conn *quic.Conn
+}
+
+func (c quicNetConn) Read([]byte) (int, error)  { return 0, errQUICConnIO }
+func (c quicNetConn) Write([]byte) (int, error) { return 0, errQUICConnIO }
+func (c quicNetConn) Close() error              { return c.conn.CloseWithError(0, "") }
+func (c quicNetConn) LocalAddr() net.Addr       { return c.conn.LocalAddr() }
+func (c quicNetConn) RemoteAddr() net.Addr      { return c.conn.RemoteAddr() }
+
+func (c quicNetConn) SetDeadline(time.Time) error      { return errQUICConnIO }
+func (c quicNetConn) SetReadDeadline(time.Time) error  { return errQUICConnIO }
+func (c quicNetConn) SetWriteDeadline(time.Time) error { return errQUICConnIO }
+
+// ConnectionState returns the TLS state negotiated during the QUIC handshake.
+func (c quicNetConn) ConnectionState() tls.ConnectionState {
+	return c.conn.ConnectionState().TLS
+}
+
+// h3ConnContext adapts fn to http3.Server.ConnContext.
+func h3ConnContext(fn func(ctx context.Context, c net.Conn) context.Context) func(context.Context, *quic.Conn) context.Context {
+	return func(ctx context.Context, c *quic.Conn) context.Context {
+		return fn(ctx, quicNetConn{conn: c})
+	}
+}
@@ -0,0 +1,77 @@
+package server
+
+import (
+	"context"
+	"crypto/tls"
+	"io"
+	"net"
+	"net/http"
+	"testing"
+
+	"github.com/quic-go/quic-go/http3"
+	"go.uber.org/zap"
+)
+
+type testConnKey struct{}
+
+func TestServer_ConnContextExposesPeerCertificates(t *testing.T) {
+	cfg := testConfig()
+	cfg.TLSConfig = selfSignedTLSConfig(t)
+	cfg.TLSConfig.ClientAuth = tls.RequireAnyClientCert
+	cfg.ConnContext = func(ctx context.Context, c net.Conn) context.Context {
+		return context.WithValue(ctx, testConnKey{}, c)
+	}
+	srv, err := New(cfg, zap.NewNop())
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	srv.Mux().HandleFunc("/peer", func(w http.ResponseWriter, r *http.Request) {
+		conn, ok := r.Context().Value(testConnKey{}).(interface {
+			ConnectionState() tls.ConnectionState
+		})
+		if !ok {
+			http.Error(w, "no TLS connection in context", http.StatusInternalServerError)
+			return
+		}
+		certs := conn.ConnectionState().PeerCertificates
+		if len(certs) == 0 {
+			http.Error(w, "no peer certificates", http.StatusUnauthorized)
+			return
+		}
+		_, _ = w.Write([]byte(certs[0].Subject.CommonName))
+	})
+	startTestServer(t, srv)
+
+	clientCert := selfSignedTLSConfig(t).Certificates[0]
+	clientTLS := &tls.Config{InsecureSkipVerify: true, Certificates: []tls.Certificate{clientCert}} //nolint:gosec // test-only self-signed cert
+
+	h2 := &http.Client{Transport: &http.Transport{TLSClientConfig: clientTLS.Clone(), ForceAttemptHTTP2: true}}
+	h3Transport := &http3.Transport{TLSClientConfig: clientTLS.Clone()}
+	defer h3Transport.Close()
+	h3 := &http.Client{Transport: h3Transport}
+
+	for _, tc := range []struct {
+		name   string
+		client *http.Client
+		addr   string
+	}{
+		{"h2", h2, srv.ListenAddr("h2")},
+		{"h3", h3, srv.ListenAddr("h3")},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			resp, err := tc.client.Get("https://" + tc.addr + "/peer")
+			if err != nil {
+				t.Fatalf("request failed: %v", err)
+			}
+			defer resp.Body.Close()
+			body, _ := io.ReadAll(resp.Body)
+
+			if resp.StatusCode != http.StatusOK {
+				t.Fatalf("expected 200, got %d: %s", resp.StatusCode, body)
+			}
+			if string(body) != "localhost" {
+				t.Errorf("expected peer certificate CN localhost, got %q", body)
+			}
+		})
+	}
+}
@@ -0,0 +1,123 @@
+package server
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CORSConfig controls the Access-Control-* headers emitted by NewCORSMiddleware.
+type CORSConfig struct {
+	// AllowedOrigins lists origins permitted to make cross-origin requests.
+	// "*" allows any origin.
+	AllowedOrigins []string
+	// AllowedMethods lists methods permitted in preflight requests.
+	AllowedMethods []string
+	// AllowedHeaders lists request headers permitted in preflight requests.
+	AllowedHeaders []string
+	// ExposedHeaders lists response headers browsers may read.
+	ExposedHeaders []string
+	// AllowCredentials permits cookies and Authorization headers on cross-origin
+	// requests. When set, a wildcard origin is answered with the request origin.
+	AllowCredentials bool
+	// MaxAge is how long browsers may cache a preflight response. Zero omits the header.
+	MaxAge time.Duration
+}
+
+// DefaultCORSConfig returns a CORSConfig suitable for ConnectRPC web clients on
+// the given origins.
+func DefaultCORSConfig(origins ...string) CORSConfig {
+	return CORSConfig{
+		AllowedOrigins: origins,
+		AllowedMethods: []string{http.MethodGet, http.MethodPost},
+		AllowedHeaders: []string{
+			"Content-Type", "Authorization", "X-Correlation-ID",
+			"Connect-Protocol-Version", "Connect-Timeout-Ms", "X-User-Agent",
+			"X-Grpc-Web", "Grpc-Timeout",
+		},
+		ExposedHeaders: []string{"X-Correlation-ID", "Grpc-Status", "Grpc-Message", "Grpc-Status-Details-Bin"},
+		MaxAge:         2 * time.Hour,
+	}
+}
+
+// NewCORSMiddleware returns an http.Handler wrapper that answers CORS preflight
+// (OPTIONS) requests and adds Access-Control-* headers to cross-origin responses.
+// CORS is handled at the HTTP layer rather than as a Connect interceptor because
+// preflight requests never reach a Connect handler. Install it on the server via
+// Config.HTTPMiddleware:
+//
+//	cfg.HTTPMiddleware = append(cfg.HTTPMiddleware,
+//		server.NewCORSMiddleware(server.DefaultCORSConfig("https://app.example.com")))
+func NewCORSMiddleware(cfg CORSConfig) func(http.Handler) http.Handler {
+	allowAll := false
+	origins := make(map[string]bool, len(cfg.AllowedOrigins))
+	for _, o := range cfg.AllowedOrigins {
+		if o == "*" {
+			allowAll = true
+		}
+		origins[o] = true
+	}
+	methods := strings.Join(cfg.AllowedMethods, ", ")
+	headers := strings.Join(cfg.AllowedHeaders, ", ")
+	exposed := strings.Join(cfg.ExposedHeaders, ", ")
+	maxAge := ""
+	if cfg.MaxAge > 0 {
+		maxAge = strconv.FormatInt(int64(cfg.MaxAge/time.Second), 10)
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			origin := r.Header.Get("Origin")
+			if origin == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			h := w.Header()
+			h.Add("Vary", "Origin")
+			preflight := r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != ""
+			if preflight {
+				h.Add("Vary", "Access-Control-Request-Method")
+				h.Add("Vary", "Access-Control-Request-Headers")
+			}
+
+			if !allowAll && !origins[origin] {
+				if preflight {
+					w.WriteHeader(http.StatusForbidden)
+					return
+				}
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if allowAll && !cfg.AllowCredentials {
+				h.Set("Access-Control-Allow-Origin", "*")
+			} else {
+				h.Set("Access-Control-Allow-Origin", origin)
+			}
+			if cfg.AllowCredentials {
+				h.Set("Access-Control-Allow-Credentials", "true")
+			}
+
+			if !preflight {
+				if exposed != "" {
+					h.Set("Access-Control-Expose-Headers", exposed)
+				}
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if methods != "" {
+				h.Set("Access-Control-Allow-Methods", methods)
+			}
+			if headers != "" {
+				h.Set("Access-Control-Allow-Headers", headers)
+			}
+			if maxAge != "" {
+				h.Set("Access-Control-Max-Age", maxAge)
+			}
+			w.WriteHeader(http.StatusNoContent)
+		})
+	}
+}
@@ -0,0 +1,116 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func corsTestHandler(cfg CORSConfig) (http.Handler, *bool) {
+	called := false
+	next := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		called = true
+		w.WriteHeader(http.StatusOK)
+	})
+	return NewCORSMiddleware(cfg)(next), &called
+}
+
+func TestCORSMiddleware_Preflight(t *testing.T) {
+	cfg := DefaultCORSConfig("https://app.example.com")
+	cfg.MaxAge = 10 * time.Minute
+	h, called := corsTestHandler(cfg)
+
+	req := httptest.NewRequest(http.MethodOptions, "/svc.Foo/Bar", nil)
+	req.Header.Set("Origin", "https://app.example.com")
+	req.Header.Set("Access-Control-Request-Method", "POST")
+	req.Header.Set("Access-Control-Request-Headers", "content-type")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusNoContent {
+		t.Errorf("expected 204, got %d", rec.Code)
+	}
+	if *called {
+		t.Error("preflight should not reach the wrapped handler")
+	}
+	checks := map[string]string{
+		"Access-Control-Allow-Origin":  "https://app.example.com",
+		"Access-Control-Allow-Methods": "GET, POST",
+		"Access-Control-Max-Age":       "600",
+	}
+	for k, want := range checks {
+		if got := rec.Header().Get(k); got != want {
+			t.Errorf("%s: expected %q, got %q", k, want, got)
+		}
+	}
+	if rec.Header().Get("Access-Control-Allow-Headers") == "" {
+		t.Error("expected Access-Control-Allow-Headers to be set")
+	}
+}
+
+func TestCORSMiddleware_SimpleRequest(t *testing.T) {
+	cfg := CORSConfig{
+		AllowedOrigins:   []string{"*"},
+		ExposedHeaders:   []string{"X-Correlation-ID"},
+		AllowCredentials: true,
+	}
+	h, called := corsTestHandler(cfg)
+
+	req := httptest.NewRequest(http.MethodPost, "/svc.Foo/Bar", nil)
+	req.Header.Set("Origin", "https://other.example.com")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+
+	if !*called {
+		t.Error("expected simple request to reach the wrapped handler")
+	}
+	if got := rec.Header().Get("Access-Control-Allow-Origin"); got != "https://other.example.com" {
+		t.Errorf("expected origin echoed with credentials, got %q", got)
+	}
+	if got := rec.Header().Get("Access-Control-Allow-Credentials"); got != "true" {
+		t.Errorf("expected credentials allowed, got %q", got)
+	}
+	if got := rec.Header().Get("Access-Control-Expose-Headers"); got != "X-Correlation-ID" {
+		t.Errorf("expected exposed headers, got %q", got)
+	}
+}
+
+func TestCORSMiddleware_DisallowedOrigin(t *testing.T) {
+	h, called := corsTestHandler(DefaultCORSConfig("https://app.example.com"))
+
+	req := httptest.NewRequest(http.MethodPost, "/svc.Foo/Bar", nil)
+	req.Header.Set("Origin", "https://evil.example.com")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+
+	if got := rec.Header().Get("Access-Control-Allow-Origin"); got != "" {
+		t.Errorf("expected no Allow-Origin for disallowed origin, got %q", got)
+	}
+	if !*called {
+		t.Error("expected non-preflight request to pass through")
+	}
+
+	pre := httptest.NewRequest(http.MethodOptions, "/svc.Foo/Bar", nil)
+	pre.Header.Set("Origin", "https://evil.example.com")
+	pre.Header.Set("Access-Control-Request-Method", "POST")
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, pre)
+	if rec.Code != http.StatusForbidden {
+		t.Errorf("expected 403 for disallowed preflight, got %d", rec.Code)
+	}
+}
+
+func TestCORSMiddleware_NoOriginPassesThrough(t *testing.T) {
+	h, called := corsTestHandler(DefaultCORSConfig("*"))
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/", nil))
+
+	if !*called {
+		t.Error("expected same-origin request to reach handler")
+	}
+	if got := rec.Header().Get("Access-Control-Allow-Origin"); got != "" {
+		t.Errorf("expected no CORS headers without Origin, got %q", got)
+	}
+}
@@ -0,0 +1,40 @@
+package server
+
+import (
+	"expvar"
+	"net/http"
+	"net/http/pprof"
+	"strings"
+)
+
+// debugPathPrefix is the path prefix served by the debug endpoints.
+const debugPathPrefix = "/debug/"
+
+// debugHandler serves /debug/pprof/* and /debug/vars ahead of next. When auth
+// is set, debug requests it rejects receive 403 Forbidden; other requests are
+// passed to next untouched.
+func debugHandler(next http.Handler, auth func(*http.Request) bool) http.Handler {
+	debug := http.NewServeMux()
+	debug.HandleFunc("/debug/pprof/", pprof.Index)
+	debug.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	debug.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	debug.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	debug.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	debug.Handle("/debug/vars", expvar.Handler())
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasPrefix(r.URL.Path, debugPathPrefix) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if _, pattern := debug.Handler(r); pattern == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if auth != nil && !auth(r) {
+			http.Error(w, "forbidden", http.StatusForbidden)
+			return
+		}
+		debug.ServeHTTP(w, r)
+	})
+}
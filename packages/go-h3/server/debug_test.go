@@ -0,0 +1,111 @@
+package server
+
+import (
+	"crypto/tls"
+	"net/http"
+	"testing"
+
+	"github.com/quic-go/quic-go/http3"
+	"go.uber.org/zap"
+)
+
+// getStatus issues a GET to url with client and returns the status code.
+func getStatus(t *testing.T, client *http.Client, url string) int {
+	t.Helper()
+	resp, err := client.Get(url)
+	if err != nil {
+		t.Fatalf("GET %s: %v", url, err)
+	}
+	resp.Body.Close()
+	return resp.StatusCode
+}
+
+func TestServer_DebugEndpointsEnabled(t *testing.T) {
+	cfg := testConfig()
+	cfg.H3Enabled = false
+	cfg.EnableDebugEndpoints = true
+	srv, err := New(cfg, zap.NewNop())
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	srv.Mux().HandleFunc("/debug/custom", func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	})
+	startTestServer(t, srv)
+
+	base := "http://" + srv.ListenAddr("h2")
+	for _, path := range []string{"/debug/pprof/", "/debug/pprof/cmdline", "/debug/pprof/heap", "/debug/vars"} {
+		if got := getStatus(t, http.DefaultClient, base+path); got != http.StatusOK {
+			t.Errorf("GET %s = %d, want 200", path, got)
+		}
+	}
+	if got := getStatus(t, http.DefaultClient, base+"/debug/custom"); got != http.StatusTeapot {
+		t.Errorf("expected unrelated /debug/ routes to reach the mux, got %d", got)
+	}
+}
+
+func TestServer_DebugEndpointsDisabledByDefault(t *testing.T) {
+	cfg := testConfig()
+	cfg.H3Enabled = false
+	srv, err := New(cfg, zap.NewNop())
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	startTestServer(t, srv)
+
+	base := "http://" + srv.ListenAddr("h2")
+	for _, path := range []string{"/debug/pprof/", "/debug/vars"} {
+		if got := getStatus(t, http.DefaultClient, base+path); got != http.StatusNotFound {
+			t.Errorf("GET %s = %d, want 404", path, got)
+		}
+	}
+}
+
+func TestServer_DebugEndpointsAuth(t *testing.T) {
+	cfg := testConfig()
+	cfg.H3Enabled = false
+	cfg.EnableDebugEndpoints = true
+	cfg.DebugAuth = func(r *http.Request) bool { return r.Header.Get("X-Debug-Token") == "let-me-in" }
+	srv, err := New(cfg, zap.NewNop())
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	startTestServer(t, srv)
+
+	url := "http://" + srv.ListenAddr("h2") + "/debug/vars"
+	if got := getStatus(t, http.DefaultClient, url); got != http.StatusForbidden {
+		t.Errorf("expected 403 without token, got %d", got)
+	}
+
+	req, _ := http.NewRequest(http.MethodGet, url, nil)
+	req.Header.Set("X-Debug-Token", "let-me-in")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("expected 200 with token, got %d", resp.StatusCode)
+	}
+}
+
+func TestServer_DebugEndpointsNotOnH3(t *testing.T) {
+	cfg := testConfig()
+	cfg.TLSConfig = selfSignedTLSConfig(t)
+	cfg.EnableDebugEndpoints = true
+	srv, err := New(cfg, zap.NewNop())
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	startTestServer(t, srv)
+
+	if got := getStatus(t, insecureH2Client(), "https://"+srv.ListenAddr("h2")+"/debug/vars"); got != http.StatusOK {
+		t.Errorf("expected debug endpoints on H2, got %d", got)
+	}
+
+	tr := &http3.Transport{TLSClientConfig: &tls.Config{InsecureSkipVerify: true}} //nolint:gosec // test-only self-signed cert
+	defer tr.Close()
+	if got := getStatus(t, &http.Client{Transport: tr}, "https://"+srv.ListenAddr("h3")+"/debug/vars"); got != http.StatusNotFound {
+		t.Errorf("expected debug endpoints absent on H3, got %d", got)
+	}
+}
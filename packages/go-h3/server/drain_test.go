@@ -0,0 +1,101 @@
+package server
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+func TestServer_DrainReportsNotReadyAndCompletesInFlight(t *testing.T) {
+	cfg := testConfig()
+	cfg.H3Enabled = false
+	cfg.DrainDelay = 300 * time.Millisecond
+	cfg.GracePeriod = 5 * time.Second
+	srv, err := New(cfg, zap.NewNop())
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	srv.Mux().Handle("/readyz", srv.ReadinessHandler())
+	srv.Mux().HandleFunc("/slow", func(w http.ResponseWriter, _ *http.Request) {
+		close(started)
+		<-release
+		_, _ = w.Write([]byte("done"))
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	stopped := make(chan error, 1)
+	go func() { stopped <- srv.Start(ctx) }()
+	for !srv.Ready() {
+		time.Sleep(5 * time.Millisecond)
+	}
+	base := "http://" + srv.ListenAddr("h2")
+
+	if got := getStatus(t, http.DefaultClient, base+"/readyz"); got != http.StatusOK {
+		t.Fatalf("expected /readyz 200 before drain, got %d", got)
+	}
+
+	type result struct {
+		body string
+		err  error
+	}
+	resc := make(chan result, 1)
+	go func() {
+		resp, err := http.Get(base + "/slow")
+		if err != nil {
+			resc <- result{err: err}
+			return
+		}
+		defer resp.Body.Close()
+		b, err := io.ReadAll(resp.Body)
+		resc <- result{body: string(b), err: err}
+	}()
+	<-started
+
+	srv.Drain()
+	srv.Drain() // idempotent
+
+	if got := getStatus(t, http.DefaultClient, base+"/readyz"); got != http.StatusServiceUnavailable {
+		t.Errorf("expected /readyz 503 while draining, got %d", got)
+	}
+	select {
+	case err := <-stopped:
+		t.Fatalf("server stopped before the drain delay elapsed: %v", err)
+	default:
+	}
+
+	close(release)
+	res := <-resc
+	if res.err != nil || res.body != "done" {
+		t.Fatalf("in-flight request during drain: body=%q err=%v", res.body, res.err)
+	}
+
+	select {
+	case err := <-stopped:
+		if err != nil {
+			t.Errorf("expected clean shutdown after drain, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("server did not stop after draining")
+	}
+	if srv.Ready() {
+		t.Error("expected server not ready after shutdown")
+	}
+}
+
+func TestServer_ReadinessBeforeStart(t *testing.T) {
+	srv, err := New(testConfig(), zap.NewNop())
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	if srv.Ready() {
+		t.Error("expected server not ready before Start")
+	}
+}
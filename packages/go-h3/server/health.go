@@ -0,0 +1,150 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const defaultHealthCheckTimeout = 2 * time.Second
+
+// Health check statuses reported in HealthReport.
+const (
+	HealthStatusOK   = "ok"
+	HealthStatusFail = "fail"
+)
+
+// HealthCheck reports whether a dependency is usable. It should honour ctx,
+// which carries the registry's per-check timeout.
+type HealthCheck func(ctx context.Context) error
+
+// CheckResult is the outcome of a single named check.
+type CheckResult struct {
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+// HealthReport is the JSON body served by the readiness handler.
+type HealthReport struct {
+	Status string                 `json:"status"`
+	Checks map[string]CheckResult `json:"checks,omitempty"`
+}
+
+// HealthRegistry holds named readiness checks and serves liveness and
+// readiness probes. Liveness only reports that the process can serve HTTP;
+// readiness runs every registered check.
+type HealthRegistry struct {
+	timeout time.Duration
+
+	mu     sync.RWMutex
+	checks map[string]HealthCheck
+}
+
+// NewHealthRegistry returns an empty HealthRegistry. Each check is given
+// timeout to complete; a non-positive timeout defaults to 2s.
+func NewHealthRegistry(timeout time.Duration) *HealthRegistry {
+	if timeout <= 0 {
+		timeout = defaultHealthCheckTimeout
+	}
+	return &HealthRegistry{timeout: timeout, checks: make(map[string]HealthCheck)}
+}
+
+// Register adds or replaces the check called name.
+func (r *HealthRegistry) Register(name string, check HealthCheck) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.checks[name] = check
+}
+
+// Check runs all registered checks concurrently and returns their results. The
+// overall status is HealthStatusOK only if every check passed.
+func (r *HealthRegistry) Check(ctx context.Context) HealthReport {
+	r.mu.RLock()
+	checks := make(map[string]HealthCheck, len(r.checks))
+	for name, c := range r.checks {
+		checks[name] = c
+	}
+	r.mu.RUnlock()
+
+	report := HealthReport{Status: HealthStatusOK, Checks: make(map[string]CheckResult, len(checks))}
+	var (
+		mu sync.Mutex
+		wg sync.WaitGroup
+	)
+	for name, check := range checks {
+		wg.Add(1)
+		go func(name string, check HealthCheck) {
+			defer wg.Done()
+			err := r.run(ctx, check)
+			res := CheckResult{Status: HealthStatusOK}
+			if err != nil {
+				res = CheckResult{Status: HealthStatusFail, Error: err.Error()}
+			}
+			mu.Lock()
+			report.Checks[name] = res
+			if err != nil {
+				report.Status = HealthStatusFail
+			}
+			mu.Unlock()
+		}(name, check)
+	}
+	wg.Wait()
+	return report
+}
+
+// run executes check under the registry timeout. A check that ignores ctx is
+// abandoned when the timeout elapses and reported as failed.
+func (r *HealthRegistry) run(ctx context.Context, check HealthCheck) error {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	done := make(chan error, 1)
+	go func() { done <- check(ctx) }()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return errors.New("check timed out after " + r.timeout.String())
+		}
+		return ctx.Err()
+	}
+}
+
+// LivenessHandler returns a handler for /livez that always responds 200 while
+// the process can serve requests.
+func (r *HealthRegistry) LivenessHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		writeHealthReport(w, http.StatusOK, HealthReport{Status: HealthStatusOK})
+	})
+}
+
+// ReadinessHandler returns a handler for /readyz that runs every check and
+// responds 200 when all pass and 503 otherwise, with a HealthReport body.
+func (r *HealthRegistry) ReadinessHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		report := r.Check(req.Context())
+		status := http.StatusOK
+		if report.Status != HealthStatusOK {
+			status = http.StatusServiceUnavailable
+		}
+		writeHealthReport(w, status, report)
+	})
+}
+
+// RegisterHandlers registers LivenessHandler at /livez and ReadinessHandler at
+// /readyz on mux.
+func (r *HealthRegistry) RegisterHandlers(mux *http.ServeMux) {
+	mux.Handle("/livez", r.LivenessHandler())
+	mux.Handle("/readyz", r.ReadinessHandler())
+}
+
+func writeHealthReport(w http.ResponseWriter, status int, report HealthReport) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(report)
+}
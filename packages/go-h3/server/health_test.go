@@ -0,0 +1,106 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+func serveHealth(t *testing.T, h http.Handler) (int, HealthReport) {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	if ct := rec.Header().Get("Content-Type"); ct != "application/json" {
+		t.Errorf("Content-Type = %q, want application/json", ct)
+	}
+	var report HealthReport
+	if err := json.NewDecoder(rec.Body).Decode(&report); err != nil {
+		t.Fatalf("decode report: %v", err)
+	}
+	return rec.Code, report
+}
+
+func TestHealthRegistry_AllChecksPass(t *testing.T) {
+	r := NewHealthRegistry(time.Second)
+	r.Register("db", func(context.Context) error { return nil })
+	r.Register("cache", func(context.Context) error { return nil })
+
+	code, report := serveHealth(t, r.ReadinessHandler())
+	if code != http.StatusOK || report.Status != HealthStatusOK {
+		t.Fatalf("expected 200 ok, got %d %+v", code, report)
+	}
+	if len(report.Checks) != 2 || report.Checks["db"].Status != HealthStatusOK {
+		t.Errorf("unexpected checks: %+v", report.Checks)
+	}
+}
+
+func TestHealthRegistry_FailingCheck(t *testing.T) {
+	r := NewHealthRegistry(time.Second)
+	r.Register("db", func(context.Context) error { return nil })
+	r.Register("queue", func(context.Context) error { return errors.New("broker unreachable") })
+
+	code, report := serveHealth(t, r.ReadinessHandler())
+	if code != http.StatusServiceUnavailable || report.Status != HealthStatusFail {
+		t.Fatalf("expected 503 fail, got %d %+v", code, report)
+	}
+	if got := report.Checks["queue"]; got.Status != HealthStatusFail || got.Error != "broker unreachable" {
+		t.Errorf("queue result = %+v", got)
+	}
+	if got := report.Checks["db"]; got.Status != HealthStatusOK {
+		t.Errorf("db result = %+v", got)
+	}
+
+	code, report = serveHealth(t, r.LivenessHandler())
+	if code != http.StatusOK || report.Status != HealthStatusOK {
+		t.Errorf("expected liveness to ignore readiness checks, got %d %+v", code, report)
+	}
+}
+
+func TestHealthRegistry_CheckTimeout(t *testing.T) {
+	r := NewHealthRegistry(20 * time.Millisecond)
+	r.Register("stuck", func(context.Context) error {
+		time.Sleep(time.Second)
+		return nil
+	})
+
+	start := time.Now()
+	code, report := serveHealth(t, r.ReadinessHandler())
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("readiness took %v, expected the check to be abandoned at its timeout", elapsed)
+	}
+	if code != http.StatusServiceUnavailable || report.Checks["stuck"].Status != HealthStatusFail {
+		t.Errorf("expected timed out check to fail, got %d %+v", code, report)
+	}
+}
+
+func TestHealthRegistry_ServerReadinessCheck(t *testing.T) {
+	cfg := testConfig()
+	cfg.H3Enabled = false
+	cfg.DrainDelay = time.Minute
+	srv, err := New(cfg, zap.NewNop())
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	r := NewHealthRegistry(0)
+	r.Register("server", srv.ReadinessCheck())
+	r.RegisterHandlers(srv.Mux())
+	startTestServer(t, srv)
+
+	base := "http://" + srv.ListenAddr("h2")
+	if got := getStatus(t, http.DefaultClient, base+"/readyz"); got != http.StatusOK {
+		t.Fatalf("expected /readyz 200, got %d", got)
+	}
+	srv.Drain()
+	if got := getStatus(t, http.DefaultClient, base+"/readyz"); got != http.StatusServiceUnavailable {
+		t.Errorf("expected /readyz 503 while draining, got %d", got)
+	}
+	if got := getStatus(t, http.DefaultClient, base+"/livez"); got != http.StatusOK {
+		t.Errorf("expected /livez 200 while draining, got %d", got)
+	}
+}
@@ -2,23 +2,22 @@ package server
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"runtime/debug"
+	"strings"
 	"time"
 
 	"connectrpc.com/connect"
+	"github.com/penguintechinc/penguin-libs/packages/go-common/logging"
 	"go.uber.org/zap"
 )
 
-// correlationKey is the context key for correlation IDs.
-type correlationKey struct{}
-
-// CorrelationIDFromContext extracts the correlation ID from context.
+// CorrelationIDFromContext extracts the correlation ID from context. IDs are
+// stored under the go-common logging key, so logging.SanitizedLogger.WithContext
+// picks them up as well.
 func CorrelationIDFromContext(ctx context.Context) string {
-	if v, ok := ctx.Value(correlationKey{}).(string); ok {
-		return v
-	}
-	return ""
+	return logging.CorrelationIDFromContext(ctx)
 }
 
 // NewLoggingInterceptor returns a ConnectRPC interceptor that logs requests.
@@ -58,17 +57,34 @@ func NewLoggingInterceptor(logger *zap.Logger) connect.UnaryInterceptorFunc {
 // NewAuthInterceptor returns a ConnectRPC interceptor that validates JWT tokens.
 // validateFn receives the raw Bearer token and returns an error if invalid.
 func NewAuthInterceptor(validateFn func(token string) error, publicProcedures map[string]bool) connect.UnaryInterceptorFunc {
+	return NewSchemeAuthInterceptor(map[string]func(credential string) error{"Bearer": validateFn}, publicProcedures)
+}
+
+// NewSchemeAuthInterceptor returns a ConnectRPC interceptor that validates the
+// Authorization header with the validator registered for its scheme, e.g.
+// "Bearer", "ApiKey" or "Basic". Schemes match case-insensitively and each
+// validator receives the credential following the scheme. Requests with a
+// missing header or an unregistered scheme fail with connect.CodeUnauthenticated.
+func NewSchemeAuthInterceptor(validators map[string]func(credential string) error, publicProcedures map[string]bool) connect.UnaryInterceptorFunc {
+	byScheme := make(map[string]func(string) error, len(validators))
+	for scheme, fn := range validators {
+		byScheme[strings.ToLower(scheme)] = fn
+	}
 	return func(next connect.UnaryFunc) connect.UnaryFunc {
 		return func(ctx context.Context, req connect.AnyRequest) (connect.AnyResponse, error) {
 			if publicProcedures[req.Spec().Procedure] {
 				return next(ctx, req)
 			}
-			auth := req.Header().Get("Authorization")
-			if len(auth) < 8 || auth[:7] != "Bearer " {
-				return nil, connect.NewError(connect.CodeUnauthenticated, fmt.Errorf("missing bearer token"))
+			scheme, credential, ok := strings.Cut(req.Header().Get("Authorization"), " ")
+			if !ok || credential == "" {
+				return nil, connect.NewError(connect.CodeUnauthenticated, fmt.Errorf("missing credentials"))
 			}
-			if err := validateFn(auth[7:]); err != nil {
-				return nil, connect.NewError(connect.CodeUnauthenticated, fmt.Errorf("invalid token: %w", err))
+			validateFn, ok := byScheme[strings.ToLower(scheme)]
+			if !ok {
+				return nil, connect.NewError(connect.CodeUnauthenticated, fmt.Errorf("unsupported authorization scheme %q", scheme))
+			}
+			if err := validateFn(credential); err != nil {
+				return nil, connect.NewError(connect.CodeUnauthenticated, fmt.Errorf("invalid %s credentials: %w", scheme, err))
 			}
 			return next(ctx, req)
 		}
@@ -81,9 +97,31 @@ func NewAuthInterceptor(validateFn func(token string) error, publicProcedures ma
 func NewMetricsInterceptor(
 	counterFn func(procedure, protocol, code string),
 	histogramFn func(procedure, protocol string, durationSec float64),
+) connect.UnaryInterceptorFunc {
+	return NewMetricsInterceptorWithInflight(counterFn, histogramFn, nil, nil)
+}
+
+// NewMetricsInterceptorWithInflight is like NewMetricsInterceptor and also
+// tracks concurrent in-flight requests. incInflight and decInflight, if
+// non-nil, are invoked before and after each handler; decInflight runs even
+// when the handler errors or panics.
+func NewMetricsInterceptorWithInflight(
+	counterFn func(procedure, protocol, code string),
+	histogramFn func(procedure, protocol string, durationSec float64),
+	incInflight func(procedure, protocol string),
+	decInflight func(procedure, protocol string),
 ) connect.UnaryInterceptorFunc {
 	return func(next connect.UnaryFunc) connect.UnaryFunc {
 		return func(ctx context.Context, req connect.AnyRequest) (connect.AnyResponse, error) {
+			procedure := req.Spec().Procedure
+			protocol := req.Peer().Protocol
+			if incInflight != nil {
+				incInflight(procedure, protocol)
+			}
+			if decInflight != nil {
+				defer decInflight(procedure, protocol)
+			}
+
 			start := time.Now()
 			resp, err := next(ctx, req)
 			duration := time.Since(start).Seconds()
@@ -93,8 +131,6 @@ func NewMetricsInterceptor(
 				code = connect.CodeOf(err).String()
 			}
 
-			procedure := req.Spec().Procedure
-			protocol := req.Peer().Protocol
 			counterFn(procedure, protocol, code)
 			histogramFn(procedure, protocol, duration)
 
@@ -104,6 +140,8 @@ func NewMetricsInterceptor(
 }
 
 // NewCorrelationInterceptor propagates or generates X-Correlation-ID headers.
+// Failed requests carry the header in the error metadata, since they have no
+// response to set it on.
 func NewCorrelationInterceptor(genID func() string) connect.UnaryInterceptorFunc {
 	return func(next connect.UnaryFunc) connect.UnaryFunc {
 		return func(ctx context.Context, req connect.AnyRequest) (connect.AnyResponse, error) {
@@ -111,12 +149,20 @@ func NewCorrelationInterceptor(genID func() string) connect.UnaryInterceptorFunc
 			if cid == "" {
 				cid = genID()
 			}
-			ctx = context.WithValue(ctx, correlationKey{}, cid)
+			ctx = logging.ContextWithCorrelationID(ctx, cid)
 
 			resp, err := next(ctx, req)
 			if resp != nil {
 				resp.Header().Set("X-Correlation-ID", cid)
 			}
+			if err != nil {
+				var connectErr *connect.Error
+				if !errors.As(err, &connectErr) {
+					connectErr = connect.NewError(connect.CodeUnknown, err)
+					err = connectErr
+				}
+				connectErr.Meta().Set("X-Correlation-ID", cid)
+			}
 			return resp, err
 		}
 	}
@@ -140,3 +186,58 @@ func NewRecoveryInterceptor(logger *zap.Logger) connect.UnaryInterceptorFunc {
 		}
 	}
 }
+
+// NewTimeoutInterceptor bounds each unary handler to d. The handler receives a
+// context that is cancelled when d elapses so downstream work can stop, and the
+// caller gets connect.CodeDeadlineExceeded without waiting for a handler that
+// ignores cancellation. A non-positive d disables the timeout.
+//
+// The handler runs on its own goroutine. Once d elapses it is abandoned, not
+// stopped: it keeps running until it returns, and its response, error or panic
+// is discarded. Handlers must honour ctx cancellation to release their work,
+// and must not rely on a panic propagating past this interceptor after the
+// deadline.
+func NewTimeoutInterceptor(d time.Duration) connect.UnaryInterceptorFunc {
+	return func(next connect.UnaryFunc) connect.UnaryFunc {
+		if d <= 0 {
+			return next
+		}
+		return func(ctx context.Context, req connect.AnyRequest) (connect.AnyResponse, error) {
+			ctx, cancel := context.WithTimeout(ctx, d)
+			defer cancel()
+
+			type result struct {
+				resp  connect.AnyResponse
+				err   error
+				panic any
+			}
+			done := make(chan result, 1)
+			go func() {
+				var res result
+				defer func() {
+					if r := recover(); r != nil {
+						res.panic = r
+					}
+					done <- res
+				}()
+				res.resp, res.err = next(ctx, req)
+			}()
+
+			select {
+			case res := <-done:
+				if res.panic != nil {
+					panic(res.panic)
+				}
+				if res.err != nil && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+					return nil, connect.NewError(connect.CodeDeadlineExceeded, fmt.Errorf("handler exceeded %s timeout: %w", d, res.err))
+				}
+				return res.resp, res.err
+			case <-ctx.Done():
+				if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+					return nil, connect.NewError(connect.CodeDeadlineExceeded, fmt.Errorf("handler exceeded %s timeout", d))
+				}
+				return nil, connect.NewError(connect.CodeCanceled, ctx.Err())
+			}
+		}
+	}
+}
@@ -4,8 +4,10 @@ import (
 	"context"
 	"errors"
 	"testing"
+	"time"
 
 	"connectrpc.com/connect"
+	"github.com/penguintechinc/penguin-libs/packages/go-common/logging"
 	"go.uber.org/zap"
 )
 
@@ -105,6 +107,59 @@ func TestAuthInterceptor_PublicProcedure(t *testing.T) {
 	}
 }
 
+func TestSchemeAuthInterceptor(t *testing.T) {
+	interceptor := NewSchemeAuthInterceptor(map[string]func(string) error{
+		"Bearer": func(token string) error {
+			if token != "valid-token" {
+				return errors.New("invalid token")
+			}
+			return nil
+		},
+		"ApiKey": func(key string) error {
+			if key != "secret-key" {
+				return errors.New("invalid key")
+			}
+			return nil
+		},
+	}, nil)
+	wrapped := interceptor(func(ctx context.Context, req connect.AnyRequest) (connect.AnyResponse, error) {
+		return nil, nil
+	})
+
+	tests := []struct {
+		name     string
+		header   string
+		wantCode connect.Code
+	}{
+		{"bearer success", "Bearer valid-token", 0},
+		{"api key success", "ApiKey secret-key", 0},
+		{"scheme is case-insensitive", "apikey secret-key", 0},
+		{"api key invalid", "ApiKey wrong-key", connect.CodeUnauthenticated},
+		{"unknown scheme", "Basic dXNlcjpwYXNz", connect.CodeUnauthenticated},
+		{"missing credential", "Bearer", connect.CodeUnauthenticated},
+		{"missing header", "", connect.CodeUnauthenticated},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			req := connect.NewRequest(&struct{}{})
+			if tc.header != "" {
+				req.Header().Set("Authorization", tc.header)
+			}
+
+			_, err := wrapped(context.Background(), req)
+			if tc.wantCode == 0 {
+				if err != nil {
+					t.Errorf("expected no error, got %v", err)
+				}
+				return
+			}
+			if connect.CodeOf(err) != tc.wantCode {
+				t.Errorf("expected %v, got %v", tc.wantCode, connect.CodeOf(err))
+			}
+		})
+	}
+}
+
 func TestLoggingInterceptor_Success(t *testing.T) {
 	logger := zap.NewNop()
 	interceptor := NewLoggingInterceptor(logger)
@@ -171,6 +226,62 @@ func TestMetricsInterceptor_Callbacks(t *testing.T) {
 	}
 }
 
+func TestMetricsInterceptor_TracksInflight(t *testing.T) {
+	var inflight int
+	interceptor := NewMetricsInterceptorWithInflight(
+		func(procedure, protocol, code string) {},
+		func(procedure, protocol string, durationSec float64) {},
+		func(procedure, protocol string) { inflight++ },
+		func(procedure, protocol string) { inflight-- },
+	)
+
+	handlerErr := connect.NewError(connect.CodeInternal, errors.New("boom"))
+	for _, tc := range []struct {
+		name string
+		err  error
+	}{
+		{"success", nil},
+		{"error", handlerErr},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			wrapped := interceptor(func(ctx context.Context, req connect.AnyRequest) (connect.AnyResponse, error) {
+				if inflight != 1 {
+					t.Errorf("expected 1 in-flight request during handler, got %d", inflight)
+				}
+				return nil, tc.err
+			})
+
+			_, _ = wrapped(context.Background(), connect.NewRequest(&struct{}{}))
+
+			if inflight != 0 {
+				t.Errorf("expected 0 in-flight requests after handler, got %d", inflight)
+			}
+		})
+	}
+}
+
+func TestMetricsInterceptor_DecrementsInflightOnPanic(t *testing.T) {
+	var inflight int
+	interceptor := NewMetricsInterceptorWithInflight(
+		func(procedure, protocol, code string) {},
+		func(procedure, protocol string, durationSec float64) {},
+		func(procedure, protocol string) { inflight++ },
+		func(procedure, protocol string) { inflight-- },
+	)
+	wrapped := interceptor(func(ctx context.Context, req connect.AnyRequest) (connect.AnyResponse, error) {
+		panic("boom")
+	})
+
+	func() {
+		defer func() { _ = recover() }()
+		_, _ = wrapped(context.Background(), connect.NewRequest(&struct{}{}))
+	}()
+
+	if inflight != 0 {
+		t.Errorf("expected 0 in-flight requests after panic, got %d", inflight)
+	}
+}
+
 func TestCorrelationInterceptor_GeneratesID(t *testing.T) {
 	genIDCalled := false
 	genID := func() string {
@@ -217,6 +328,55 @@ func TestCorrelationInterceptor_PropagatesID(t *testing.T) {
 	_, _ = wrapped(context.Background(), req)
 }
 
+func TestCorrelationInterceptor_SharesLoggingContextKey(t *testing.T) {
+	interceptor := NewCorrelationInterceptor(func() string { return "shared-id" })
+	wrapped := interceptor(func(ctx context.Context, req connect.AnyRequest) (connect.AnyResponse, error) {
+		if got := logging.CorrelationIDFromContext(ctx); got != "shared-id" {
+			t.Errorf("expected logging package to see shared-id, got %q", got)
+		}
+		return nil, nil
+	})
+
+	_, _ = wrapped(context.Background(), connect.NewRequest(&struct{}{}))
+}
+
+func TestCorrelationInterceptor_SetsIDOnErrorMetadata(t *testing.T) {
+	interceptor := NewCorrelationInterceptor(func() string { return "error-correlation-id" })
+	wrapped := interceptor(func(ctx context.Context, req connect.AnyRequest) (connect.AnyResponse, error) {
+		return nil, connect.NewError(connect.CodeNotFound, errors.New("missing"))
+	})
+
+	_, err := wrapped(context.Background(), connect.NewRequest(&struct{}{}))
+
+	var connectErr *connect.Error
+	if !errors.As(err, &connectErr) {
+		t.Fatalf("expected *connect.Error, got %T", err)
+	}
+	if got := connectErr.Meta().Get("X-Correlation-ID"); got != "error-correlation-id" {
+		t.Errorf("expected correlation ID in error metadata, got %q", got)
+	}
+	if connectErr.Code() != connect.CodeNotFound {
+		t.Errorf("expected code to be preserved, got %v", connectErr.Code())
+	}
+}
+
+func TestCorrelationInterceptor_WrapsPlainError(t *testing.T) {
+	interceptor := NewCorrelationInterceptor(func() string { return "plain-correlation-id" })
+	wrapped := interceptor(func(ctx context.Context, req connect.AnyRequest) (connect.AnyResponse, error) {
+		return nil, errors.New("boom")
+	})
+
+	_, err := wrapped(context.Background(), connect.NewRequest(&struct{}{}))
+
+	var connectErr *connect.Error
+	if !errors.As(err, &connectErr) {
+		t.Fatalf("expected *connect.Error, got %T", err)
+	}
+	if got := connectErr.Meta().Get("X-Correlation-ID"); got != "plain-correlation-id" {
+		t.Errorf("expected correlation ID in error metadata, got %q", got)
+	}
+}
+
 func TestRecoveryInterceptor_PanicRecovered(t *testing.T) {
 	logger := zap.NewNop()
 	interceptor := NewRecoveryInterceptor(logger)
@@ -234,3 +394,57 @@ func TestRecoveryInterceptor_PanicRecovered(t *testing.T) {
 		t.Errorf("expected CodeInternal, got %v", connect.CodeOf(err))
 	}
 }
+
+func TestTimeoutInterceptor_FastHandlerPasses(t *testing.T) {
+	interceptor := NewTimeoutInterceptor(time.Second)
+	wrapped := interceptor(func(ctx context.Context, req connect.AnyRequest) (connect.AnyResponse, error) {
+		return connect.NewResponse(&struct{}{}), nil
+	})
+
+	resp, err := wrapped(context.Background(), connect.NewRequest(&struct{}{}))
+	if err != nil {
+		t.Errorf("expected no error, got %v", err)
+	}
+	if resp == nil {
+		t.Error("expected handler response to be returned")
+	}
+}
+
+func TestTimeoutInterceptor_SlowHandlerTimesOut(t *testing.T) {
+	interceptor := NewTimeoutInterceptor(20 * time.Millisecond)
+	cancelled := make(chan struct{})
+	wrapped := interceptor(func(ctx context.Context, req connect.AnyRequest) (connect.AnyResponse, error) {
+		<-ctx.Done()
+		close(cancelled)
+		return nil, ctx.Err()
+	})
+
+	_, err := wrapped(context.Background(), connect.NewRequest(&struct{}{}))
+	if connect.CodeOf(err) != connect.CodeDeadlineExceeded {
+		t.Errorf("expected CodeDeadlineExceeded, got %v", connect.CodeOf(err))
+	}
+	select {
+	case <-cancelled:
+	case <-time.After(time.Second):
+		t.Error("handler context was not cancelled")
+	}
+}
+
+func TestTimeoutInterceptor_HandlerIgnoringContextStillTimesOut(t *testing.T) {
+	interceptor := NewTimeoutInterceptor(20 * time.Millisecond)
+	release := make(chan struct{})
+	defer close(release)
+	wrapped := interceptor(func(ctx context.Context, req connect.AnyRequest) (connect.AnyResponse, error) {
+		<-release
+		return nil, nil
+	})
+
+	start := time.Now()
+	_, err := wrapped(context.Background(), connect.NewRequest(&struct{}{}))
+	if connect.CodeOf(err) != connect.CodeDeadlineExceeded {
+		t.Errorf("expected CodeDeadlineExceeded, got %v", connect.CodeOf(err))
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("expected prompt timeout, took %v", elapsed)
+	}
+}
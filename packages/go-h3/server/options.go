@@ -2,7 +2,10 @@
 package server
 
 import (
+	"context"
 	"crypto/tls"
+	"net"
+	"net/http"
 	"time"
 
 	"connectrpc.com/connect"
@@ -20,25 +23,81 @@ type Config struct {
 	H3Enabled bool
 	// TLSConfig is required for HTTP/3 and optional for HTTP/2.
 	TLSConfig *tls.Config
+	// CertReloader, when set, supplies the certificate for both listeners and is
+	// watched for the lifetime of Start, so renewed certificates are picked up
+	// without a restart. Other TLSConfig settings still apply; if TLSConfig is
+	// nil, the reloader's TLS 1.3 defaults are used.
+	CertReloader *CertReloader
+	// ACME, when set, obtains and renews certificates for both listeners
+	// automatically and serves HTTP-01 challenges on ACMEConfig.HTTPAddr. Mutually
+	// exclusive with CertReloader.
+	ACME *ACMEConfig
+	// H2C serves HTTP/2 over cleartext (prior knowledge or Upgrade) on the H2
+	// listener when TLSConfig is nil. Use it behind a TLS-terminating load
+	// balancer. Ignored when TLSConfig is set.
+	H2C bool
+	// AltSvcMaxAge is the max-age advertised in the Alt-Svc header that H2 responses
+	// carry when the H3 listener is also enabled. Zero omits the parameter, which
+	// clients treat as 24 hours.
+	AltSvcMaxAge time.Duration
+	// ConnContext, if set, derives the base context of each accepted connection on
+	// both listeners, e.g. go-aaa middleware.WithConnContext() for SPIFFE mTLS. H2
+	// passes the *tls.Conn (or TCP conn). H3 passes an adapter that reports the
+	// QUIC addresses and implements ConnectionState() tls.ConnectionState but
+	// does not support I/O.
+	ConnContext func(ctx context.Context, c net.Conn) context.Context
 	// GracePeriod is the shutdown grace period. Default 30s.
 	GracePeriod time.Duration
-	// Interceptors are ConnectRPC interceptors applied to all handlers.
+	// DrainDelay is how long Server.Drain keeps serving, while reporting not
+	// ready, before graceful shutdown begins, giving load balancers time to stop
+	// routing new requests. Default 5s.
+	DrainDelay time.Duration
+	// Interceptors are ConnectRPC interceptors applied to all handlers registered
+	// with Server.HandleConnect, outside any route-specific interceptors.
 	Interceptors []connect.Interceptor
+	// MaxRequestBytes caps the size of each request body read by the mux. ConnectRPC
+	// handlers reject larger requests with connect.CodeResourceExhausted. Zero
+	// disables the limit.
+	MaxRequestBytes int64
+	// HTTPMiddleware wraps the mux served by both listeners, for concerns that sit
+	// below ConnectRPC such as CORS. The first entry is the outermost wrapper.
+	HTTPMiddleware []func(http.Handler) http.Handler
+	// EnableDebugEndpoints serves net/http/pprof under /debug/pprof/ and expvar
+	// under /debug/vars on the H2 listener only. Default off; do not expose it
+	// on a public address without DebugAuth.
+	EnableDebugEndpoints bool
+	// UnixSocketPath, if set, also serves the H2 handler (plaintext HTTP/1.1, or
+	// h2c when H2C is set) on a unix domain socket at this path, for sidecars on
+	// the same host. It works alongside or, with H2Enabled false, instead of the
+	// TCP listener. A stale socket file is replaced on Start and removed on
+	// shutdown.
+	UnixSocketPath string
+	// QUIC tunes the HTTP/3 listener's QUIC transport, including 0-RTT.
+	QUIC QUICConfig
+	// DebugAuth, if set, must return true for a request to reach the debug
+	// endpoints; rejected requests receive 403 Forbidden.
+	DebugAuth func(r *http.Request) bool
 }
 
 // DefaultConfig returns a Config with sensible defaults.
 func DefaultConfig() Config {
 	return Config{
-		H2Addr:      ":8080",
-		H3Addr:      ":8443",
-		H2Enabled:   true,
-		H3Enabled:   true,
-		GracePeriod: 30 * time.Second,
+		H2Addr:       ":8080",
+		H3Addr:       ":8443",
+		H2Enabled:    true,
+		H3Enabled:    true,
+		GracePeriod:  30 * time.Second,
+		DrainDelay:   5 * time.Second,
+		AltSvcMaxAge: 24 * time.Hour,
 	}
 }
 
 // ConfigFromEnv returns a Config populated from environment variables.
-// Recognized vars: H2_PORT, H3_PORT, H2_ENABLED, H3_ENABLED, TLS_CERT_PATH, TLS_KEY_PATH.
+// Recognized vars: H2_PORT, H3_PORT, H2_ENABLED, H3_ENABLED, and for TLS either
+// TLS_CERT_FILE/TLS_KEY_FILE (TLS_CERT_PATH/TLS_KEY_PATH are accepted as aliases)
+// or inline TLS_CERT_PEM/TLS_KEY_PEM. Inline PEM takes precedence over files.
+// Setting only a certificate or only a key is an error. DEBUG_ENDPOINTS=true
+// sets EnableDebugEndpoints.
 // Values not set in the environment fall back to DefaultConfig.
 func ConfigFromEnv() (Config, error) {
 	cfg := DefaultConfig()
@@ -54,14 +113,13 @@ func ConfigFromEnv() (Config, error) {
 	if envOrDefault("H3_ENABLED", "true") == "false" {
 		cfg.H3Enabled = false
 	}
-	certPath := envOrDefault("TLS_CERT_PATH", "")
-	keyPath := envOrDefault("TLS_KEY_PATH", "")
-	if certPath != "" && keyPath != "" {
-		tlsCfg, err := NewTLSConfig(certPath, keyPath)
-		if err != nil {
-			return cfg, err
-		}
-		cfg.TLSConfig = tlsCfg
+	if envOrDefault("DEBUG_ENDPOINTS", "false") == "true" {
+		cfg.EnableDebugEndpoints = true
 	}
+	tlsCfg, err := tlsConfigFromEnv()
+	if err != nil {
+		return cfg, err
+	}
+	cfg.TLSConfig = tlsCfg
 	return cfg, nil
 }
@@ -1,6 +1,9 @@
 package server
 
 import (
+	"crypto/tls"
+	"os"
+	"strings"
 	"testing"
 	"time"
 )
@@ -23,6 +26,9 @@ func TestDefaultConfig(t *testing.T) {
 	if cfg.GracePeriod != 30*time.Second {
 		t.Errorf("expected GracePeriod 30s, got %v", cfg.GracePeriod)
 	}
+	if cfg.QUIC.Disable0RTT {
+		t.Error("expected QUIC.Disable0RTT false, got true")
+	}
 	if cfg.TLSConfig != nil {
 		t.Error("expected TLSConfig nil, got non-nil")
 	}
@@ -86,3 +92,75 @@ func TestConfigFromEnv_Disabled(t *testing.T) {
 		t.Error("expected H3Enabled true, got false")
 	}
 }
+
+func TestConfigFromEnv_DebugEndpoints(t *testing.T) {
+	t.Setenv("DEBUG_ENDPOINTS", "true")
+
+	cfg, err := ConfigFromEnv()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !cfg.EnableDebugEndpoints {
+		t.Error("expected EnableDebugEndpoints true, got false")
+	}
+}
+
+func TestConfigFromEnv_TLSFiles(t *testing.T) {
+	certPath, keyPath := tempCertPaths(t)
+	writeCertFiles(t, certPath, keyPath, 1)
+	t.Setenv("TLS_CERT_FILE", certPath)
+	t.Setenv("TLS_KEY_FILE", keyPath)
+
+	cfg, err := ConfigFromEnv()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if cfg.TLSConfig == nil {
+		t.Fatal("expected TLSConfig to be loaded, got nil")
+	}
+	if cfg.TLSConfig.MinVersion != tls.VersionTLS13 {
+		t.Errorf("expected MinVersion TLS 1.3, got %x", cfg.TLSConfig.MinVersion)
+	}
+	if len(cfg.TLSConfig.Certificates) != 1 {
+		t.Errorf("expected 1 certificate, got %d", len(cfg.TLSConfig.Certificates))
+	}
+}
+
+func TestConfigFromEnv_TLSInlinePEM(t *testing.T) {
+	certPath, keyPath := tempCertPaths(t)
+	writeCertFiles(t, certPath, keyPath, 1)
+	certPEM, _ := os.ReadFile(certPath)
+	keyPEM, _ := os.ReadFile(keyPath)
+	t.Setenv("TLS_CERT_PEM", string(certPEM))
+	t.Setenv("TLS_KEY_PEM", string(keyPEM))
+
+	cfg, err := ConfigFromEnv()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if cfg.TLSConfig == nil || len(cfg.TLSConfig.Certificates) != 1 {
+		t.Fatal("expected TLSConfig with 1 certificate from inline PEM")
+	}
+}
+
+func TestConfigFromEnv_TLSCertWithoutKey(t *testing.T) {
+	certPath, keyPath := tempCertPaths(t)
+	writeCertFiles(t, certPath, keyPath, 1)
+	t.Setenv("TLS_CERT_FILE", certPath)
+
+	_, err := ConfigFromEnv()
+	if err == nil || !strings.Contains(err.Error(), "TLS_KEY_FILE") {
+		t.Errorf("expected error naming the missing key var, got %v", err)
+	}
+}
+
+func TestConfigFromEnv_TLSKeyWithoutCert(t *testing.T) {
+	t.Setenv("TLS_KEY_PEM", "key")
+
+	_, err := ConfigFromEnv()
+	if err == nil || !strings.Contains(err.Error(), "TLS_CERT_FILE") {
+		t.Errorf("expected error naming the missing cert var, got %v", err)
+	}
+}
@@ -0,0 +1,115 @@
+package server
+
+import (
+	"fmt"
+
+	"connectrpc.com/connect"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// PrometheusConfig names and shapes the metrics created by NewPrometheusMetrics.
+type PrometheusConfig struct {
+	// Namespace and Subsystem prefix every metric name, e.g. "myapp" and "rpc"
+	// yield myapp_rpc_requests_total. Both are optional.
+	Namespace string
+	Subsystem string
+	// Buckets are the request duration histogram buckets in seconds. Defaults
+	// to prometheus.DefBuckets.
+	Buckets []float64
+	// ConstLabels are attached to every metric, e.g. {"service": "billing"}.
+	ConstLabels prometheus.Labels
+}
+
+// PrometheusMetrics holds the standard ConnectRPC server metrics. Its methods
+// match the callbacks of NewMetricsInterceptorWithInflight:
+//
+//	m, err := server.NewPrometheusMetrics(prometheus.DefaultRegisterer, server.PrometheusConfig{Namespace: "billing"})
+//	if err != nil { ... }
+//	cfg.Interceptors = append(cfg.Interceptors, m.Interceptor())
+type PrometheusMetrics struct {
+	// Requests counts completed requests, labelled procedure, protocol and code.
+	Requests *prometheus.CounterVec
+	// Duration observes handler latency in seconds, labelled procedure and protocol.
+	Duration *prometheus.HistogramVec
+	// InFlight tracks requests currently being handled, labelled procedure and protocol.
+	InFlight *prometheus.GaugeVec
+}
+
+// NewPrometheusMetrics creates requests_total, request_duration_seconds and
+// requests_in_flight and registers them with reg, or with
+// prometheus.DefaultRegisterer if reg is nil. It fails if any of them is
+// already registered, e.g. when called twice with the same names, and then
+// unregisters those it had registered.
+func NewPrometheusMetrics(reg prometheus.Registerer, cfg PrometheusConfig) (*PrometheusMetrics, error) {
+	if reg == nil {
+		reg = prometheus.DefaultRegisterer
+	}
+	buckets := cfg.Buckets
+	if len(buckets) == 0 {
+		buckets = prometheus.DefBuckets
+	}
+
+	m := &PrometheusMetrics{
+		Requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace:   cfg.Namespace,
+			Subsystem:   cfg.Subsystem,
+			Name:        "requests_total",
+			Help:        "Total ConnectRPC requests handled, by procedure, protocol and status code.",
+			ConstLabels: cfg.ConstLabels,
+		}, []string{"procedure", "protocol", "code"}),
+		Duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace:   cfg.Namespace,
+			Subsystem:   cfg.Subsystem,
+			Name:        "request_duration_seconds",
+			Help:        "ConnectRPC handler latency in seconds, by procedure and protocol.",
+			Buckets:     buckets,
+			ConstLabels: cfg.ConstLabels,
+		}, []string{"procedure", "protocol"}),
+		InFlight: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace:   cfg.Namespace,
+			Subsystem:   cfg.Subsystem,
+			Name:        "requests_in_flight",
+			Help:        "ConnectRPC requests currently being handled, by procedure and protocol.",
+			ConstLabels: cfg.ConstLabels,
+		}, []string{"procedure", "protocol"}),
+	}
+	collectors := []prometheus.Collector{m.Requests, m.Duration, m.InFlight}
+	for i, c := range collectors {
+		if err := reg.Register(c); err != nil {
+			// Undo the partial registration so a corrected retry can succeed.
+			for _, registered := range collectors[:i] {
+				reg.Unregister(registered)
+			}
+			return nil, fmt.Errorf("registering prometheus metrics: %w", err)
+		}
+	}
+	return m, nil
+}
+
+// Interceptor returns NewMetricsInterceptorWithInflight wired to m.
+func (m *PrometheusMetrics) Interceptor() connect.UnaryInterceptorFunc {
+	return NewMetricsInterceptorWithInflight(m.CountRequest, m.ObserveDuration, m.IncInflight, m.DecInflight)
+}
+
+// CountRequest increments Requests; it is NewMetricsInterceptor's counterFn.
+func (m *PrometheusMetrics) CountRequest(procedure, protocol, code string) {
+	m.Requests.WithLabelValues(procedure, protocol, code).Inc()
+}
+
+// ObserveDuration records a handler latency; it is NewMetricsInterceptor's
+// histogramFn.
+func (m *PrometheusMetrics) ObserveDuration(procedure, protocol string, durationSec float64) {
+	m.Duration.WithLabelValues(procedure, protocol).Observe(durationSec)
+}
+
+// IncInflight increments InFlight; it is NewMetricsInterceptorWithInflight's
+// incInflight.
+func (m *PrometheusMetrics) IncInflight(procedure, protocol string) {
+	m.InFlight.WithLabelValues(procedure, protocol).Inc()
+}
+
+// DecInflight decrements InFlight; it is NewMetricsInterceptorWithInflight's
+// decInflight.
+func (m *PrometheusMetrics) DecInflight(procedure, protocol string) {
+	m.InFlight.WithLabelValues(procedure, protocol).Dec()
+}
@@ -0,0 +1,122 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"connectrpc.com/connect"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"go.uber.org/zap"
+)
+
+func TestNewPrometheusMetrics_Registers(t *testing.T) {
+	reg := prometheus.NewPedanticRegistry()
+	m, err := NewPrometheusMetrics(reg, PrometheusConfig{Namespace: "test", Subsystem: "rpc"})
+	if err != nil {
+		t.Fatalf("NewPrometheusMetrics: %v", err)
+	}
+	m.CountRequest("/svc/M", "connect", "ok")
+	m.ObserveDuration("/svc/M", "connect", 0.01)
+	m.IncInflight("/svc/M", "connect")
+
+	families, err := reg.Gather()
+	if err != nil {
+		t.Fatalf("Gather: %v", err)
+	}
+	names := make(map[string]bool)
+	for _, f := range families {
+		names[f.GetName()] = true
+	}
+	for _, want := range []string{"test_rpc_requests_total", "test_rpc_request_duration_seconds", "test_rpc_requests_in_flight"} {
+		if !names[want] {
+			t.Errorf("expected metric %s to be registered", want)
+		}
+	}
+}
+
+func TestNewPrometheusMetrics_DuplicateRegistrationFails(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	if _, err := NewPrometheusMetrics(reg, PrometheusConfig{}); err != nil {
+		t.Fatalf("first NewPrometheusMetrics: %v", err)
+	}
+	_, err := NewPrometheusMetrics(reg, PrometheusConfig{})
+	var already prometheus.AlreadyRegisteredError
+	if !errors.As(err, &already) {
+		t.Errorf("expected AlreadyRegisteredError, got %v", err)
+	}
+}
+
+func TestPrometheusMetrics_ObservesRequests(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	m, err := NewPrometheusMetrics(reg, PrometheusConfig{})
+	if err != nil {
+		t.Fatalf("NewPrometheusMetrics: %v", err)
+	}
+
+	cfg := testConfig()
+	cfg.H3Enabled = false
+	cfg.Interceptors = []connect.Interceptor{m.Interceptor()}
+	srv, err := New(cfg, zap.NewNop())
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	srv.HandleConnect(echoService("Metrics"), nil)
+	deny := connect.UnaryInterceptorFunc(func(connect.UnaryFunc) connect.UnaryFunc {
+		return func(context.Context, connect.AnyRequest) (connect.AnyResponse, error) {
+			return nil, connect.NewError(connect.CodePermissionDenied, nil)
+		}
+	})
+	srv.HandleConnect(echoService("Denied"), []connect.Interceptor{deny})
+	startTestServer(t, srv)
+
+	base := "http://" + srv.ListenAddr("h2")
+	ok := connect.NewClient[echoMsg, echoMsg](http.DefaultClient, base+"/test.Metrics/Echo", connect.WithCodec(jsonCodec{}))
+	for range 2 {
+		if _, err := ok.CallUnary(context.Background(), connect.NewRequest(&echoMsg{Text: "hi"})); err != nil {
+			t.Fatalf("call failed: %v", err)
+		}
+	}
+	denied := connect.NewClient[echoMsg, echoMsg](http.DefaultClient, base+"/test.Denied/Echo", connect.WithCodec(jsonCodec{}))
+	if _, err := denied.CallUnary(context.Background(), connect.NewRequest(&echoMsg{})); err == nil {
+		t.Fatal("expected denied call to fail")
+	}
+
+	if got := testutil.ToFloat64(m.Requests.WithLabelValues("/test.Metrics/Echo", "connect", "ok")); got != 2 {
+		t.Errorf("expected 2 ok requests, got %v", got)
+	}
+	if got := testutil.ToFloat64(m.Requests.WithLabelValues("/test.Denied/Echo", "connect", "permission_denied")); got != 1 {
+		t.Errorf("expected 1 permission_denied request, got %v", got)
+	}
+	if got := testutil.CollectAndCount(m.Duration); got != 2 {
+		t.Errorf("expected duration series for 2 procedures, got %d", got)
+	}
+	if got := testutil.ToFloat64(m.InFlight.WithLabelValues("/test.Metrics/Echo", "connect")); got != 0 {
+		t.Errorf("expected 0 in-flight requests after completion, got %v", got)
+	}
+}
+
+// failingRegisterer fails the Register call numbered failOn (1-based).
+type failingRegisterer struct {
+	prometheus.Registerer
+	failOn, calls int
+}
+
+func (r *failingRegisterer) Register(c prometheus.Collector) error {
+	if r.calls++; r.calls == r.failOn {
+		return errors.New("register failed")
+	}
+	return r.Registerer.Register(c)
+}
+
+func TestNewPrometheusMetrics_FailedRegistrationIsUndone(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	if _, err := NewPrometheusMetrics(&failingRegisterer{Registerer: reg, failOn: 3}, PrometheusConfig{}); err == nil {
+		t.Fatal("expected registration to fail")
+	}
+	if _, err := NewPrometheusMetrics(reg, PrometheusConfig{}); err != nil {
+		t.Fatalf("expected retry to succeed after a failed registration, got %v", err)
+	}
+}
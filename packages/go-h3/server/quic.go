@@ -0,0 +1,72 @@
+package server
+
+import (
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/quic-go/quic-go"
+)
+
+// QUICConfig tunes the QUIC transport under the HTTP/3 listener. Zero values
+// keep quic-go's defaults.
+type QUICConfig struct {
+	// Disable0RTT rejects TLS 1.3 early data from resuming clients. 0-RTT is
+	// accepted by default, including for a zero-value QUICConfig, which saves a
+	// round trip on reconnect.
+	//
+	// Early data is not protected against replay: an attacker who captures a
+	// 0-RTT request can resend it, and the handler runs again. Handlers reached
+	// in 0-RTT must be idempotent; set Disable0RTT if any non-idempotent RPC
+	// (for example one that creates or transfers something) is served without
+	// its own replay protection.
+	Disable0RTT bool
+	// MaxIdleTimeout closes a connection after this long without network
+	// activity. quic-go defaults to 30s.
+	MaxIdleTimeout time.Duration
+	// KeepAlivePeriod, if set, sends keep-alive packets at this interval so idle
+	// connections are not closed by MaxIdleTimeout or NAT timeouts.
+	KeepAlivePeriod time.Duration
+	// MaxIncomingStreams caps concurrent bidirectional streams, i.e. in-flight
+	// requests, per connection. quic-go defaults to 100.
+	MaxIncomingStreams int64
+	// MaxIncomingUniStreams caps concurrent unidirectional streams per
+	// connection. quic-go defaults to 100.
+	MaxIncomingUniStreams int64
+	// UDPReceiveBufferSize sets the socket receive buffer in bytes. quic-go
+	// already tries to raise it to 7 MiB; set a larger value for high packet
+	// rates. The kernel may cap it (net.core.rmem_max on Linux).
+	UDPReceiveBufferSize int
+}
+
+// quicConfig maps c to the quic.Config used by the HTTP/3 listener.
+func (c QUICConfig) quicConfig() *quic.Config {
+	return &quic.Config{
+		Allow0RTT:             !c.Disable0RTT,
+		MaxIdleTimeout:        c.MaxIdleTimeout,
+		KeepAlivePeriod:       c.KeepAlivePeriod,
+		MaxIncomingStreams:    c.MaxIncomingStreams,
+		MaxIncomingUniStreams: c.MaxIncomingUniStreams,
+	}
+}
+
+// listenQUIC opens the UDP socket for the HTTP/3 listener and wraps it in a
+// quic.Transport. The caller owns both and must close the transport before the
+// connection.
+func listenQUIC(addr string, c QUICConfig) (*net.UDPConn, *quic.Transport, error) {
+	udpAddr, err := net.ResolveUDPAddr("udp", addr)
+	if err != nil {
+		return nil, nil, err
+	}
+	conn, err := net.ListenUDP("udp", udpAddr)
+	if err != nil {
+		return nil, nil, err
+	}
+	if c.UDPReceiveBufferSize > 0 {
+		if err := conn.SetReadBuffer(c.UDPReceiveBufferSize); err != nil {
+			conn.Close()
+			return nil, nil, fmt.Errorf("set UDP receive buffer: %w", err)
+		}
+	}
+	return conn, &quic.Transport{Conn: conn}, nil
+}
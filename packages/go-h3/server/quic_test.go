@@ -0,0 +1,77 @@
+package server
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/quic-go/quic-go/http3"
+	"go.uber.org/zap"
+)
+
+func TestQUICConfig_Mapping(t *testing.T) {
+	qc := QUICConfig{
+		MaxIdleTimeout:        45 * time.Second,
+		KeepAlivePeriod:       10 * time.Second,
+		MaxIncomingStreams:    1000,
+		MaxIncomingUniStreams: 50,
+	}.quicConfig()
+
+	if !qc.Allow0RTT {
+		t.Error("expected 0-RTT to be allowed by default")
+	}
+	if qc.MaxIdleTimeout != 45*time.Second {
+		t.Errorf("MaxIdleTimeout: got %v", qc.MaxIdleTimeout)
+	}
+	if qc.KeepAlivePeriod != 10*time.Second {
+		t.Errorf("KeepAlivePeriod: got %v", qc.KeepAlivePeriod)
+	}
+	if qc.MaxIncomingStreams != 1000 {
+		t.Errorf("MaxIncomingStreams: got %d", qc.MaxIncomingStreams)
+	}
+	if qc.MaxIncomingUniStreams != 50 {
+		t.Errorf("MaxIncomingUniStreams: got %d", qc.MaxIncomingUniStreams)
+	}
+}
+
+func TestServer_QUICConfigApplied(t *testing.T) {
+	cfg := testConfig()
+	cfg.H2Enabled = false
+	cfg.TLSConfig = selfSignedTLSConfig(t)
+	cfg.QUIC = QUICConfig{
+		Disable0RTT:          true,
+		MaxIdleTimeout:       90 * time.Second,
+		MaxIncomingStreams:   500,
+		UDPReceiveBufferSize: 8 << 20,
+	}
+	srv, err := New(cfg, zap.NewNop())
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	srv.Mux().HandleFunc("/ping", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte("pong"))
+	})
+	startTestServer(t, srv)
+
+	srv.mu.Lock()
+	qc := srv.h3.QUICConfig
+	srv.mu.Unlock()
+	if qc.Allow0RTT {
+		t.Error("expected 0-RTT to be disabled")
+	}
+	if qc.MaxIdleTimeout != 90*time.Second {
+		t.Errorf("MaxIdleTimeout: got %v", qc.MaxIdleTimeout)
+	}
+	if qc.MaxIncomingStreams != 500 {
+		t.Errorf("MaxIncomingStreams: got %d", qc.MaxIncomingStreams)
+	}
+
+	tr := &http3.Transport{TLSClientConfig: &tls.Config{InsecureSkipVerify: true}} //nolint:gosec // test-only self-signed cert
+	defer tr.Close()
+	client := &http.Client{Transport: tr, Timeout: 5 * time.Second}
+	if code := getStatus(t, client, fmt.Sprintf("https://%s/ping", srv.ListenAddr("h3"))); code != http.StatusOK {
+		t.Errorf("expected 200 over tuned H3 listener, got %d", code)
+	}
+}
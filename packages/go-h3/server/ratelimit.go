@@ -0,0 +1,135 @@
+package server
+
+import (
+	"container/list"
+	"context"
+	"fmt"
+	"math"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+
+	"connectrpc.com/connect"
+)
+
+// RateLimitKeyFunc derives the rate-limit bucket key for a request, typically
+// the authenticated subject or the peer address. An empty key bypasses limiting.
+type RateLimitKeyFunc func(ctx context.Context, req connect.AnyRequest) string
+
+// RateLimitConfig configures NewRateLimitInterceptor.
+type RateLimitConfig struct {
+	// Rate is the sustained number of requests per second allowed per key.
+	Rate float64
+	// Burst is the maximum number of requests a key may make at once. Defaults to 1.
+	Burst int
+	// MaxKeys bounds the number of tracked keys; the least recently used bucket is
+	// evicted when the limit is reached. Defaults to 10000.
+	MaxKeys int
+}
+
+// PeerRateLimitKey keys rate limiting on the peer IP address.
+func PeerRateLimitKey(_ context.Context, req connect.AnyRequest) string {
+	addr := req.Peer().Addr
+	if host, _, err := net.SplitHostPort(addr); err == nil {
+		return host
+	}
+	return addr
+}
+
+// NewRateLimitInterceptor returns a ConnectRPC interceptor that applies a token
+// bucket per key returned by keyFn. Requests over the limit fail with
+// connect.CodeResourceExhausted and a Retry-After header (in whole seconds).
+func NewRateLimitInterceptor(keyFn RateLimitKeyFunc, cfg RateLimitConfig) connect.UnaryInterceptorFunc {
+	limiter := newKeyedLimiter(cfg, time.Now)
+	return func(next connect.UnaryFunc) connect.UnaryFunc {
+		return func(ctx context.Context, req connect.AnyRequest) (connect.AnyResponse, error) {
+			key := keyFn(ctx, req)
+			if key == "" {
+				return next(ctx, req)
+			}
+			if ok, wait := limiter.allow(key); !ok {
+				err := connect.NewError(connect.CodeResourceExhausted, fmt.Errorf("rate limit exceeded"))
+				err.Meta().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+				return nil, err
+			}
+			return next(ctx, req)
+		}
+	}
+}
+
+// keyedLimiter holds a bounded LRU of token buckets.
+type keyedLimiter struct {
+	rate    float64
+	burst   float64
+	maxKeys int
+	now     func() time.Time
+
+	mu      sync.Mutex
+	lru     *list.List
+	buckets map[string]*list.Element
+}
+
+// bucket is a token bucket stored in the limiter's LRU list.
+type bucket struct {
+	key    string
+	tokens float64
+	last   time.Time
+}
+
+func newKeyedLimiter(cfg RateLimitConfig, now func() time.Time) *keyedLimiter {
+	if cfg.Burst <= 0 {
+		cfg.Burst = 1
+	}
+	if cfg.MaxKeys <= 0 {
+		cfg.MaxKeys = 10000
+	}
+	return &keyedLimiter{
+		rate:    cfg.Rate,
+		burst:   float64(cfg.Burst),
+		maxKeys: cfg.MaxKeys,
+		now:     now,
+		lru:     list.New(),
+		buckets: make(map[string]*list.Element),
+	}
+}
+
+// allow consumes a token for key. When no token is available it reports how long
+// until one will be.
+func (l *keyedLimiter) allow(key string) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	var b *bucket
+	if el, ok := l.buckets[key]; ok {
+		l.lru.MoveToFront(el)
+		b = el.Value.(*bucket)
+		b.tokens = math.Min(l.burst, b.tokens+now.Sub(b.last).Seconds()*l.rate)
+		b.last = now
+	} else {
+		if l.lru.Len() >= l.maxKeys {
+			oldest := l.lru.Back()
+			l.lru.Remove(oldest)
+			delete(l.buckets, oldest.Value.(*bucket).key)
+		}
+		b = &bucket{key: key, tokens: l.burst, last: now}
+		l.buckets[key] = l.lru.PushFront(b)
+	}
+
+	if b.tokens >= 1 {
+		b.tokens--
+		return true, 0
+	}
+	if l.rate <= 0 {
+		return false, time.Second
+	}
+	return false, time.Duration((1 - b.tokens) / l.rate * float64(time.Second))
+}
+
+// len reports the number of tracked keys.
+func (l *keyedLimiter) len() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.lru.Len()
+}
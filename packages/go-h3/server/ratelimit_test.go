@@ -0,0 +1,97 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"connectrpc.com/connect"
+)
+
+type keyCtx struct{}
+
+func keyFromCtx(ctx context.Context, _ connect.AnyRequest) string {
+	k, _ := ctx.Value(keyCtx{}).(string)
+	return k
+}
+
+func TestRateLimitInterceptor_PerKeyIsolation(t *testing.T) {
+	interceptor := NewRateLimitInterceptor(keyFromCtx, RateLimitConfig{Rate: 0.5, Burst: 2})
+	wrapped := interceptor(func(ctx context.Context, req connect.AnyRequest) (connect.AnyResponse, error) {
+		return nil, nil
+	})
+	req := connect.NewRequest(&struct{}{})
+	ctxA := context.WithValue(context.Background(), keyCtx{}, "alice")
+	ctxB := context.WithValue(context.Background(), keyCtx{}, "bob")
+
+	for i := 0; i < 2; i++ {
+		if _, err := wrapped(ctxA, req); err != nil {
+			t.Fatalf("request %d for alice should be allowed, got %v", i, err)
+		}
+	}
+	_, err := wrapped(ctxA, req)
+	if connect.CodeOf(err) != connect.CodeResourceExhausted {
+		t.Fatalf("expected CodeResourceExhausted once bucket is empty, got %v", err)
+	}
+	var cerr *connect.Error
+	if !errors.As(err, &cerr) || cerr.Meta().Get("Retry-After") != "2" {
+		t.Errorf("expected Retry-After 2, got %q", cerr.Meta().Get("Retry-After"))
+	}
+
+	if _, err := wrapped(ctxB, req); err != nil {
+		t.Errorf("expected bob to be unaffected by alice's limit, got %v", err)
+	}
+}
+
+func TestRateLimitInterceptor_EmptyKeyBypasses(t *testing.T) {
+	interceptor := NewRateLimitInterceptor(keyFromCtx, RateLimitConfig{Rate: 0, Burst: 1})
+	wrapped := interceptor(func(ctx context.Context, req connect.AnyRequest) (connect.AnyResponse, error) {
+		return nil, nil
+	})
+	for i := 0; i < 5; i++ {
+		if _, err := wrapped(context.Background(), connect.NewRequest(&struct{}{})); err != nil {
+			t.Fatalf("expected requests without a key to bypass limiting, got %v", err)
+		}
+	}
+}
+
+func TestKeyedLimiter_Refills(t *testing.T) {
+	now := time.Unix(0, 0)
+	l := newKeyedLimiter(RateLimitConfig{Rate: 1, Burst: 1}, func() time.Time { return now })
+
+	if ok, _ := l.allow("k"); !ok {
+		t.Fatal("expected first request allowed")
+	}
+	ok, wait := l.allow("k")
+	if ok {
+		t.Fatal("expected second immediate request denied")
+	}
+	if wait != time.Second {
+		t.Errorf("expected 1s wait, got %v", wait)
+	}
+	now = now.Add(time.Second)
+	if ok, _ := l.allow("k"); !ok {
+		t.Error("expected request allowed after refill")
+	}
+}
+
+func TestKeyedLimiter_EvictsLeastRecentlyUsed(t *testing.T) {
+	now := time.Unix(0, 0)
+	l := newKeyedLimiter(RateLimitConfig{Rate: 0, Burst: 1, MaxKeys: 2}, func() time.Time { return now })
+
+	l.allow("a")
+	l.allow("b")
+	l.allow("a") // touch a so b is least recently used
+	l.allow("c") // evicts b
+
+	if l.len() != 2 {
+		t.Fatalf("expected 2 tracked keys, got %d", l.len())
+	}
+	if ok, _ := l.allow("a"); ok {
+		t.Error("expected key a to remain tracked and exhausted")
+	}
+	if ok, _ := l.allow("b"); !ok {
+		t.Error("expected evicted key b to start with a fresh bucket")
+	}
+}
@@ -0,0 +1,31 @@
+package server
+
+import (
+	"net/http"
+
+	"connectrpc.com/connect"
+)
+
+// ConnectHandlerFunc builds a ConnectRPC handler from handler options and
+// returns it with the path prefix it serves, matching the constructors generated
+// by protoc-gen-connect-go once the service implementation is bound:
+//
+//	func(opts ...connect.HandlerOption) (string, http.Handler) {
+//		return adminv1connect.NewAdminServiceHandler(svc, opts...)
+//	}
+type ConnectHandlerFunc func(opts ...connect.HandlerOption) (string, http.Handler)
+
+// HandleConnect registers the handler built by newHandler on the mux at the
+// path prefix it returns, and returns that prefix. The handler runs
+// Config.Interceptors followed by interceptors, so route-specific interceptors
+// such as authentication for an admin service compose with, and run inside,
+// the global ones. Extra options are passed through to the handler.
+func (s *Server) HandleConnect(newHandler ConnectHandlerFunc, interceptors []connect.Interceptor, opts ...connect.HandlerOption) string {
+	chain := make([]connect.Interceptor, 0, len(s.cfg.Interceptors)+len(interceptors))
+	chain = append(chain, s.cfg.Interceptors...)
+	chain = append(chain, interceptors...)
+
+	path, handler := newHandler(append([]connect.HandlerOption{connect.WithInterceptors(chain...)}, opts...)...)
+	s.mux.Handle(path, handler)
+	return path
+}
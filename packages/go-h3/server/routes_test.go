@@ -0,0 +1,113 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"slices"
+	"sync"
+	"testing"
+
+	"connectrpc.com/connect"
+	"go.uber.org/zap"
+)
+
+// callRecorder collects interceptor names in the order they run.
+type callRecorder struct {
+	mu    sync.Mutex
+	calls []string
+}
+
+func (r *callRecorder) interceptor(name string) connect.UnaryInterceptorFunc {
+	return func(next connect.UnaryFunc) connect.UnaryFunc {
+		return func(ctx context.Context, req connect.AnyRequest) (connect.AnyResponse, error) {
+			r.mu.Lock()
+			r.calls = append(r.calls, name)
+			r.mu.Unlock()
+			return next(ctx, req)
+		}
+	}
+}
+
+func (r *callRecorder) take() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	calls := r.calls
+	r.calls = nil
+	return calls
+}
+
+// echoService returns a ConnectHandlerFunc serving /test.<name>/Echo.
+func echoService(name string) ConnectHandlerFunc {
+	return func(opts ...connect.HandlerOption) (string, http.Handler) {
+		procedure := "/test." + name + "/Echo"
+		opts = append(opts, connect.WithCodec(jsonCodec{}))
+		return "/test." + name + "/", connect.NewUnaryHandler(procedure,
+			func(_ context.Context, req *connect.Request[echoMsg]) (*connect.Response[echoMsg], error) {
+				return connect.NewResponse(req.Msg), nil
+			}, opts...)
+	}
+}
+
+func TestServer_HandleConnectPerRouteInterceptors(t *testing.T) {
+	rec := &callRecorder{}
+	cfg := testConfig()
+	cfg.H3Enabled = false
+	cfg.Interceptors = []connect.Interceptor{rec.interceptor("global")}
+	srv, err := New(cfg, zap.NewNop())
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	adminPath := srv.HandleConnect(echoService("Admin"), []connect.Interceptor{rec.interceptor("auth"), rec.interceptor("audit")})
+	healthPath := srv.HandleConnect(echoService("Health"), nil)
+	if adminPath != "/test.Admin/" || healthPath != "/test.Health/" {
+		t.Fatalf("unexpected paths %q and %q", adminPath, healthPath)
+	}
+	startTestServer(t, srv)
+
+	call := func(service string) {
+		t.Helper()
+		client := connect.NewClient[echoMsg, echoMsg](http.DefaultClient,
+			"http://"+srv.ListenAddr("h2")+"/test."+service+"/Echo", connect.WithCodec(jsonCodec{}))
+		if _, err := client.CallUnary(context.Background(), connect.NewRequest(&echoMsg{Text: "hi"})); err != nil {
+			t.Fatalf("%s call failed: %v", service, err)
+		}
+	}
+
+	call("Admin")
+	if got, want := rec.take(), []string{"global", "auth", "audit"}; !slices.Equal(got, want) {
+		t.Errorf("admin interceptors = %v, want %v", got, want)
+	}
+
+	call("Health")
+	if got, want := rec.take(), []string{"global"}; !slices.Equal(got, want) {
+		t.Errorf("health interceptors = %v, want %v", got, want)
+	}
+}
+
+func TestServer_HandleConnectRouteInterceptorRejects(t *testing.T) {
+	cfg := testConfig()
+	cfg.H3Enabled = false
+	srv, err := New(cfg, zap.NewNop())
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	deny := connect.UnaryInterceptorFunc(func(connect.UnaryFunc) connect.UnaryFunc {
+		return func(context.Context, connect.AnyRequest) (connect.AnyResponse, error) {
+			return nil, connect.NewError(connect.CodeUnauthenticated, nil)
+		}
+	})
+	srv.HandleConnect(echoService("Admin"), []connect.Interceptor{deny})
+	srv.HandleConnect(echoService("Health"), nil)
+	startTestServer(t, srv)
+
+	base := "http://" + srv.ListenAddr("h2")
+	admin := connect.NewClient[echoMsg, echoMsg](http.DefaultClient, base+"/test.Admin/Echo", connect.WithCodec(jsonCodec{}))
+	if _, err := admin.CallUnary(context.Background(), connect.NewRequest(&echoMsg{})); connect.CodeOf(err) != connect.CodeUnauthenticated {
+		t.Errorf("expected admin call to be rejected, got %v", err)
+	}
+	health := connect.NewClient[echoMsg, echoMsg](http.DefaultClient, base+"/test.Health/Echo", connect.WithCodec(jsonCodec{}))
+	if _, err := health.CallUnary(context.Background(), connect.NewRequest(&echoMsg{})); err != nil {
+		t.Errorf("expected health call to succeed, got %v", err)
+	}
+}
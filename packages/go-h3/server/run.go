@@ -0,0 +1,38 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"os/signal"
+	"syscall"
+
+	"go.uber.org/zap"
+)
+
+// Run builds a Server from cfg, lets register add handlers to its mux, and
+// serves until SIGINT or SIGTERM, then shuts down gracefully within
+// GracePeriod. It returns the error from New or Start, nil after a clean
+// shutdown:
+//
+//	err := server.Run(cfg, logger, func(mux *http.ServeMux) {
+//		mux.Handle(echov1connect.NewEchoServiceHandler(svc))
+//	})
+func Run(cfg Config, logger *zap.Logger, register func(*http.ServeMux)) error {
+	return RunContext(context.Background(), cfg, logger, register)
+}
+
+// RunContext is Run with a parent context; cancelling ctx triggers the same
+// graceful shutdown as a signal.
+func RunContext(ctx context.Context, cfg Config, logger *zap.Logger, register func(*http.ServeMux)) error {
+	srv, err := New(cfg, logger)
+	if err != nil {
+		return err
+	}
+	if register != nil {
+		register(srv.Mux())
+	}
+
+	ctx, stop := signal.NotifyContext(ctx, syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+	return srv.Start(ctx)
+}
@@ -0,0 +1,104 @@
+package server
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+func TestRunContext_CancelShutsDownGracefully(t *testing.T) {
+	sock := filepath.Join(t.TempDir(), "run.sock")
+	cfg := testConfig()
+	cfg.H2Enabled = false
+	cfg.H3Enabled = false
+	cfg.UnixSocketPath = sock
+	cfg.GracePeriod = 5 * time.Second
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	stopped := make(chan error, 1)
+	go func() {
+		stopped <- RunContext(ctx, cfg, zap.NewNop(), func(mux *http.ServeMux) {
+			mux.HandleFunc("/ping", func(w http.ResponseWriter, _ *http.Request) {
+				_, _ = w.Write([]byte("pong"))
+			})
+			mux.HandleFunc("/slow", func(w http.ResponseWriter, _ *http.Request) {
+				close(started)
+				<-release
+				_, _ = w.Write([]byte("done"))
+			})
+		})
+	}()
+
+	client := unixClient(sock)
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		resp, err := client.Get("http://unix/ping")
+		if err == nil {
+			resp.Body.Close()
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("server did not start: %v", err)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	type result struct {
+		body string
+		err  error
+	}
+	slow := make(chan result, 1)
+	go func() {
+		resp, err := client.Get("http://unix/slow")
+		if err != nil {
+			slow <- result{err: err}
+			return
+		}
+		defer resp.Body.Close()
+		b, err := io.ReadAll(resp.Body)
+		slow <- result{body: string(b), err: err}
+	}()
+	<-started
+
+	// Cancelling the parent context stands in for SIGTERM.
+	cancel()
+	select {
+	case err := <-stopped:
+		t.Fatalf("RunContext returned before the in-flight request finished: %v", err)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	close(release)
+	if r := <-slow; r.err != nil || r.body != "done" {
+		t.Errorf("in-flight request: body %q, err %v", r.body, r.err)
+	}
+	select {
+	case err := <-stopped:
+		if err != nil {
+			t.Errorf("expected nil error after graceful shutdown, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("RunContext did not return after shutdown")
+	}
+}
+
+func TestRunContext_NewError(t *testing.T) {
+	cfg := testConfig()
+	cfg.ACME = &ACMEConfig{}
+	called := false
+	err := RunContext(context.Background(), cfg, zap.NewNop(), func(*http.ServeMux) { called = true })
+	if err == nil {
+		t.Fatal("expected error from New")
+	}
+	if called {
+		t.Error("register must not run when New fails")
+	}
+}
@@ -2,13 +2,24 @@ package server
 
 import (
 	"context"
+	"crypto/tls"
 	"errors"
 	"fmt"
+	"io/fs"
+	"net"
 	"net/http"
+	"os"
 	"sync"
+	"sync/atomic"
+	"time"
 
+	"github.com/quic-go/quic-go"
 	"github.com/quic-go/quic-go/http3"
+	"github.com/quic-go/webtransport-go"
 	"go.uber.org/zap"
+	"golang.org/x/crypto/acme/autocert"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
 )
 
 // Server runs HTTP/2 and HTTP/3 listeners sharing the same mux.
@@ -19,6 +30,28 @@ type Server struct {
 	mu     sync.Mutex
 	h2     *http.Server
 	h3     *http3.Server
+	h2Addr string
+	h3Addr string
+	h3Conn net.PacketConn
+
+	h3Transport *quic.Transport
+	h3Listener  *quic.EarlyListener
+
+	unix     *http.Server
+	unixPath string
+
+	wt    *webtransport.Server
+	wtMux *http.ServeMux
+
+	serving   atomic.Bool
+	draining  atomic.Bool
+	drainc    chan struct{}
+	drainOnce sync.Once
+
+	acme        *autocert.Manager
+	acmeHandler http.Handler
+	acmeSrv     *http.Server
+	acmeAddr    string
 }
 
 // New creates a Server with the given config and logger.
@@ -31,11 +64,23 @@ func New(cfg Config, logger *zap.Logger) (*Server, error) {
 			return nil, fmt.Errorf("creating logger: %w", err)
 		}
 	}
-	return &Server{
+	s := &Server{
 		cfg:    cfg,
 		mux:    http.NewServeMux(),
 		logger: logger,
-	}, nil
+		drainc: make(chan struct{}),
+	}
+	if cfg.ACME != nil {
+		if cfg.CertReloader != nil {
+			return nil, errors.New("ACME and CertReloader are mutually exclusive")
+		}
+		m, h, err := newACMEManager(*cfg.ACME)
+		if err != nil {
+			return nil, err
+		}
+		s.acme, s.acmeHandler = m, h
+	}
+	return s, nil
 }
 
 // Mux returns the underlying ServeMux for registering ConnectRPC handlers.
@@ -48,26 +93,139 @@ func (s *Server) Mux() *http.ServeMux {
 func (s *Server) Start(ctx context.Context) error {
 	s.mu.Lock()
 
-	errc := make(chan error, 2)
+	errc := make(chan error, 4)
 	var wg sync.WaitGroup
+	tlsConfig := s.tlsConfig()
+
+	// Reject invalid configurations before any listener is started.
+	if s.wtMux != nil && !s.cfg.H3Enabled {
+		s.mu.Unlock()
+		return errors.New("WebTransport handlers require HTTP/3")
+	}
+	if s.cfg.H3Enabled && tlsConfig == nil {
+		s.mu.Unlock()
+		return fmt.Errorf("TLS config required for HTTP/3")
+	}
+
+	if s.cfg.CertReloader != nil {
+		watchCtx, stopWatch := context.WithCancel(ctx)
+		defer stopWatch()
+		go s.cfg.CertReloader.Watch(watchCtx)
+	}
+
+	// abort tears down the listeners already started when a later one fails,
+	// and waits for their serve goroutines to return.
+	abort := func(err error) error {
+		s.mu.Unlock()
+		if serr := s.shutdown(); serr != nil {
+			s.logger.Warn("shutdown after failed start", zap.Error(serr))
+		}
+		wg.Wait()
+		return err
+	}
+
+	if s.acme != nil {
+		addr := s.cfg.ACME.HTTPAddr
+		if addr == "" {
+			addr = ":80"
+		}
+		ln, err := net.Listen("tcp", addr)
+		if err != nil {
+			s.mu.Unlock()
+			return fmt.Errorf("acme challenge listen: %w", err)
+		}
+		s.acmeSrv = &http.Server{Handler: s.acmeHandler}
+		s.acmeAddr = ln.Addr().String()
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.logger.Info("ACME challenge server starting", zap.String("addr", s.acmeAddr))
+			if err := s.acmeSrv.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				errc <- fmt.Errorf("acme challenge server: %w", err)
+			}
+		}()
+	}
+
+	if s.cfg.H3Enabled {
+		tlsCfg := tlsConfig.Clone()
+		tlsCfg.NextProtos = []string{"h3"}
+
+		s.h3 = &http3.Server{
+			Addr:       s.cfg.H3Addr,
+			Handler:    s.handler(),
+			TLSConfig:  tlsCfg,
+			QUICConfig: s.cfg.QUIC.quicConfig(),
+		}
+		if s.cfg.ConnContext != nil {
+			s.h3.ConnContext = h3ConnContext(s.cfg.ConnContext)
+		}
+		if s.wtMux != nil {
+			s.enableWebTransport()
+		}
+		conn, tr, err := listenQUIC(s.cfg.H3Addr, s.cfg.QUIC)
+		if err != nil {
+			s.h3 = nil
+			return abort(fmt.Errorf("h3 listen: %w", err))
+		}
+		ln, err := tr.ListenEarly(http3.ConfigureTLSConfig(tlsCfg), s.h3.QUICConfig)
+		if err != nil {
+			_ = tr.Close()
+			_ = conn.Close()
+			s.h3 = nil
+			return abort(fmt.Errorf("h3 listen: %w", err))
+		}
+		s.h3Conn, s.h3Transport, s.h3Listener = conn, tr, ln
+		s.h3Addr = conn.LocalAddr().String()
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.logger.Info("HTTP/3 server starting", zap.String("addr", s.h3Addr))
+			var err error
+			if s.wt != nil {
+				err = s.serveWebTransport(ln)
+			} else {
+				err = s.h3.ServeListener(ln)
+			}
+			if err != nil && !errors.Is(err, http.ErrServerClosed) {
+				errc <- fmt.Errorf("h3 server: %w", err)
+			}
+		}()
+	}
 
 	if s.cfg.H2Enabled {
+		handler := s.handler()
+		if s.cfg.EnableDebugEndpoints {
+			handler = debugHandler(handler, s.cfg.DebugAuth)
+		}
+		if s.h3 != nil {
+			handler = altSvcHandler(handler, s.h3Addr, s.cfg.AltSvcMaxAge)
+		}
+		if s.cfg.H2C && tlsConfig == nil {
+			handler = h2c.NewHandler(handler, &http2.Server{})
+		}
 		s.h2 = &http.Server{
-			Addr:    s.cfg.H2Addr,
-			Handler: s.mux,
+			Addr:        s.cfg.H2Addr,
+			Handler:     handler,
+			ConnContext: s.cfg.ConnContext,
 		}
-		if s.cfg.TLSConfig != nil {
-			s.h2.TLSConfig = s.cfg.TLSConfig.Clone()
+		if tlsConfig != nil {
+			s.h2.TLSConfig = tlsConfig.Clone()
+		}
+		ln, err := net.Listen("tcp", s.cfg.H2Addr)
+		if err != nil {
+			s.h2 = nil
+			return abort(fmt.Errorf("h2 listen: %w", err))
 		}
+		s.h2Addr = ln.Addr().String()
 		wg.Add(1)
 		go func() {
 			defer wg.Done()
-			s.logger.Info("HTTP/2 server starting", zap.String("addr", s.cfg.H2Addr))
+			s.logger.Info("HTTP/2 server starting", zap.String("addr", s.h2Addr), zap.Bool("h2c", s.cfg.H2C && tlsConfig == nil))
 			var err error
-			if s.cfg.TLSConfig != nil {
-				err = s.h2.ListenAndServeTLS("", "")
+			if tlsConfig != nil {
+				err = s.h2.ServeTLS(ln, "", "")
 			} else {
-				err = s.h2.ListenAndServe()
+				err = s.h2.Serve(ln)
 			}
 			if err != nil && !errors.Is(err, http.ErrServerClosed) {
 				errc <- fmt.Errorf("h2 server: %w", err)
@@ -75,42 +233,96 @@ func (s *Server) Start(ctx context.Context) error {
 		}()
 	}
 
-	if s.cfg.H3Enabled {
-		if s.cfg.TLSConfig == nil {
-			s.mu.Unlock()
-			return fmt.Errorf("TLS config required for HTTP/3")
+	if s.cfg.UnixSocketPath != "" {
+		ln, err := listenUnix(s.cfg.UnixSocketPath)
+		if err != nil {
+			return abort(fmt.Errorf("unix socket listen: %w", err))
 		}
-		tlsCfg := s.cfg.TLSConfig.Clone()
-		tlsCfg.NextProtos = []string{"h3"}
-
-		s.h3 = &http3.Server{
-			Addr:      s.cfg.H3Addr,
-			Handler:   s.mux,
-			TLSConfig: tlsCfg,
+		handler := s.handler()
+		if s.cfg.H2C {
+			handler = h2c.NewHandler(handler, &http2.Server{})
 		}
+		s.unix = &http.Server{Handler: handler, ConnContext: s.cfg.ConnContext}
+		s.unixPath = s.cfg.UnixSocketPath
 		wg.Add(1)
 		go func() {
 			defer wg.Done()
-			s.logger.Info("HTTP/3 server starting", zap.String("addr", s.cfg.H3Addr))
-			if err := s.h3.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
-				errc <- fmt.Errorf("h3 server: %w", err)
+			s.logger.Info("unix socket server starting", zap.String("path", s.unixPath))
+			if err := s.unix.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				errc <- fmt.Errorf("unix socket server: %w", err)
 			}
 		}()
 	}
 
 	s.mu.Unlock()
+	s.serving.Store(true)
 
-	// Wait for context cancellation or a fatal listener error.
+	// Wait for context cancellation, a drain request, or a fatal listener error.
 	select {
 	case <-ctx.Done():
 		s.logger.Info("shutdown signal received")
+	case <-s.drainc:
+		s.logger.Info("draining, shutdown begins after delay", zap.Duration("delay", s.cfg.DrainDelay))
+		select {
+		case <-time.After(s.cfg.DrainDelay):
+		case <-ctx.Done():
+		case err := <-errc:
+			s.logger.Error("listener error while draining", zap.Error(err))
+		}
 	case err := <-errc:
 		s.logger.Error("listener error, shutting down", zap.Error(err))
 	}
 
+	s.serving.Store(false)
 	return s.shutdown()
 }
 
+// Drain marks the server not ready, so ReadinessHandler reports 503, and makes
+// Start begin graceful shutdown after Config.DrainDelay. Listeners keep
+// accepting requests during the delay so clients routed before the load
+// balancer notices are still served. Start then returns nil once in-flight
+// requests finish or GracePeriod elapses. Calling Drain more than once has no
+// further effect.
+func (s *Server) Drain() {
+	s.drainOnce.Do(func() {
+		s.draining.Store(true)
+		close(s.drainc)
+	})
+}
+
+// Ready reports whether the server has started its listeners and is not
+// draining or shutting down.
+func (s *Server) Ready() bool {
+	return s.serving.Load() && !s.draining.Load()
+}
+
+// ReadinessHandler returns a handler for a readiness probe such as /readyz. It
+// responds 200 while Ready reports true and 503 otherwise.
+func (s *Server) ReadinessHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		if !s.Ready() {
+			http.Error(w, "not ready", http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = w.Write([]byte("ok"))
+	})
+}
+
+// ReadinessCheck returns a HealthCheck that fails while the server is not
+// Ready, so a HealthRegistry's /readyz also turns unhealthy during Drain.
+func (s *Server) ReadinessCheck() HealthCheck {
+	return func(context.Context) error {
+		if !s.Ready() {
+			return errors.New("server is not serving or is draining")
+		}
+		return nil
+	}
+}
+
+// shutdown stops both listeners concurrently within GracePeriod. In-flight H2 and
+// H3 requests are allowed to finish; H3 connections still open when the grace
+// period elapses are closed abruptly. With WebTransport handlers registered, H3
+// connections are closed immediately.
 func (s *Server) shutdown() error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
@@ -118,36 +330,162 @@ func (s *Server) shutdown() error {
 	shutCtx, cancel := context.WithTimeout(context.Background(), s.cfg.GracePeriod)
 	defer cancel()
 
-	var errs []error
+	var (
+		wg      sync.WaitGroup
+		h2Err   error
+		acmeErr error
+		unixErr error
+		h3Errs  []error
+	)
+	if s.acmeSrv != nil {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := s.acmeSrv.Shutdown(shutCtx); err != nil {
+				acmeErr = fmt.Errorf("acme challenge shutdown: %w", err)
+			}
+		}()
+	}
 	if s.h2 != nil {
-		s.logger.Info("shutting down HTTP/2 server")
-		if err := s.h2.Shutdown(shutCtx); err != nil {
-			errs = append(errs, fmt.Errorf("h2 shutdown: %w", err))
-		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.logger.Info("shutting down HTTP/2 server")
+			if err := s.h2.Shutdown(shutCtx); err != nil {
+				h2Err = fmt.Errorf("h2 shutdown: %w", err)
+			}
+		}()
+	}
+	if s.unix != nil {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.logger.Info("shutting down unix socket server")
+			if err := s.unix.Shutdown(shutCtx); err != nil {
+				unixErr = fmt.Errorf("unix socket shutdown: %w", err)
+			}
+			// The listener unlinks the socket on close; this covers a forced exit
+			// from Shutdown before the listener was closed.
+			if err := os.Remove(s.unixPath); err != nil && !errors.Is(err, fs.ErrNotExist) {
+				unixErr = errors.Join(unixErr, fmt.Errorf("unix socket remove: %w", err))
+			}
+		}()
 	}
 	if s.h3 != nil {
-		s.logger.Info("shutting down HTTP/3 server")
-		if err := s.h3.Close(); err != nil {
-			errs = append(errs, fmt.Errorf("h3 shutdown: %w", err))
-		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.logger.Info("shutting down HTTP/3 server")
+			if s.wt != nil {
+				// Connections served for WebTransport are not tracked by
+				// http3.Server, so they cannot be drained; close them.
+				if err := s.wt.Close(); err != nil {
+					h3Errs = append(h3Errs, fmt.Errorf("webtransport close: %w", err))
+				}
+			} else if err := s.h3.Shutdown(shutCtx); err != nil {
+				if errors.Is(err, context.DeadlineExceeded) {
+					s.logger.Warn("HTTP/3 grace period elapsed, closing remaining connections")
+					_ = s.h3.Close()
+				}
+				h3Errs = append(h3Errs, fmt.Errorf("h3 shutdown: %w", err))
+			}
+			// http3.Server does not close a listener passed to ServeListener, nor
+			// the transport and socket under it.
+			if err := s.h3Listener.Close(); err != nil {
+				h3Errs = append(h3Errs, fmt.Errorf("h3 listener close: %w", err))
+			}
+			if err := s.h3Transport.Close(); err != nil {
+				h3Errs = append(h3Errs, fmt.Errorf("h3 transport close: %w", err))
+			}
+			if err := s.h3Conn.Close(); err != nil {
+				h3Errs = append(h3Errs, fmt.Errorf("h3 listener close: %w", err))
+			}
+		}()
 	}
-	return errors.Join(errs...)
+	wg.Wait()
+
+	return errors.Join(append([]error{h2Err, acmeErr, unixErr}, h3Errs...)...)
 }
 
 // ListenAddr returns the actual listener address once started. Useful for tests
-// using ":0" ports. Returns empty string if the listener has not started.
+// using ":0" ports. protocol is "h2", "h3", "unix" for the unix socket path, or
+// "acme" for the ACME HTTP-01 challenge listener. Returns empty string if the
+// listener has not started.
 func (s *Server) ListenAddr(protocol string) string {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 	switch protocol {
 	case "h2":
 		if s.h2 != nil {
-			return s.cfg.H2Addr
+			return s.h2Addr
 		}
 	case "h3":
 		if s.h3 != nil {
-			return s.cfg.H3Addr
+			return s.h3Addr
+		}
+	case "unix":
+		if s.unix != nil {
+			return s.unixPath
+		}
+	case "acme":
+		if s.acmeSrv != nil {
+			return s.acmeAddr
 		}
 	}
 	return ""
 }
+
+// listenUnix listens on a unix socket at path, first removing a stale socket
+// left by a previous process. A non-socket file at path is left untouched and
+// reported as an error.
+func listenUnix(path string) (net.Listener, error) {
+	if fi, err := os.Lstat(path); err == nil {
+		if fi.Mode()&fs.ModeSocket == 0 {
+			return nil, fmt.Errorf("%s exists and is not a socket", path)
+		}
+		if err := os.Remove(path); err != nil {
+			return nil, fmt.Errorf("remove stale socket: %w", err)
+		}
+	}
+	return net.Listen("unix", path)
+}
+
+// tlsConfig returns the TLS configuration shared by both listeners. When ACME or
+// a CertReloader is configured, certificates are served from it instead of
+// TLSConfig.Certificates; other TLSConfig settings still apply.
+func (s *Server) tlsConfig() *tls.Config {
+	var getCertificate func(*tls.ClientHelloInfo) (*tls.Certificate, error)
+	switch {
+	case s.acme != nil:
+		getCertificate = s.acme.GetCertificate
+	case s.cfg.CertReloader != nil:
+		getCertificate = s.cfg.CertReloader.GetCertificate
+	default:
+		return s.cfg.TLSConfig
+	}
+	if s.cfg.TLSConfig == nil {
+		return &tls.Config{
+			GetCertificate: getCertificate,
+			MinVersion:     tls.VersionTLS13,
+			NextProtos:     []string{"h3", "h2", "http/1.1"},
+		}
+	}
+	cfg := s.cfg.TLSConfig.Clone()
+	cfg.Certificates = nil
+	cfg.GetCertificate = getCertificate
+	return cfg
+}
+
+// handler returns the http.Handler shared by the H2 and H3 listeners: the mux,
+// limited to Config.MaxRequestBytes, wrapped by Config.HTTPMiddleware with the
+// first entry outermost.
+func (s *Server) handler() http.Handler {
+	var h http.Handler = s.mux
+	if s.cfg.MaxRequestBytes > 0 {
+		h = http.MaxBytesHandler(h, s.cfg.MaxRequestBytes)
+	}
+	for i := len(s.cfg.HTTPMiddleware) - 1; i >= 0; i-- {
+		h = s.cfg.HTTPMiddleware[i](h)
+	}
+	return h
+}
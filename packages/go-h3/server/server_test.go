@@ -0,0 +1,399 @@
+package server
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"connectrpc.com/connect"
+	"github.com/quic-go/quic-go/http3"
+	"go.uber.org/zap"
+	"golang.org/x/net/http2"
+)
+
+// startTestServer starts srv in the background and waits until every enabled
+// listener has bound. The server is shut down when the test finishes.
+func startTestServer(t *testing.T, srv *Server) {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- srv.Start(ctx) }()
+	t.Cleanup(func() {
+		cancel()
+		select {
+		case <-done:
+		case <-time.After(5 * time.Second):
+			t.Error("server did not shut down")
+		}
+	})
+
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		h2Ready := !srv.cfg.H2Enabled || srv.ListenAddr("h2") != ""
+		h3Ready := !srv.cfg.H3Enabled || srv.ListenAddr("h3") != ""
+		if h2Ready && h3Ready {
+			return
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatal("server listeners did not start")
+}
+
+// testConfig returns a Config with ephemeral loopback ports and a short grace period.
+func testConfig() Config {
+	cfg := DefaultConfig()
+	cfg.H2Addr = "127.0.0.1:0"
+	cfg.H3Addr = "127.0.0.1:0"
+	cfg.GracePeriod = time.Second
+	return cfg
+}
+
+// selfSignedTLSConfig returns a TLS 1.3 server config with a fresh self-signed
+// certificate valid for 127.0.0.1 and localhost.
+func selfSignedTLSConfig(t *testing.T) *tls.Config {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "localhost"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		DNSNames:     []string{"localhost"},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("create certificate: %v", err)
+	}
+	return &tls.Config{
+		Certificates: []tls.Certificate{{Certificate: [][]byte{der}, PrivateKey: key}},
+		MinVersion:   tls.VersionTLS13,
+		NextProtos:   []string{"h2", "http/1.1"},
+	}
+}
+
+// insecureH2Client returns an HTTP/2 client that skips certificate verification.
+func insecureH2Client() *http.Client {
+	return &http.Client{Transport: &http.Transport{
+		TLSClientConfig:   &tls.Config{InsecureSkipVerify: true}, //nolint:gosec // test-only self-signed cert
+		ForceAttemptHTTP2: true,
+	}}
+}
+
+func TestServer_ListenAddrReportsBoundPort(t *testing.T) {
+	cfg := testConfig()
+	cfg.H3Enabled = false
+	srv, err := New(cfg, zap.NewNop())
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	startTestServer(t, srv)
+
+	addr := srv.ListenAddr("h2")
+	if _, port, _ := net.SplitHostPort(addr); port == "" || port == "0" {
+		t.Errorf("expected a bound port, got %q", addr)
+	}
+	if got := srv.ListenAddr("h3"); got != "" {
+		t.Errorf("expected empty h3 address when disabled, got %q", got)
+	}
+}
+
+func TestServer_H2ListenFailureClosesH3(t *testing.T) {
+	busy, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	defer busy.Close()
+
+	cfg := testConfig()
+	cfg.TLSConfig = selfSignedTLSConfig(t)
+	cfg.H2Addr = busy.Addr().String()
+	srv, err := New(cfg, zap.NewNop())
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	if err := srv.Start(context.Background()); err == nil {
+		t.Fatal("expected Start to fail when the h2 address is in use")
+	}
+
+	h3Addr := srv.ListenAddr("h3")
+	if h3Addr == "" {
+		t.Fatal("expected the h3 listener to have been started")
+	}
+	pc, err := net.ListenPacket("udp", h3Addr)
+	if err != nil {
+		t.Fatalf("expected the h3 socket to be released, got %v", err)
+	}
+	pc.Close()
+}
+
+func TestServer_H2C(t *testing.T) {
+	cfg := testConfig()
+	cfg.H3Enabled = false
+	cfg.H2C = true
+	srv, err := New(cfg, zap.NewNop())
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	srv.Mux().HandleFunc("/proto", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(r.Proto))
+	})
+	startTestServer(t, srv)
+
+	client := &http.Client{Transport: &http2.Transport{
+		AllowHTTP: true,
+		DialTLSContext: func(ctx context.Context, network, addr string, _ *tls.Config) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, network, addr)
+		},
+	}}
+	resp, err := client.Get("http://" + srv.ListenAddr("h2") + "/proto")
+	if err != nil {
+		t.Fatalf("h2c request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.ProtoMajor != 2 {
+		t.Errorf("expected HTTP/2 response, got %s", resp.Proto)
+	}
+}
+
+func TestServer_WithoutH2C_RejectsPriorKnowledge(t *testing.T) {
+	cfg := testConfig()
+	cfg.H3Enabled = false
+	srv, err := New(cfg, zap.NewNop())
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	startTestServer(t, srv)
+
+	client := &http.Client{Transport: &http2.Transport{
+		AllowHTTP: true,
+		DialTLSContext: func(ctx context.Context, network, addr string, _ *tls.Config) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, network, addr)
+		},
+	}}
+	if resp, err := client.Get("http://" + srv.ListenAddr("h2") + "/"); err == nil {
+		resp.Body.Close()
+		t.Error("expected prior-knowledge HTTP/2 to fail without H2C")
+	}
+}
+
+func TestServer_AltSvcAdvertisesH3Port(t *testing.T) {
+	cfg := testConfig()
+	cfg.TLSConfig = selfSignedTLSConfig(t)
+	cfg.AltSvcMaxAge = time.Hour
+	srv, err := New(cfg, zap.NewNop())
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	srv.Mux().HandleFunc("/", func(w http.ResponseWriter, _ *http.Request) {})
+	startTestServer(t, srv)
+
+	resp, err := insecureH2Client().Get("https://" + srv.ListenAddr("h2") + "/")
+	if err != nil {
+		t.Fatalf("h2 request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	_, port, _ := net.SplitHostPort(srv.ListenAddr("h3"))
+	want := `h3=":` + port + `"; ma=3600`
+	if got := resp.Header.Get("Alt-Svc"); got != want {
+		t.Errorf("expected Alt-Svc %q, got %q", want, got)
+	}
+	if resp.ProtoMajor != 2 {
+		t.Errorf("expected HTTP/2 response, got %s", resp.Proto)
+	}
+}
+
+func TestServer_NoAltSvcWithoutH3(t *testing.T) {
+	cfg := testConfig()
+	cfg.H3Enabled = false
+	srv, err := New(cfg, zap.NewNop())
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	srv.Mux().HandleFunc("/", func(w http.ResponseWriter, _ *http.Request) {})
+	startTestServer(t, srv)
+
+	resp, err := http.Get("http://" + srv.ListenAddr("h2") + "/")
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if got := resp.Header.Get("Alt-Svc"); got != "" {
+		t.Errorf("expected no Alt-Svc header, got %q", got)
+	}
+}
+
+func TestAltSvcValue(t *testing.T) {
+	if got := altSvcValue("127.0.0.1:8443", 0); got != `h3=":8443"` {
+		t.Errorf("unexpected value without max-age: %q", got)
+	}
+	if got := altSvcValue("[::]:443", 24*time.Hour); got != `h3=":443"; ma=86400` {
+		t.Errorf("unexpected value with max-age: %q", got)
+	}
+	if got := altSvcValue("bad-addr", 0); got != "" {
+		t.Errorf("expected empty value for invalid address, got %q", got)
+	}
+}
+
+func TestServer_H3GracefulShutdownCompletesInFlight(t *testing.T) {
+	cfg := testConfig()
+	cfg.H2Enabled = false
+	cfg.TLSConfig = selfSignedTLSConfig(t)
+	cfg.GracePeriod = 5 * time.Second
+	srv, err := New(cfg, zap.NewNop())
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	started := make(chan struct{})
+	srv.Mux().HandleFunc("/slow", func(w http.ResponseWriter, _ *http.Request) {
+		close(started)
+		time.Sleep(300 * time.Millisecond)
+		_, _ = w.Write([]byte("done"))
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	stopped := make(chan error, 1)
+	go func() { stopped <- srv.Start(ctx) }()
+	for srv.ListenAddr("h3") == "" {
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	tr := &http3.Transport{TLSClientConfig: &tls.Config{InsecureSkipVerify: true}} //nolint:gosec // test-only self-signed cert
+	defer tr.Close()
+	client := &http.Client{Transport: tr}
+
+	type result struct {
+		body string
+		err  error
+	}
+	resc := make(chan result, 1)
+	go func() {
+		resp, err := client.Get("https://" + srv.ListenAddr("h3") + "/slow")
+		if err != nil {
+			resc <- result{err: err}
+			return
+		}
+		defer resp.Body.Close()
+		b, err := io.ReadAll(resp.Body)
+		resc <- result{body: string(b), err: err}
+	}()
+
+	<-started
+	cancel()
+
+	res := <-resc
+	if res.err != nil {
+		t.Fatalf("in-flight H3 request failed during shutdown: %v", res.err)
+	}
+	if res.body != "done" {
+		t.Errorf("expected body done, got %q", res.body)
+	}
+	select {
+	case err := <-stopped:
+		if err != nil {
+			t.Errorf("expected clean shutdown, got %v", err)
+		}
+	case <-time.After(cfg.GracePeriod):
+		t.Error("server did not stop within the grace period")
+	}
+}
+
+func TestServer_HTTPMiddlewareOrder(t *testing.T) {
+	var order []string
+	mw := func(name string) func(http.Handler) http.Handler {
+		return func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				order = append(order, name)
+				next.ServeHTTP(w, r)
+			})
+		}
+	}
+	cfg := testConfig()
+	cfg.H3Enabled = false
+	cfg.HTTPMiddleware = []func(http.Handler) http.Handler{mw("outer"), mw("inner")}
+	srv, err := New(cfg, zap.NewNop())
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	srv.Mux().HandleFunc("/", func(w http.ResponseWriter, _ *http.Request) { order = append(order, "mux") })
+	startTestServer(t, srv)
+
+	resp, err := http.Get("http://" + srv.ListenAddr("h2") + "/")
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	resp.Body.Close()
+
+	if len(order) != 3 || order[0] != "outer" || order[1] != "inner" || order[2] != "mux" {
+		t.Errorf("expected [outer inner mux], got %v", order)
+	}
+}
+
+// jsonCodec lets tests register Connect handlers for plain Go structs.
+type jsonCodec struct{}
+
+func (jsonCodec) Name() string                       { return "json" }
+func (jsonCodec) Marshal(v any) ([]byte, error)      { return json.Marshal(v) }
+func (jsonCodec) Unmarshal(data []byte, v any) error { return json.Unmarshal(data, v) }
+
+type echoMsg struct {
+	Text string `json:"text"`
+}
+
+func TestServer_MaxRequestBytes(t *testing.T) {
+	cfg := testConfig()
+	cfg.H3Enabled = false
+	cfg.MaxRequestBytes = 64
+	srv, err := New(cfg, zap.NewNop())
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	srv.Mux().Handle("/test.Echo/Echo", connect.NewUnaryHandler("/test.Echo/Echo",
+		func(_ context.Context, req *connect.Request[echoMsg]) (*connect.Response[echoMsg], error) {
+			return connect.NewResponse(req.Msg), nil
+		},
+		connect.WithCodec(jsonCodec{}),
+	))
+	startTestServer(t, srv)
+
+	client := connect.NewClient[echoMsg, echoMsg](http.DefaultClient,
+		"http://"+srv.ListenAddr("h2")+"/test.Echo/Echo",
+		connect.WithCodec(jsonCodec{}))
+
+	resp, err := client.CallUnary(context.Background(), connect.NewRequest(&echoMsg{Text: "small"}))
+	if err != nil {
+		t.Fatalf("expected in-limit request to succeed, got %v", err)
+	}
+	if resp.Msg.Text != "small" {
+		t.Errorf("expected echo of small, got %q", resp.Msg.Text)
+	}
+
+	_, err = client.CallUnary(context.Background(), connect.NewRequest(&echoMsg{Text: strings.Repeat("x", 256)}))
+	if connect.CodeOf(err) != connect.CodeResourceExhausted {
+		t.Errorf("expected CodeResourceExhausted for oversized request, got %v", err)
+	}
+}
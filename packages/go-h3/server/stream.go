@@ -0,0 +1,206 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"connectrpc.com/connect"
+	"go.uber.org/zap"
+)
+
+// NewStreamLoggingInterceptor returns a connect.Interceptor that logs unary RPCs
+// exactly like NewLoggingInterceptor and also logs streaming RPCs on open and on
+// completion, including message counts, duration, and status code.
+func NewStreamLoggingInterceptor(logger *zap.Logger) connect.Interceptor {
+	return &streamLoggingInterceptor{logger: logger, unary: NewLoggingInterceptor(logger)}
+}
+
+// NewStreamMetricsInterceptor returns a connect.Interceptor that records unary RPCs
+// exactly like NewMetricsInterceptor and also invokes counterFn and histogramFn
+// once per streaming RPC when the stream completes.
+func NewStreamMetricsInterceptor(
+	counterFn func(procedure, protocol, code string),
+	histogramFn func(procedure, protocol string, durationSec float64),
+) connect.Interceptor {
+	return &streamMetricsInterceptor{
+		counterFn:   counterFn,
+		histogramFn: histogramFn,
+		unary:       NewMetricsInterceptor(counterFn, histogramFn),
+	}
+}
+
+// streamLoggingInterceptor implements connect.Interceptor for request logging.
+type streamLoggingInterceptor struct {
+	logger *zap.Logger
+	unary  connect.UnaryInterceptorFunc
+}
+
+func (i *streamLoggingInterceptor) WrapUnary(next connect.UnaryFunc) connect.UnaryFunc {
+	return i.unary(next)
+}
+
+func (i *streamLoggingInterceptor) WrapStreamingHandler(next connect.StreamingHandlerFunc) connect.StreamingHandlerFunc {
+	return func(ctx context.Context, conn connect.StreamingHandlerConn) error {
+		procedure := conn.Spec().Procedure
+		protocol := conn.Peer().Protocol
+		i.logger.Debug("stream opened",
+			zap.String("procedure", procedure),
+			zap.String("protocol", protocol),
+		)
+
+		start := time.Now()
+		counted := &countingHandlerConn{StreamingHandlerConn: conn}
+		err := next(ctx, counted)
+		i.logStreamEnd(procedure, protocol, time.Since(start), counted.sent.Load(), counted.received.Load(), err)
+		return err
+	}
+}
+
+func (i *streamLoggingInterceptor) WrapStreamingClient(next connect.StreamingClientFunc) connect.StreamingClientFunc {
+	return func(ctx context.Context, spec connect.Spec) connect.StreamingClientConn {
+		conn := next(ctx, spec)
+		start := time.Now()
+		counted := &countingClientConn{StreamingClientConn: conn}
+		counted.onClose = func(err error) {
+			i.logStreamEnd(spec.Procedure, conn.Peer().Protocol, time.Since(start), counted.sent.Load(), counted.received.Load(), err)
+		}
+		return counted
+	}
+}
+
+// logStreamEnd writes the completion log line for a stream.
+func (i *streamLoggingInterceptor) logStreamEnd(procedure, protocol string, duration time.Duration, sent, received int64, err error) {
+	fields := []zap.Field{
+		zap.String("procedure", procedure),
+		zap.String("protocol", protocol),
+		zap.Duration("duration", duration),
+		zap.Int64("messages_sent", sent),
+		zap.Int64("messages_received", received),
+		zap.String("code", streamCode(err)),
+	}
+	if err != nil {
+		i.logger.Warn("stream failed", append(fields, zap.Error(err))...)
+		return
+	}
+	i.logger.Info("stream completed", fields...)
+}
+
+// streamMetricsInterceptor implements connect.Interceptor for request metrics.
+type streamMetricsInterceptor struct {
+	counterFn   func(procedure, protocol, code string)
+	histogramFn func(procedure, protocol string, durationSec float64)
+	unary       connect.UnaryInterceptorFunc
+}
+
+func (i *streamMetricsInterceptor) WrapUnary(next connect.UnaryFunc) connect.UnaryFunc {
+	return i.unary(next)
+}
+
+func (i *streamMetricsInterceptor) WrapStreamingHandler(next connect.StreamingHandlerFunc) connect.StreamingHandlerFunc {
+	return func(ctx context.Context, conn connect.StreamingHandlerConn) error {
+		start := time.Now()
+		err := next(ctx, conn)
+		i.record(conn.Spec().Procedure, conn.Peer().Protocol, time.Since(start), err)
+		return err
+	}
+}
+
+func (i *streamMetricsInterceptor) WrapStreamingClient(next connect.StreamingClientFunc) connect.StreamingClientFunc {
+	return func(ctx context.Context, spec connect.Spec) connect.StreamingClientConn {
+		conn := next(ctx, spec)
+		start := time.Now()
+		counted := &countingClientConn{StreamingClientConn: conn}
+		counted.onClose = func(err error) {
+			i.record(spec.Procedure, conn.Peer().Protocol, time.Since(start), err)
+		}
+		return counted
+	}
+}
+
+func (i *streamMetricsInterceptor) record(procedure, protocol string, duration time.Duration, err error) {
+	i.counterFn(procedure, protocol, streamCode(err))
+	i.histogramFn(procedure, protocol, duration.Seconds())
+}
+
+// streamCode maps a stream's terminal error to the code string used by the
+// metrics and logging interceptors.
+func streamCode(err error) string {
+	if err == nil {
+		return "ok"
+	}
+	return connect.CodeOf(err).String()
+}
+
+// countingHandlerConn counts messages passing through a server-side stream.
+type countingHandlerConn struct {
+	connect.StreamingHandlerConn
+	sent     atomic.Int64
+	received atomic.Int64
+}
+
+func (c *countingHandlerConn) Send(msg any) error {
+	err := c.StreamingHandlerConn.Send(msg)
+	if err == nil {
+		c.sent.Add(1)
+	}
+	return err
+}
+
+func (c *countingHandlerConn) Receive(msg any) error {
+	err := c.StreamingHandlerConn.Receive(msg)
+	if err == nil {
+		c.received.Add(1)
+	}
+	return err
+}
+
+// countingClientConn counts messages on a client-side stream and invokes onClose
+// once when the response side is closed, passing the first non-EOF receive error.
+type countingClientConn struct {
+	connect.StreamingClientConn
+	sent     atomic.Int64
+	received atomic.Int64
+	onClose  func(err error)
+
+	mu      sync.Mutex
+	recvErr error
+	once    sync.Once
+}
+
+func (c *countingClientConn) Send(msg any) error {
+	err := c.StreamingClientConn.Send(msg)
+	if err == nil {
+		c.sent.Add(1)
+	}
+	return err
+}
+
+func (c *countingClientConn) Receive(msg any) error {
+	err := c.StreamingClientConn.Receive(msg)
+	switch {
+	case err == nil:
+		c.received.Add(1)
+	case !errors.Is(err, io.EOF):
+		c.mu.Lock()
+		if c.recvErr == nil {
+			c.recvErr = err
+		}
+		c.mu.Unlock()
+	}
+	return err
+}
+
+func (c *countingClientConn) CloseResponse() error {
+	err := c.StreamingClientConn.CloseResponse()
+	c.once.Do(func() {
+		c.mu.Lock()
+		recvErr := c.recvErr
+		c.mu.Unlock()
+		c.onClose(recvErr)
+	})
+	return err
+}
@@ -0,0 +1,176 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"testing"
+
+	"connectrpc.com/connect"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+// fakeHandlerConn is a minimal StreamingHandlerConn that serves queued messages.
+type fakeHandlerConn struct {
+	inbound int
+	sent    int
+}
+
+func (c *fakeHandlerConn) Spec() connect.Spec {
+	return connect.Spec{Procedure: "/svc.Foo/Watch", StreamType: connect.StreamTypeBidi}
+}
+func (c *fakeHandlerConn) Peer() connect.Peer { return connect.Peer{Protocol: connect.ProtocolConnect} }
+func (c *fakeHandlerConn) Receive(any) error {
+	if c.inbound == 0 {
+		return io.EOF
+	}
+	c.inbound--
+	return nil
+}
+func (c *fakeHandlerConn) RequestHeader() http.Header   { return http.Header{} }
+func (c *fakeHandlerConn) Send(any) error               { c.sent++; return nil }
+func (c *fakeHandlerConn) ResponseHeader() http.Header  { return http.Header{} }
+func (c *fakeHandlerConn) ResponseTrailer() http.Header { return http.Header{} }
+
+// echoStream receives every inbound message and sends one reply per message.
+func echoStream(_ context.Context, conn connect.StreamingHandlerConn) error {
+	for {
+		if err := conn.Receive(nil); err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return err
+		}
+		if err := conn.Send(nil); err != nil {
+			return err
+		}
+	}
+}
+
+func TestStreamLoggingInterceptor_LogsStreamCompletion(t *testing.T) {
+	core, logs := observer.New(zap.DebugLevel)
+	interceptor := NewStreamLoggingInterceptor(zap.New(core))
+
+	handler := interceptor.WrapStreamingHandler(echoStream)
+	if err := handler(context.Background(), &fakeHandlerConn{inbound: 3}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if logs.FilterMessage("stream opened").Len() != 1 {
+		t.Error("expected a stream opened log entry")
+	}
+	done := logs.FilterMessage("stream completed").All()
+	if len(done) != 1 {
+		t.Fatalf("expected 1 stream completed entry, got %d", len(done))
+	}
+	fields := done[0].ContextMap()
+	if fields["messages_received"] != int64(3) || fields["messages_sent"] != int64(3) {
+		t.Errorf("expected 3 messages each way, got received=%v sent=%v", fields["messages_received"], fields["messages_sent"])
+	}
+	if fields["procedure"] != "/svc.Foo/Watch" {
+		t.Errorf("expected procedure /svc.Foo/Watch, got %v", fields["procedure"])
+	}
+	if fields["code"] != "ok" {
+		t.Errorf("expected code ok, got %v", fields["code"])
+	}
+}
+
+func TestStreamLoggingInterceptor_LogsStreamFailure(t *testing.T) {
+	core, logs := observer.New(zap.DebugLevel)
+	interceptor := NewStreamLoggingInterceptor(zap.New(core))
+
+	handler := interceptor.WrapStreamingHandler(func(context.Context, connect.StreamingHandlerConn) error {
+		return connect.NewError(connect.CodeUnavailable, errors.New("backend down"))
+	})
+	_ = handler(context.Background(), &fakeHandlerConn{})
+
+	failed := logs.FilterMessage("stream failed").All()
+	if len(failed) != 1 {
+		t.Fatalf("expected 1 stream failed entry, got %d", len(failed))
+	}
+	if code := failed[0].ContextMap()["code"]; code != "unavailable" {
+		t.Errorf("expected code unavailable, got %v", code)
+	}
+}
+
+func TestStreamLoggingInterceptor_UnaryUnchanged(t *testing.T) {
+	core, logs := observer.New(zap.DebugLevel)
+	interceptor := NewStreamLoggingInterceptor(zap.New(core))
+
+	wrapped := interceptor.WrapUnary(func(context.Context, connect.AnyRequest) (connect.AnyResponse, error) {
+		return nil, nil
+	})
+	_, _ = wrapped(context.Background(), connect.NewRequest(&struct{}{}))
+
+	if logs.FilterMessage("rpc completed").Len() != 1 {
+		t.Error("expected unary RPC to log rpc completed")
+	}
+}
+
+func TestStreamMetricsInterceptor_RecordsOnCompletion(t *testing.T) {
+	var codes []string
+	var durations []float64
+	interceptor := NewStreamMetricsInterceptor(
+		func(procedure, protocol, code string) { codes = append(codes, code) },
+		func(procedure, protocol string, d float64) { durations = append(durations, d) },
+	)
+
+	handler := interceptor.WrapStreamingHandler(echoStream)
+	_ = handler(context.Background(), &fakeHandlerConn{inbound: 2})
+
+	if len(codes) != 1 || codes[0] != "ok" {
+		t.Errorf("expected one ok counter call, got %v", codes)
+	}
+	if len(durations) != 1 || durations[0] < 0 {
+		t.Errorf("expected one non-negative duration, got %v", durations)
+	}
+}
+
+// fakeClientConn is a minimal StreamingClientConn that returns recvErr after
+// delivering inbound messages.
+type fakeClientConn struct {
+	inbound int
+	recvErr error
+}
+
+func (c *fakeClientConn) Spec() connect.Spec           { return connect.Spec{Procedure: "/svc.Foo/Watch"} }
+func (c *fakeClientConn) Peer() connect.Peer           { return connect.Peer{Protocol: connect.ProtocolGRPC} }
+func (c *fakeClientConn) Send(any) error               { return nil }
+func (c *fakeClientConn) RequestHeader() http.Header   { return http.Header{} }
+func (c *fakeClientConn) CloseRequest() error          { return nil }
+func (c *fakeClientConn) ResponseHeader() http.Header  { return http.Header{} }
+func (c *fakeClientConn) ResponseTrailer() http.Header { return http.Header{} }
+func (c *fakeClientConn) CloseResponse() error         { return nil }
+func (c *fakeClientConn) Receive(any) error {
+	if c.inbound == 0 {
+		return c.recvErr
+	}
+	c.inbound--
+	return nil
+}
+
+func TestStreamMetricsInterceptor_ClientRecordsOnClose(t *testing.T) {
+	var codes []string
+	interceptor := NewStreamMetricsInterceptor(
+		func(procedure, protocol, code string) { codes = append(codes, code) },
+		func(string, string, float64) {},
+	)
+
+	clientFn := interceptor.WrapStreamingClient(func(context.Context, connect.Spec) connect.StreamingClientConn {
+		return &fakeClientConn{inbound: 1, recvErr: connect.NewError(connect.CodeAborted, errors.New("aborted"))}
+	})
+	conn := clientFn(context.Background(), connect.Spec{Procedure: "/svc.Foo/Watch"})
+	_ = conn.Receive(nil)
+	_ = conn.Receive(nil)
+	if len(codes) != 0 {
+		t.Fatal("expected no metrics before the response is closed")
+	}
+	_ = conn.CloseResponse()
+	_ = conn.CloseResponse()
+
+	if len(codes) != 1 || codes[0] != "aborted" {
+		t.Errorf("expected a single aborted counter call, got %v", codes)
+	}
+}
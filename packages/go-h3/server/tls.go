@@ -12,11 +12,69 @@ func NewTLSConfig(certPath, keyPath string) (*tls.Config, error) {
 	if err != nil {
 		return nil, fmt.Errorf("loading TLS keypair: %w", err)
 	}
+	return newTLSConfig(cert), nil
+}
+
+// NewTLSConfigFromPEM creates a TLS 1.3 configuration from PEM-encoded cert and
+// key data.
+func NewTLSConfigFromPEM(certPEM, keyPEM []byte) (*tls.Config, error) {
+	cert, err := tls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		return nil, fmt.Errorf("parsing TLS keypair: %w", err)
+	}
+	return newTLSConfig(cert), nil
+}
+
+func newTLSConfig(cert tls.Certificate) *tls.Config {
 	return &tls.Config{
 		Certificates: []tls.Certificate{cert},
 		MinVersion:   tls.VersionTLS13,
 		NextProtos:   []string{"h3", "h2", "http/1.1"},
-	}, nil
+	}
+}
+
+// tlsConfigFromEnv loads a TLS config from TLS_CERT_FILE/TLS_KEY_FILE (or the
+// older TLS_CERT_PATH/TLS_KEY_PATH) or from inline TLS_CERT_PEM/TLS_KEY_PEM.
+// It returns nil when none are set and an error when only a cert or only a key
+// is provided.
+func tlsConfigFromEnv() (*tls.Config, error) {
+	certFile := envOrDefault("TLS_CERT_FILE", envOrDefault("TLS_CERT_PATH", ""))
+	keyFile := envOrDefault("TLS_KEY_FILE", envOrDefault("TLS_KEY_PATH", ""))
+	certPEM := envOrDefault("TLS_CERT_PEM", "")
+	keyPEM := envOrDefault("TLS_KEY_PEM", "")
+
+	hasCert := certFile != "" || certPEM != ""
+	hasKey := keyFile != "" || keyPEM != ""
+	switch {
+	case !hasCert && !hasKey:
+		return nil, nil
+	case !hasKey:
+		return nil, fmt.Errorf("TLS certificate configured without a key: set TLS_KEY_FILE or TLS_KEY_PEM")
+	case !hasCert:
+		return nil, fmt.Errorf("TLS key configured without a certificate: set TLS_CERT_FILE or TLS_CERT_PEM")
+	}
+
+	certData, err := envPEM("certificate", certFile, certPEM)
+	if err != nil {
+		return nil, err
+	}
+	keyData, err := envPEM("key", keyFile, keyPEM)
+	if err != nil {
+		return nil, err
+	}
+	return NewTLSConfigFromPEM(certData, keyData)
+}
+
+// envPEM returns inline PEM data if set, otherwise the contents of file.
+func envPEM(what, file, inline string) ([]byte, error) {
+	if inline != "" {
+		return []byte(inline), nil
+	}
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("reading TLS %s: %w", what, err)
+	}
+	return data, nil
 }
 
 // envOrDefault returns the environment variable value or a default.
@@ -0,0 +1,60 @@
+package server
+
+import (
+	"context"
+
+	"connectrpc.com/connect"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// traceContext propagates W3C traceparent/tracestate headers.
+var traceContext = propagation.TraceContext{}
+
+// NewTracingInterceptor returns a ConnectRPC interceptor that starts a span per RPC
+// named after the procedure. On handlers it continues the trace carried by the
+// incoming traceparent header; on clients it injects traceparent into the outgoing
+// request. Spans record the protocol, the status code, and the correlation ID set
+// by NewCorrelationInterceptor (which should run first), and end with an error
+// status when the RPC fails.
+func NewTracingInterceptor(tracer trace.Tracer) connect.UnaryInterceptorFunc {
+	return func(next connect.UnaryFunc) connect.UnaryFunc {
+		return func(ctx context.Context, req connect.AnyRequest) (connect.AnyResponse, error) {
+			spec := req.Spec()
+			kind := trace.SpanKindServer
+			if spec.IsClient {
+				kind = trace.SpanKindClient
+			} else {
+				ctx = traceContext.Extract(ctx, propagation.HeaderCarrier(req.Header()))
+			}
+
+			ctx, span := tracer.Start(ctx, spec.Procedure, trace.WithSpanKind(kind))
+			defer span.End()
+
+			span.SetAttributes(
+				attribute.String("rpc.system", "connect_rpc"),
+				attribute.String("rpc.method", spec.Procedure),
+				attribute.String("rpc.connect_rpc.protocol", req.Peer().Protocol),
+			)
+			if cid := CorrelationIDFromContext(ctx); cid != "" {
+				span.SetAttributes(attribute.String("correlation_id", cid))
+			}
+			if spec.IsClient {
+				traceContext.Inject(ctx, propagation.HeaderCarrier(req.Header()))
+			}
+
+			resp, err := next(ctx, req)
+
+			span.SetAttributes(attribute.String("rpc.connect_rpc.status_code", streamCode(err)))
+			if err != nil {
+				span.RecordError(err)
+				span.SetStatus(codes.Error, err.Error())
+			} else {
+				span.SetStatus(codes.Ok, "")
+			}
+			return resp, err
+		}
+	}
+}
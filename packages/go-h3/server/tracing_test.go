@@ -0,0 +1,121 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"connectrpc.com/connect"
+	"github.com/penguintechinc/penguin-libs/packages/go-common/logging"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// procedureRequest overrides Spec so tests can exercise procedure-dependent behaviour.
+type procedureRequest struct {
+	*connect.Request[struct{}]
+	spec connect.Spec
+}
+
+func (r procedureRequest) Spec() connect.Spec { return r.spec }
+
+func newProcedureRequest(procedure string) procedureRequest {
+	return procedureRequest{
+		Request: connect.NewRequest(&struct{}{}),
+		spec:    connect.Spec{Procedure: procedure},
+	}
+}
+
+func newRecordingTracer() (trace.Tracer, *tracetest.SpanRecorder) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	return provider.Tracer("test"), recorder
+}
+
+func spanAttr(span sdktrace.ReadOnlySpan, key string) attribute.Value {
+	for _, kv := range span.Attributes() {
+		if string(kv.Key) == key {
+			return kv.Value
+		}
+	}
+	return attribute.Value{}
+}
+
+func TestTracingInterceptor_RecordsSpan(t *testing.T) {
+	tracer, recorder := newRecordingTracer()
+	interceptor := NewTracingInterceptor(tracer)
+	wrapped := interceptor(func(ctx context.Context, req connect.AnyRequest) (connect.AnyResponse, error) {
+		return nil, nil
+	})
+
+	ctx := logging.ContextWithCorrelationID(context.Background(), "corr-42")
+	_, _ = wrapped(ctx, newProcedureRequest("/svc.Foo/Bar"))
+
+	spans := recorder.Ended()
+	if len(spans) != 1 {
+		t.Fatalf("expected 1 span, got %d", len(spans))
+	}
+	span := spans[0]
+	if span.Name() != "/svc.Foo/Bar" {
+		t.Errorf("expected span name /svc.Foo/Bar, got %q", span.Name())
+	}
+	if span.SpanKind() != trace.SpanKindServer {
+		t.Errorf("expected server span, got %v", span.SpanKind())
+	}
+	if got := spanAttr(span, "correlation_id").AsString(); got != "corr-42" {
+		t.Errorf("expected correlation_id corr-42, got %q", got)
+	}
+	if got := spanAttr(span, "rpc.connect_rpc.status_code").AsString(); got != "ok" {
+		t.Errorf("expected status code ok, got %q", got)
+	}
+	if span.Status().Code != codes.Ok {
+		t.Errorf("expected Ok status, got %v", span.Status().Code)
+	}
+}
+
+func TestTracingInterceptor_ErrorStatus(t *testing.T) {
+	tracer, recorder := newRecordingTracer()
+	interceptor := NewTracingInterceptor(tracer)
+	wrapped := interceptor(func(ctx context.Context, req connect.AnyRequest) (connect.AnyResponse, error) {
+		return nil, connect.NewError(connect.CodeNotFound, errors.New("missing"))
+	})
+
+	_, _ = wrapped(context.Background(), newProcedureRequest("/svc.Foo/Get"))
+
+	span := recorder.Ended()[0]
+	if span.Status().Code != codes.Error {
+		t.Errorf("expected Error status, got %v", span.Status().Code)
+	}
+	if got := spanAttr(span, "rpc.connect_rpc.status_code").AsString(); got != "not_found" {
+		t.Errorf("expected status code not_found, got %q", got)
+	}
+}
+
+func TestTracingInterceptor_ContinuesIncomingTrace(t *testing.T) {
+	tracer, recorder := newRecordingTracer()
+	interceptor := NewTracingInterceptor(tracer)
+
+	var handlerSpan trace.SpanContext
+	wrapped := interceptor(func(ctx context.Context, req connect.AnyRequest) (connect.AnyResponse, error) {
+		handlerSpan = trace.SpanContextFromContext(ctx)
+		return nil, nil
+	})
+
+	req := newProcedureRequest("/svc.Foo/Bar")
+	req.Header().Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	_, _ = wrapped(context.Background(), req)
+
+	span := recorder.Ended()[0]
+	if got := span.SpanContext().TraceID().String(); got != "4bf92f3577b34da6a3ce929d0e0e4736" {
+		t.Errorf("expected span to join incoming trace, got trace ID %s", got)
+	}
+	if got := span.Parent().SpanID().String(); got != "00f067aa0ba902b7" {
+		t.Errorf("expected parent span ID 00f067aa0ba902b7, got %s", got)
+	}
+	if handlerSpan.SpanID() != span.SpanContext().SpanID() {
+		t.Error("expected handler context to carry the RPC span")
+	}
+}
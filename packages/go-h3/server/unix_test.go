@@ -0,0 +1,159 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// unixClient returns an HTTP client that dials the unix socket at path.
+func unixClient(path string) *http.Client {
+	return &http.Client{Transport: &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, "unix", path)
+		},
+	}}
+}
+
+func TestServer_UnixSocket(t *testing.T) {
+	sock := filepath.Join(t.TempDir(), "h3.sock")
+	cfg := testConfig()
+	cfg.H2Enabled = false
+	cfg.H3Enabled = false
+	cfg.UnixSocketPath = sock
+	srv, err := New(cfg, zap.NewNop())
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	srv.Mux().HandleFunc("/hello", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte("hi"))
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	stopped := make(chan error, 1)
+	go func() { stopped <- srv.Start(ctx) }()
+	deadline := time.Now().Add(5 * time.Second)
+	for srv.ListenAddr("unix") == "" {
+		if time.Now().After(deadline) {
+			t.Fatal("unix listener did not start")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	if got := srv.ListenAddr("unix"); got != sock {
+		t.Errorf("ListenAddr(unix) = %q, want %q", got, sock)
+	}
+
+	client := unixClient(sock)
+	resp, err := client.Get("http://unix/hello")
+	if err != nil {
+		t.Fatalf("request over unix socket failed: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if string(body) != "hi" {
+		t.Errorf("body = %q, want hi", body)
+	}
+	client.CloseIdleConnections()
+
+	cancel()
+	select {
+	case err := <-stopped:
+		if err != nil {
+			t.Fatalf("expected clean shutdown, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("server did not shut down")
+	}
+	if _, err := os.Stat(sock); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("expected socket file to be removed after shutdown, stat err = %v", err)
+	}
+}
+
+func TestServer_UnixSocketReplacesStaleSocket(t *testing.T) {
+	sock := filepath.Join(t.TempDir(), "h3.sock")
+	stale, err := net.Listen("unix", sock)
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	// Simulate a crashed process: keep the file but drop the listener.
+	stale.(*net.UnixListener).SetUnlinkOnClose(false)
+	stale.Close()
+
+	cfg := testConfig()
+	cfg.H3Enabled = false
+	cfg.UnixSocketPath = sock
+	srv, err := New(cfg, zap.NewNop())
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	startTestServer(t, srv)
+
+	if srv.ListenAddr("unix") != sock {
+		t.Fatalf("expected unix listener at %s", sock)
+	}
+	resp, err := unixClient(sock).Get("http://unix/")
+	if err != nil {
+		t.Fatalf("request over unix socket failed: %v", err)
+	}
+	resp.Body.Close()
+}
+
+func TestServer_UnixSocketRefusesRegularFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "not-a-socket")
+	if err := os.WriteFile(path, []byte("data"), 0o600); err != nil {
+		t.Fatalf("write file: %v", err)
+	}
+
+	cfg := testConfig()
+	cfg.H2Enabled = false
+	cfg.H3Enabled = false
+	cfg.UnixSocketPath = path
+	srv, err := New(cfg, zap.NewNop())
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	if err := srv.Start(context.Background()); err == nil {
+		t.Fatal("expected Start to fail for a regular file")
+	}
+	if _, err := os.Stat(path); err != nil {
+		t.Errorf("expected regular file to be left in place: %v", err)
+	}
+}
+
+func TestServer_UnixSocketFailureClosesH2(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "not-a-socket")
+	if err := os.WriteFile(path, []byte("data"), 0o600); err != nil {
+		t.Fatalf("write file: %v", err)
+	}
+
+	cfg := testConfig()
+	cfg.H3Enabled = false
+	cfg.UnixSocketPath = path
+	srv, err := New(cfg, zap.NewNop())
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	if err := srv.Start(context.Background()); err == nil {
+		t.Fatal("expected Start to fail for a regular file")
+	}
+
+	h2Addr := srv.ListenAddr("h2")
+	if h2Addr == "" {
+		t.Fatal("expected the h2 listener to have been started")
+	}
+	if conn, err := net.DialTimeout("tcp", h2Addr, time.Second); err == nil {
+		conn.Close()
+		t.Error("expected the h2 listener to be closed after Start failed")
+	}
+}
@@ -0,0 +1,89 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/quic-go/quic-go"
+	"github.com/quic-go/webtransport-go"
+	"go.uber.org/zap"
+)
+
+// webTransportProtocol is the :protocol pseudo-header of an extended CONNECT
+// request opening a WebTransport session; http3 exposes it as Request.Proto.
+const webTransportProtocol = "webtransport"
+
+// WebTransportHandler serves an established WebTransport session. r is the
+// CONNECT request that opened it. The session is closed when the handler
+// returns.
+type WebTransportHandler func(sess *webtransport.Session, r *http.Request)
+
+// HandleWebTransport registers h for WebTransport sessions opened at pattern on
+// the HTTP/3 listener. WebTransport routes live on their own mux: they never
+// match regular requests, and routes registered via Mux never receive
+// WebTransport CONNECT requests. Register handlers before calling Start;
+// Start fails if any are registered while HTTP/3 is disabled.
+//
+// Upgrade requests carrying an Origin header are only accepted when it
+// matches the request's host.
+func (s *Server) HandleWebTransport(pattern string, h WebTransportHandler) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.wtMux == nil {
+		s.wtMux = http.NewServeMux()
+	}
+	s.wtMux.HandleFunc(pattern, func(w http.ResponseWriter, r *http.Request) {
+		sess, err := s.wt.Upgrade(w, r)
+		if err != nil {
+			s.logger.Warn("webtransport upgrade failed", zap.String("path", r.URL.Path), zap.Error(err))
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		defer sess.CloseWithError(0, "")
+		h(sess, r)
+	})
+}
+
+// enableWebTransport wraps the HTTP/3 server so WebTransport CONNECT requests
+// are dispatched to the WebTransport mux. Callers must hold s.mu and call it
+// after s.h3 is fully configured, since it chains s.h3.ConnContext.
+func (s *Server) enableWebTransport() {
+	s.wt = &webtransport.Server{H3: s.h3}
+	webtransport.ConfigureHTTP3Server(s.h3)
+	s.h3.QUICConfig.EnableDatagrams = true
+	s.h3.QUICConfig.EnableStreamResetPartialDelivery = true
+	s.h3.Handler = webTransportDispatch(s.wtMux, s.h3.Handler)
+}
+
+// serveWebTransport accepts QUIC connections from ln and serves each with the
+// WebTransport server, which handles regular HTTP/3 requests on them too. It
+// returns nil once ln is closed.
+func (s *Server) serveWebTransport(ln *quic.EarlyListener) error {
+	for {
+		conn, err := ln.Accept(context.Background())
+		if err != nil {
+			if errors.Is(err, quic.ErrServerClosed) {
+				return nil
+			}
+			return err
+		}
+		go func() {
+			if err := s.wt.ServeQUICConn(conn); err != nil {
+				s.logger.Debug("webtransport connection failed", zap.Error(err))
+			}
+		}()
+	}
+}
+
+// webTransportDispatch routes WebTransport session requests to wt and all other
+// requests to next.
+func webTransportDispatch(wt, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodConnect && r.Proto == webTransportProtocol {
+			wt.ServeHTTP(w, r)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
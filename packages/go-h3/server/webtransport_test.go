@@ -0,0 +1,108 @@
+package server
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/quic-go/quic-go"
+	"github.com/quic-go/webtransport-go"
+	"go.uber.org/zap"
+)
+
+func webTransportDialer() *webtransport.Dialer {
+	return &webtransport.Dialer{
+		TLSClientConfig: &tls.Config{InsecureSkipVerify: true, NextProtos: []string{"h3"}}, //nolint:gosec // test only
+		QUICConfig: &quic.Config{
+			EnableDatagrams:                  true,
+			EnableStreamResetPartialDelivery: true,
+		},
+	}
+}
+
+func TestServer_WebTransportEchoDatagram(t *testing.T) {
+	cfg := testConfig()
+	cfg.H2Enabled = false
+	cfg.TLSConfig = selfSignedTLSConfig(t)
+	srv, err := New(cfg, zap.NewNop())
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	srv.Mux().HandleFunc("/echo", func(w http.ResponseWriter, _ *http.Request) {
+		t.Error("regular mux must not receive WebTransport requests")
+	})
+	srv.HandleWebTransport("/echo", func(sess *webtransport.Session, _ *http.Request) {
+		msg, err := sess.ReceiveDatagram(sess.Context())
+		if err != nil {
+			return
+		}
+		_ = sess.SendDatagram(msg)
+		<-sess.Context().Done()
+	})
+	startTestServer(t, srv)
+
+	d := webTransportDialer()
+	defer d.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	url := fmt.Sprintf("https://%s/echo", srv.ListenAddr("h3"))
+	resp, sess, err := d.Dial(ctx, url, nil)
+	if err != nil {
+		t.Fatalf("Dial: %v", err)
+	}
+	defer sess.CloseWithError(0, "")
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected 200, got %d", resp.StatusCode)
+	}
+
+	if err := sess.SendDatagram([]byte("ping")); err != nil {
+		t.Fatalf("SendDatagram: %v", err)
+	}
+	got, err := sess.ReceiveDatagram(ctx)
+	if err != nil {
+		t.Fatalf("ReceiveDatagram: %v", err)
+	}
+	if string(got) != "ping" {
+		t.Errorf("expected echoed %q, got %q", "ping", got)
+	}
+}
+
+func TestServer_WebTransportUnknownPath(t *testing.T) {
+	cfg := testConfig()
+	cfg.H2Enabled = false
+	cfg.TLSConfig = selfSignedTLSConfig(t)
+	srv, err := New(cfg, zap.NewNop())
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	srv.HandleWebTransport("/echo", func(*webtransport.Session, *http.Request) {})
+	startTestServer(t, srv)
+
+	d := webTransportDialer()
+	defer d.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	resp, _, err := d.Dial(ctx, fmt.Sprintf("https://%s/other", srv.ListenAddr("h3")), nil)
+	if err == nil {
+		t.Fatal("expected dial to an unregistered path to fail")
+	}
+	if resp != nil && resp.StatusCode != http.StatusNotFound {
+		t.Errorf("expected 404, got %d", resp.StatusCode)
+	}
+}
+
+func TestServer_WebTransportRequiresH3(t *testing.T) {
+	cfg := testConfig()
+	cfg.H3Enabled = false
+	srv, err := New(cfg, zap.NewNop())
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	srv.HandleWebTransport("/echo", func(*webtransport.Session, *http.Request) {})
+	if err := srv.Start(context.Background()); err == nil {
+		t.Fatal("expected Start to fail without HTTP/3")
+	}
+}